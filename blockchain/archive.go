@@ -0,0 +1,206 @@
+package blockchain
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// Format archive chain (JSON lines):
+//
+//	{"type":"manifest", ...}
+//	{"type":"block","block":{...}}   satu baris per block
+//	{"type":"signature","sha256":...} sha256 semua baris sebelumnya, ditandatangani node
+const (
+	archiveFormat  = "doctracker-chain"
+	archiveVersion = 1
+)
+
+var ErrInvalidArchive = errors.New("invalid chain archive")
+
+// ArchiveManifest baris pertama archive
+type ArchiveManifest struct {
+	Type      string `json:"type"`
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	Height    int    `json:"height"`
+	Blocks    int    `json:"blocks"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type archiveLine struct {
+	Type      string        `json:"type"`
+	Block     *models.Block `json:"block,omitempty"`
+	SHA256    string        `json:"sha256,omitempty"`
+	Signature string        `json:"signature,omitempty"`
+	PublicKey string        `json:"public_key,omitempty"`
+}
+
+// Archive hasil ReadArchive
+type Archive struct {
+	Manifest ArchiveManifest
+	Blocks   []models.Block
+	Signer   string // fingerprint key signing node pembuat archive
+}
+
+// ExportArchive menulis blocks sebagai archive bertanda tangan key signing node
+func ExportArchive(w io.Writer, blocks []models.Block) error {
+	if len(blocks) == 0 {
+		return ErrNoChain
+	}
+
+	h := sha256.New()
+	out := io.MultiWriter(w, h)
+	manifest := ArchiveManifest{
+		Type:      "manifest",
+		Format:    archiveFormat,
+		Version:   archiveVersion,
+		Height:    blocks[len(blocks)-1].Index,
+		Blocks:    len(blocks),
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := writeLine(out, manifest); err != nil {
+		return err
+	}
+	for i := range blocks {
+		if err := writeLine(out, archiveLine{Type: "block", Block: &blocks[i]}); err != nil {
+			return err
+		}
+	}
+
+	digest := h.Sum(nil)
+	sig, err := keymanager.Sign(digest)
+	if err != nil {
+		return fmt.Errorf("failed to sign archive: %v", err)
+	}
+	pub, err := keymanager.PublicKey()
+	if err != nil {
+		return err
+	}
+	return writeLine(w, archiveLine{
+		Type:      "signature",
+		SHA256:    hex.EncodeToString(digest),
+		Signature: hex.EncodeToString(sig),
+		PublicKey: hex.EncodeToString(utils.SerializePublicKey(pub)),
+	})
+}
+
+// ReadArchive membaca archive dan memverifikasi signature-nya. trusted berisi
+// fingerprint signer yang diterima; nil berarti signer tidak dibatasi.
+// Isi chain belum diverifikasi, gunakan VerifyChain.
+func ReadArchive(r io.Reader, trusted []string) (Archive, error) {
+	var archive Archive
+	reader := bufio.NewReader(r)
+	h := sha256.New()
+
+	line, err := readLine(reader, h)
+	if err != nil {
+		return archive, err
+	}
+	if err := json.Unmarshal(line, &archive.Manifest); err != nil || archive.Manifest.Format != archiveFormat {
+		return archive, fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+	if archive.Manifest.Version != archiveVersion {
+		return archive, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, archive.Manifest.Version)
+	}
+
+	for {
+		digest := h.Sum(nil)
+		line, err := readLine(reader, h)
+		if err != nil {
+			return archive, err
+		}
+		var l archiveLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return archive, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		switch l.Type {
+		case "block":
+			if l.Block == nil {
+				return archive, fmt.Errorf("%w: empty block line", ErrInvalidArchive)
+			}
+			archive.Blocks = append(archive.Blocks, *l.Block)
+		case "signature":
+			if l.SHA256 != hex.EncodeToString(digest) {
+				return archive, fmt.Errorf("%w: content hash mismatch", ErrInvalidArchive)
+			}
+			pub, err := utils.ParsePublicKeyHex(l.PublicKey)
+			if err != nil {
+				return archive, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			sig, err := hex.DecodeString(l.Signature)
+			if err != nil || !keymanager.Verify(pub, digest, sig) {
+				return archive, fmt.Errorf("%w: bad signature", ErrInvalidArchive)
+			}
+			archive.Signer = keymanager.Fingerprint(pub)
+			if trusted != nil && !containsFingerprint(trusted, archive.Signer) {
+				return archive, fmt.Errorf("%w: archive signed by %s", ErrUntrustedSigner, archive.Signer)
+			}
+			if len(archive.Blocks) != archive.Manifest.Blocks {
+				return archive, fmt.Errorf("%w: expected %d blocks, found %d", ErrInvalidArchive, archive.Manifest.Blocks, len(archive.Blocks))
+			}
+			return archive, nil
+		default:
+			return archive, fmt.Errorf("%w: unknown line type %q", ErrInvalidArchive, l.Type)
+		}
+	}
+}
+
+// ImportChain mengganti storage lokal dengan blocks yang sudah diverifikasi
+func ImportChain(blocks []models.Block, force bool) error {
+	if _, err := VerifyChain(blocks, false); err != nil {
+		return err
+	}
+
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	if HasLocalChain() && !force {
+		return ErrChainExists
+	}
+	if err := clearStorage(); err != nil {
+		return err
+	}
+	clearSnapshots()
+	for _, b := range blocks {
+		if err := saveEncryptedBlock(b); err != nil {
+			return fmt.Errorf("block %d: %v", b.Index, err)
+		}
+	}
+	Blockchain = blocks
+	return nil
+}
+
+func writeLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func readLine(r *bufio.Reader, h hash.Hash) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: unexpected end of archive", ErrInvalidArchive)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.Write(line)
+	return line, nil
+}
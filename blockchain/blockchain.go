@@ -1,26 +1,18 @@
 package blockchain
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
 	"doc-tracker/mempool"
 	"doc-tracker/models"
-	"doc-tracker/utils"
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
 	"sync"
 	"time"
 )
 
 var (
-	Blockchain  []models.Block
-	chainMutex  sync.RWMutex
-	chainFile   = "data/chain.bin"  // File terenkripsi
-	pubKeyPath  = "data/public.pem" // Sama dengan mempool
-	privKeyPath = "data/private.pem"
+	Blockchain []models.Block
+	chainMutex sync.RWMutex
+	legacyOnce sync.Once
 )
 
 // InitChain inisialisasi blockchain dengan genesis block terenkripsi
@@ -71,6 +63,7 @@ func CreateGenesisBlock() models.Block {
 		Nonce:        0,
 		Encrypted:    true,
 	}
+	genesis.MerkleRoot = MerkleRoot(genesis.Transactions)
 	genesis.Hash = CalculateHash(genesis)
 	return genesis
 }
@@ -112,6 +105,7 @@ func MineNewBlock(transactions []models.Tracker) (models.Block, error) {
 		Transactions: transactions,
 		Encrypted:    true,
 	}
+	newBlock.MerkleRoot = MerkleRoot(transactions)
 
 	// Mining process
 	MineBlock(&newBlock, 4)
@@ -129,147 +123,11 @@ func MineNewBlock(transactions []models.Tracker) (models.Block, error) {
 		// Hapus tracker dari mempool
 		mempool.RemoveFromMempool(tx.ID)
 	}
+	maybeSnapshot(newBlock.Index)
 
 	return newBlock, nil
 }
 
-// ================ ENCRYPTION FUNCTIONS ================
-
-// saveEncryptedBlock menyimpan block terenkripsi
-func saveEncryptedBlock(block models.Block) error {
-	// 1. Serialisasi block
-	data, err := json.Marshal(block)
-	if err != nil {
-		return fmt.Errorf("marshal failed: %v", err)
-	}
-
-	// 2. Load public key
-	pubKey, err := utils.LoadECDSAPublicKey(pubKeyPath)
-	if err != nil {
-		return fmt.Errorf("load public key failed: %v", err)
-	}
-
-	// 3. Enkripsi dengan ECIES
-	eciesPub := utils.ImportECDSAPublic(pubKey)
-	encryptedData, err := utils.ECIESEncrypt(rand.Reader, eciesPub, data, nil, nil)
-	if err != nil {
-		return fmt.Errorf("encryption failed: %v", err)
-	}
-
-	// 4. Simpan ke file terpisah per block
-	if err := os.MkdirAll("data/blocks", 0755); err != nil {
-		return fmt.Errorf("failed to create blocks directory: %v", err)
-	}
-	// Gunakan index sebagai nama file
-	blockFile := fmt.Sprintf("data/blocks/%d.bin", block.Index)
-	if err := os.WriteFile(blockFile, encryptedData, 0600); err != nil {
-		return fmt.Errorf("write failed: %v", err)
-	}
-
-	// 5. Update chain index
-	return updateChainIndex(block.Index, block.Hash)
-}
-
-// loadDecryptedBlock memuat dan mendekripsi block
-func loadDecryptedBlock(index int) (models.Block, error) {
-	blockFile := fmt.Sprintf("data/blocks/%d.bin", index)
-	encryptedData, err := os.ReadFile(blockFile)
-	if err != nil {
-		return models.Block{}, fmt.Errorf("read failed: %v", err)
-	}
-
-	// Load private key
-	privKey, err := utils.LoadECDSAPrivateKey(privKeyPath)
-	if err != nil {
-		return models.Block{}, fmt.Errorf("load private key failed: %v", err)
-	}
-
-	// Dekripsi
-	plaintext, err := utils.ECIESDecrypt(utils.ImportECDSA(privKey), encryptedData, nil, nil)
-	if err != nil {
-		return models.Block{}, fmt.Errorf("decryption failed: %v", err)
-	}
-
-	var block models.Block
-	if err := json.Unmarshal(plaintext, &block); err != nil {
-		return models.Block{}, fmt.Errorf("unmarshal failed: %v", err)
-	}
-
-	return block, nil
-}
-
-// updateChainIndex memperbarui index chain terenkripsi
-func updateChainIndex(index int, hash string) error {
-	chainData := fmt.Sprintf("%d:%s", index, hash)
-
-	// Enkripsi data index
-	pubKey, err := utils.LoadECDSAPublicKey(pubKeyPath)
-	if err != nil {
-		return err
-	}
-
-	eciesPub := utils.ImportECDSAPublic(pubKey)
-	encryptedData, err := utils.ECIESEncrypt(rand.Reader, eciesPub, []byte(chainData), nil, nil)
-	if err != nil {
-		return err
-	}
-
-	// Tulis ke file chain index
-	return os.WriteFile(chainFile, encryptedData, 0600)
-}
-
-// loadChainFromStorage memuat seluruh chain dari storage
-func loadChainFromStorage() bool {
-	if _, err := os.Stat(chainFile); os.IsNotExist(err) {
-		return false
-	}
-
-	// Dekripsi chain index
-	encryptedData, err := os.ReadFile(chainFile)
-	if err != nil {
-		log.Printf("⚠️ Failed to read chain file: %v", err)
-		return false
-	}
-
-	privKey, err := utils.LoadECDSAPrivateKey(privKeyPath)
-	if err != nil {
-		log.Printf("⚠️ Failed to load private key: %v", err)
-		return false
-	}
-
-	plaintext, err := utils.ECIESDecrypt(utils.ImportECDSA(privKey), encryptedData, nil, nil)
-	if err != nil {
-		log.Printf("⚠️ Failed to decrypt chain index: %v", err)
-		return false
-	}
-
-	// Parse index terakhir
-	plaintextStr, _ := utils.ConvertForAtoi(string(plaintext))
-	if plaintextStr == "" {
-		log.Println("⚠️ Invalid chain index format")
-		return false
-	}
-	lastIndex, err := strconv.Atoi(plaintextStr)
-	if err != nil {
-		log.Printf("⚠️ Invalid chain index: %v", err)
-		return false
-	}
-
-	// Load semua block
-	var chain []models.Block
-	for i := 0; i <= lastIndex; i++ {
-		block, err := loadDecryptedBlock(i)
-		if err != nil {
-			log.Printf("⚠️ Failed to load block %d: %v", i, err)
-			return false
-		}
-		chain = append(chain, block)
-	}
-
-	Blockchain = chain
-	return true
-}
-
 // ================ CORE BLOCKCHAIN FUNCTIONS ================
 
 // GetLastBlock mengambil block terakhir
@@ -291,6 +149,9 @@ func IsBlockValid(newBlock, prevBlock models.Block) bool {
 	if prevBlock.Hash != newBlock.PrevHash {
 		return false
 	}
+	if newBlock.MerkleRoot != "" && MerkleRoot(newBlock.Transactions) != newBlock.MerkleRoot {
+		return false
+	}
 	if CalculateHash(newBlock) != newBlock.Hash {
 		return false
 	}
@@ -300,20 +161,20 @@ func IsBlockValid(newBlock, prevBlock models.Block) bool {
 	return true
 }
 
-// CalculateHash menghitung hash untuk block
+// CalculateHash menghitung hash untuk block. Block format baru (punya merkle
+// root) di-hash dari header saja agar chain bisa diverifikasi tanpa body.
 func CalculateHash(block models.Block) string {
-	record := strconv.Itoa(block.Index) + strconv.FormatInt(block.Timestamp, 10) + block.PrevHash + fmt.Sprintf("%v", block.Transactions) + strconv.Itoa(block.Nonce)
-	h := sha256.New()
-	h.Write([]byte(record))
-	hashed := h.Sum(nil)
-	return fmt.Sprintf("%x", hashed)
+	if block.MerkleRoot != "" {
+		return HeaderHash(HeaderOf(block))
+	}
+	return legacyHash(block)
 }
 
 // MineBlock melakukan proof-of-work
 func MineBlock(block *models.Block, difficulty int) {
 	for {
 		hash := CalculateHash(*block)
-		if hash[:difficulty] == "0000" {
+		if hash[:difficulty] == powPrefix {
 			block.Hash = hash
 			break
 		}
@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"doc-tracker/storage"
+	"log"
 )
 
 // AddBlock menambahkan block dari peer lain jika belum ada
@@ -12,7 +13,7 @@ func AddBlock(block Block) {
 		}
 	}
 	Blockchain = append(Blockchain, block)
-	storage.SaveBlock(block)
+	persistBlock(block)
 }
 
 // IsValidChain memeriksa apakah chain valid dari genesis hingga terakhir
@@ -38,9 +39,13 @@ func ReplaceChain(newChain []Block) bool {
 	Blockchain = newChain
 
 	// Simpan semua block baru
-	storage.ClearAllBlocks()
+	if err := clearStorage(); err != nil {
+		log.Printf("⚠️ Failed to clear chain storage: %v", err)
+	}
 	for _, b := range newChain {
-		storage.SaveBlock(b)
+		if err := saveEncryptedBlock(b); err != nil {
+			log.Printf("⚠️ Failed to save block %d: %v", b.Index, err)
+		}
 	}
 
 	return true
@@ -69,7 +74,7 @@ func TryAddBlock(block Block) bool {
 
 	if IsBlockValid(block, last) {
 		Blockchain = append(Blockchain, block)
-		storage.SaveBlock(block)
+		persistBlock(block)
 		return true
 	}
 
@@ -80,6 +85,15 @@ func AddBlockToChain(block Block) {
 	last := GetLastBlock()
 	if IsBlockValid(block, last) {
 		Blockchain = append(Blockchain, block)
-		storage.SaveBlock(block)
+		persistBlock(block)
+	}
+}
+
+// persistBlock menyimpan block dari peer ke storage header + body
+func persistBlock(block Block) {
+	if err := saveEncryptedBlock(block); err != nil {
+		log.Printf("⚠️ Failed to save block %d: %v", block.Index, err)
+		return
 	}
+	maybeSnapshot(block.Index)
 }
@@ -0,0 +1,179 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"doc-tracker/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidHeader = errors.New("invalid block header")
+
+// powPrefix target proof-of-work MineBlock
+const powPrefix = "0000"
+
+// TxHash adalah leaf merkle untuk satu tracker
+func TxHash(tx models.Tracker) string {
+	data, _ := json.Marshal(tx)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MerkleRoot menghitung merkle root transaksi block. Node ganjil diduplikasi;
+// block tanpa transaksi memakai sha256 dari string kosong.
+func MerkleRoot(txs []models.Tracker) string {
+	if len(txs) == 0 {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:])
+	}
+
+	level := make([][]byte, len(txs))
+	for i, tx := range txs {
+		level[i], _ = hex.DecodeString(TxHash(tx))
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			sum := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	return hex.EncodeToString(level[0])
+}
+
+// MerkleStep satu langkah bukti inklusi: hash saudara pada level tersebut
+type MerkleStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // saudara di kiri: sha256(Hash || current)
+}
+
+// MerkleProof bukti inklusi transaksi ke-index terhadap MerkleRoot(txs),
+// dengan aturan duplikasi node ganjil yang sama
+func MerkleProof(txs []models.Tracker, index int) []MerkleStep {
+	if index < 0 || index >= len(txs) {
+		return nil
+	}
+	level := make([][]byte, len(txs))
+	for i, tx := range txs {
+		level[i], _ = hex.DecodeString(TxHash(tx))
+	}
+	proof := []MerkleStep{}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		if index%2 == 0 {
+			proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[index+1])})
+		} else {
+			proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[index-1]), Left: true})
+		}
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			sum := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, sum[:])
+		}
+		level = next
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof memeriksa bukti MerkleProof untuk txHash terhadap root
+func VerifyMerkleProof(txHash string, proof []MerkleStep, root string) bool {
+	current, err := hex.DecodeString(txHash)
+	if err != nil {
+		return false
+	}
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		var sum [32]byte
+		if step.Left {
+			sum = sha256.Sum256(append(sibling, current...))
+		} else {
+			sum = sha256.Sum256(append(current, sibling...))
+		}
+		current = sum[:]
+	}
+	return hex.EncodeToString(current) == root
+}
+
+// HeaderHash menghitung hash block format baru hanya dari field header
+func HeaderHash(h models.BlockHeader) string {
+	record := strconv.Itoa(h.Index) + strconv.FormatInt(h.Timestamp, 10) + h.PrevHash + h.MerkleRoot + strconv.Itoa(h.Nonce)
+	sum := sha256.Sum256([]byte(record))
+	return hex.EncodeToString(sum[:])
+}
+
+// HeaderOf membuat header dari block. Block format lama tetap mendapat merkle
+// root (untuk cek body) tetapi ditandai Legacy.
+func HeaderOf(b models.Block) models.BlockHeader {
+	h := models.BlockHeader{
+		Index:      b.Index,
+		Timestamp:  b.Timestamp,
+		PrevHash:   b.PrevHash,
+		Hash:       b.Hash,
+		MerkleRoot: b.MerkleRoot,
+		Nonce:      b.Nonce,
+		TxCount:    len(b.Transactions),
+	}
+	if h.MerkleRoot == "" {
+		h.MerkleRoot = MerkleRoot(b.Transactions)
+		h.Legacy = true
+	}
+	return h
+}
+
+// VerifyHeaders memeriksa link, hash dan proof-of-work rangkaian header tanpa
+// membuka body. Error menyebut header pertama yang tidak valid.
+func VerifyHeaders(headers []models.BlockHeader) error {
+	for i, h := range headers {
+		if i > 0 {
+			prev := headers[i-1]
+			if h.Index != prev.Index+1 {
+				return fmt.Errorf("%w: block %d: index does not follow %d", ErrInvalidHeader, h.Index, prev.Index)
+			}
+			if h.PrevHash != prev.Hash {
+				return fmt.Errorf("%w: block %d: prev hash does not match block %d", ErrInvalidHeader, h.Index, prev.Index)
+			}
+		}
+		if h.Legacy {
+			continue // hash format lama hanya bisa dicek dengan body
+		}
+		if HeaderHash(h) != h.Hash {
+			return fmt.Errorf("%w: block %d: hash mismatch", ErrInvalidHeader, h.Index)
+		}
+		if h.Index > 0 && !strings.HasPrefix(h.Hash, powPrefix) {
+			return fmt.Errorf("%w: block %d: proof of work not satisfied", ErrInvalidHeader, h.Index)
+		}
+	}
+	return nil
+}
+
+// GetHeaders mengembalikan header chain mulai dari index from (limit <= 0 = semua)
+func GetHeaders(from, limit int) []models.BlockHeader {
+	chainMutex.RLock()
+	defer chainMutex.RUnlock()
+
+	var headers []models.BlockHeader
+	for _, b := range Blockchain {
+		if b.Index < from {
+			continue
+		}
+		if limit > 0 && len(headers) >= limit {
+			break
+		}
+		headers = append(headers, HeaderOf(b))
+	}
+	return headers
+}
@@ -0,0 +1,238 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"doc-tracker/models"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// legacyTrackerFixture tracker format awal (v0) seperti di block lama
+func legacyTrackerFixture() models.Tracker {
+	return models.Tracker{
+		ID:             "trk-legacy-1",
+		Type:           "document",
+		Privacy:        "private",
+		Creator:        "alice@example.com",
+		CreatorAddr:    "addr-alice",
+		CreatedAt:      1699999000,
+		TargetEnd:      "self",
+		Status:         "complete",
+		EncryptedNotes: map[string]string{"addr-alice": "wrapped-key"},
+		Checkpoints: []models.Checkpoint{{
+			Email:         "bob@example.com",
+			Type:          "internal",
+			Role:          "signer",
+			IsViewable:    true,
+			EncryptedNote: "enc-note",
+			Address:       "addr-bob",
+			EvidenceHash:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			EvidencePath:  "storage/evidence/trk-legacy-1_addr-bob.jpg",
+			IsCompleted:   true,
+			CompletedAt:   1699999500,
+		}},
+	}
+}
+
+// legacyV1TrackerFixture tracker dengan note dan attachment terenkripsi (layout v1)
+func legacyV1TrackerFixture() models.Tracker {
+	return models.Tracker{
+		ID:               "trk-legacy-2",
+		Creator:          "alice@example.com",
+		CreatorAddr:      "addr-alice",
+		CreatedAt:        1699999000,
+		Status:           "pending",
+		EncryptedContent: "enc-content",
+		Attachments: []models.Attachment{{
+			Name:        "a.pdf",
+			ContentType: "application/pdf",
+			Size:        10,
+			Hash:        "h",
+			Ciphertext:  "ct",
+		}},
+	}
+}
+
+// Nilai di bawah dibekukan: jika berubah, block lama di chain yang sudah ada
+// tidak bisa diverifikasi lagi
+func TestLegacyBlockHashStable(t *testing.T) {
+	cases := []struct {
+		name       string
+		block      models.Block
+		wantHash   string
+		wantMerkle string
+	}{
+		{
+			name: "v0",
+			block: models.Block{
+				Index: 1, Timestamp: 1700000000, PrevHash: "0000prev", Nonce: 42,
+				Hash:         "9fc82055bd0ca6453e9ab542443c61865587c72dba93251795d481cc227d06a0",
+				Transactions: []models.Tracker{legacyTrackerFixture()},
+			},
+			wantHash:   "9fc82055bd0ca6453e9ab542443c61865587c72dba93251795d481cc227d06a0",
+			wantMerkle: "b4ad24a8db6b9d8a1305403207babe6594a2795de6513616ac9e902896f96a9a",
+		},
+		{
+			name: "v1",
+			block: models.Block{
+				Index: 3, Timestamp: 1700000200, PrevHash: "0000prev", Nonce: 9,
+				Hash:         "956284b39732bc9a591d31194b6dd8743bd1a29d1bf2f92f1edb83de03c9dc87",
+				Transactions: []models.Tracker{legacyV1TrackerFixture()},
+			},
+			wantHash:   "956284b39732bc9a591d31194b6dd8743bd1a29d1bf2f92f1edb83de03c9dc87",
+			wantMerkle: MerkleRoot([]models.Tracker{legacyV1TrackerFixture()}),
+		},
+		{
+			name: "v1 layout unknown hash falls back to v0",
+			block: models.Block{
+				Index: 3, Timestamp: 1700000200, PrevHash: "0000prev", Nonce: 9,
+				Hash:         "unknown",
+				Transactions: []models.Tracker{legacyV1TrackerFixture()},
+			},
+			wantHash:   "8ea01d835679477001fa357fc48b572874a7e6c8aa447ce817d0d9afccd3c15d",
+			wantMerkle: MerkleRoot([]models.Tracker{legacyV1TrackerFixture()}),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CalculateHash(tc.block); got != tc.wantHash {
+				t.Errorf("CalculateHash = %s, want %s", got, tc.wantHash)
+			}
+
+			h := HeaderOf(tc.block)
+			if !h.Legacy {
+				t.Errorf("HeaderOf(legacy block).Legacy = false")
+			}
+			if h.MerkleRoot != tc.wantMerkle {
+				t.Errorf("HeaderOf merkle root = %s, want %s", h.MerkleRoot, tc.wantMerkle)
+			}
+			if h.Hash != tc.block.Hash || h.TxCount != len(tc.block.Transactions) {
+				t.Errorf("HeaderOf = %+v, want hash %s and %d transactions", h, tc.block.Hash, len(tc.block.Transactions))
+			}
+		})
+	}
+}
+
+func TestLegacyMerkleRootStable(t *testing.T) {
+	// Leaf = sha256(JSON tracker); field baru harus omitempty agar leaf block lama tetap
+	want := "b4ad24a8db6b9d8a1305403207babe6594a2795de6513616ac9e902896f96a9a"
+	if got := TxHash(legacyTrackerFixture()); got != want {
+		t.Errorf("TxHash(legacy tracker) = %s, want %s", got, want)
+	}
+	if got := MerkleRoot([]models.Tracker{legacyTrackerFixture()}); got != want {
+		t.Errorf("MerkleRoot(single tracker) = %s, want %s", got, want)
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	a, b, c := legacyTrackerFixture(), legacyV1TrackerFixture(), legacyTrackerFixture()
+	c.ID = "trk-legacy-3"
+
+	pair := func(l, r string) string {
+		lb, _ := hex.DecodeString(l)
+		rb, _ := hex.DecodeString(r)
+		sum := sha256.Sum256(append(lb, rb...))
+		return hex.EncodeToString(sum[:])
+	}
+	ab := pair(TxHash(a), TxHash(b))
+	cc := pair(TxHash(c), TxHash(c))
+
+	cases := []struct {
+		name string
+		txs  []models.Tracker
+		want string
+	}{
+		{"empty", nil, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"single", []models.Tracker{a}, TxHash(a)},
+		{"pair", []models.Tracker{a, b}, ab},
+		{"odd duplicates last", []models.Tracker{a, b, c}, pair(ab, cc)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := MerkleRoot(tc.txs)
+			if root != tc.want {
+				t.Fatalf("MerkleRoot = %s, want %s", root, tc.want)
+			}
+			for i, tx := range tc.txs {
+				if !VerifyMerkleProof(TxHash(tx), MerkleProof(tc.txs, i), root) {
+					t.Errorf("proof for tx %d does not verify", i)
+				}
+			}
+		})
+	}
+
+	txs := []models.Tracker{a, b, c}
+	if VerifyMerkleProof(TxHash(a), MerkleProof(txs, 1), MerkleRoot(txs)) {
+		t.Error("proof for tx 1 verified tx 0")
+	}
+}
+
+func TestHeaderHashStable(t *testing.T) {
+	h := models.BlockHeader{
+		Index:      2,
+		Timestamp:  1700000100,
+		PrevHash:   "0000prev",
+		MerkleRoot: "b4ad24a8db6b9d8a1305403207babe6594a2795de6513616ac9e902896f96a9a",
+		Nonce:      7,
+	}
+	want := "cd20d3d4fef608e62e87bb1039da2023f6f83497f564cc952edab21484ac07d8"
+	if got := HeaderHash(h); got != want {
+		t.Errorf("HeaderHash = %s, want %s", got, want)
+	}
+
+	// Block format baru di-hash dari header saja, body tidak ikut
+	b := models.Block{Index: h.Index, Timestamp: h.Timestamp, PrevHash: h.PrevHash, MerkleRoot: h.MerkleRoot, Nonce: h.Nonce}
+	if got := CalculateHash(b); got != want {
+		t.Errorf("CalculateHash(new format) = %s, want %s", got, want)
+	}
+	if HeaderOf(b).Legacy {
+		t.Error("HeaderOf(new format).Legacy = true")
+	}
+}
+
+// minedHeader membuat header format baru yang memenuhi proof-of-work
+func minedHeader(prev models.BlockHeader, txs []models.Tracker) models.BlockHeader {
+	h := models.BlockHeader{
+		Index:      prev.Index + 1,
+		Timestamp:  prev.Timestamp + 60,
+		PrevHash:   prev.Hash,
+		MerkleRoot: MerkleRoot(txs),
+		TxCount:    len(txs),
+	}
+	for {
+		h.Hash = HeaderHash(h)
+		if strings.HasPrefix(h.Hash, powPrefix) {
+			return h
+		}
+		h.Nonce++
+	}
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	genesis := models.BlockHeader{Index: 0, Timestamp: 1700000000, MerkleRoot: MerkleRoot(nil)}
+	genesis.Hash = HeaderHash(genesis)
+	first := minedHeader(genesis, []models.Tracker{legacyTrackerFixture()})
+	second := minedHeader(first, nil)
+
+	if err := VerifyHeaders([]models.BlockHeader{genesis, first, second}); err != nil {
+		t.Fatalf("VerifyHeaders(valid chain) = %v", err)
+	}
+
+	cases := map[string]func(hs []models.BlockHeader){
+		"merkle root changed": func(hs []models.BlockHeader) { hs[1].MerkleRoot = MerkleRoot(nil) },
+		"prev hash changed":   func(hs []models.BlockHeader) { hs[2].PrevHash = genesis.Hash },
+		"index gap":           func(hs []models.BlockHeader) { hs[2].Index = 5 },
+		"nonce changed":       func(hs []models.BlockHeader) { hs[1].Nonce++ },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			hs := []models.BlockHeader{genesis, first, second}
+			mutate(hs)
+			if err := VerifyHeaders(hs); !errors.Is(err, ErrInvalidHeader) {
+				t.Errorf("VerifyHeaders err = %v, want ErrInvalidHeader", err)
+			}
+		})
+	}
+}
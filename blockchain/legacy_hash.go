@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"doc-tracker/models"
+	"fmt"
+	"strconv"
+)
+
+// Block format lama (tanpa merkle root) di-hash dari fmt "%v" transaksinya,
+// sehingga hasilnya bergantung pada field struct saat block di-mine. Layout
+// di bawah dibekukan agar field baru di models tidak mengubah hash block lama:
+//   - v0: model awal
+//   - v1: tracker dengan note dan attachment terenkripsi
+
+type legacyCheckpoint struct {
+	Email         string
+	Type          string
+	Company       string
+	Role          string
+	IsViewable    bool
+	Note          string
+	EncryptedNote string
+	Address       string
+	EvidenceHash  string
+	EvidencePath  string
+	IsCompleted   bool
+	CompletedAt   int64
+}
+
+type legacyTrackerV0 struct {
+	ID             string
+	Type           string
+	Privacy        string
+	Creator        string
+	CreatorAddr    string
+	CreatedAt      int64
+	Checkpoints    []legacyCheckpoint
+	TargetEnd      string
+	Status         string
+	EncryptedNotes map[string]string
+}
+
+type legacyAttachment struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Hash        string
+	Data        string
+	Ciphertext  string
+}
+
+// legacyTrackerV1 ditulis rata (tanpa embed) karena "%v" mencetak struct
+// embedded sebagai kurung kurawal tersendiri
+type legacyTrackerV1 struct {
+	ID               string
+	Type             string
+	Privacy          string
+	Creator          string
+	CreatorAddr      string
+	CreatedAt        int64
+	Checkpoints      []legacyCheckpoint
+	TargetEnd        string
+	Status           string
+	EncryptedNotes   map[string]string
+	Note             string
+	EncryptedContent string
+	Attachments      []legacyAttachment
+}
+
+// legacyHash menghitung hash block format lama dengan layout yang cocok dengan
+// block.Hash; jika tidak ada yang cocok, hash layout v0 dikembalikan
+func legacyHash(block models.Block) string {
+	v0 := make([]legacyTrackerV0, len(block.Transactions))
+	v1 := make([]legacyTrackerV1, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		v0[i] = legacyTrackerOf(tx)
+		v1[i] = legacyTrackerV1{
+			ID:               v0[i].ID,
+			Type:             v0[i].Type,
+			Privacy:          v0[i].Privacy,
+			Creator:          v0[i].Creator,
+			CreatorAddr:      v0[i].CreatorAddr,
+			CreatedAt:        v0[i].CreatedAt,
+			Checkpoints:      v0[i].Checkpoints,
+			TargetEnd:        v0[i].TargetEnd,
+			Status:           v0[i].Status,
+			EncryptedNotes:   v0[i].EncryptedNotes,
+			Note:             tx.Note,
+			EncryptedContent: tx.EncryptedContent,
+		}
+		for _, a := range tx.Attachments {
+			v1[i].Attachments = append(v1[i].Attachments, legacyAttachment(a))
+		}
+	}
+
+	hash := legacyRecordHash(block, v0)
+	if hash != block.Hash {
+		if h1 := legacyRecordHash(block, v1); h1 == block.Hash {
+			return h1
+		}
+	}
+	return hash
+}
+
+func legacyTrackerOf(tx models.Tracker) legacyTrackerV0 {
+	t := legacyTrackerV0{
+		ID:             tx.ID,
+		Type:           tx.Type,
+		Privacy:        tx.Privacy,
+		Creator:        tx.Creator,
+		CreatorAddr:    tx.CreatorAddr,
+		CreatedAt:      tx.CreatedAt,
+		TargetEnd:      tx.TargetEnd,
+		Status:         tx.Status,
+		EncryptedNotes: tx.EncryptedNotes,
+	}
+	if tx.Checkpoints != nil {
+		t.Checkpoints = make([]legacyCheckpoint, len(tx.Checkpoints))
+	}
+	for i, cp := range tx.Checkpoints {
+		t.Checkpoints[i] = legacyCheckpoint{
+			Email:         cp.Email,
+			Type:          cp.Type,
+			Company:       cp.Company,
+			Role:          cp.Role,
+			IsViewable:    cp.IsViewable,
+			Note:          cp.Note,
+			EncryptedNote: cp.EncryptedNote,
+			Address:       cp.Address,
+			EvidenceHash:  cp.EvidenceHash,
+			EvidencePath:  cp.EvidencePath,
+			IsCompleted:   cp.IsCompleted,
+			CompletedAt:   cp.CompletedAt,
+		}
+	}
+	return t
+}
+
+func legacyRecordHash(block models.Block, txs any) string {
+	record := strconv.Itoa(block.Index) + strconv.FormatInt(block.Timestamp, 10) + block.PrevHash + fmt.Sprintf("%v", txs) + strconv.Itoa(block.Nonce)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(record)))
+}
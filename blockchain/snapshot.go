@@ -0,0 +1,401 @@
+package blockchain
+
+import (
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const snapshotVersion = 1
+
+var snapshotsDir = "data/snapshots"
+
+var (
+	ErrNoSnapshot      = errors.New("no snapshot available")
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	ErrUntrustedSigner = errors.New("not signed by a trusted node")
+	ErrChainExists     = errors.New("local chain already exists")
+)
+
+// Snapshot adalah state chain pada height tertentu: seluruh header (agar node
+// baru tetap punya rantai hash) dan index tracker per block.
+type Snapshot struct {
+	Version   int                  `json:"version"`
+	Height    int                  `json:"height"`
+	CreatedAt int64                `json:"created_at"`
+	Tip       models.BlockHeader   `json:"tip"`
+	Headers   []models.BlockHeader `json:"headers"`
+	Trackers  []SnapshotEntry      `json:"trackers"` // urut sesuai block dan posisi transaksi
+}
+
+type SnapshotEntry struct {
+	BlockIndex int            `json:"block_index"`
+	Tracker    models.Tracker `json:"tracker"`
+}
+
+// SignedSnapshot membawa payload snapshot apa adanya beserta signature node
+type SignedSnapshot struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`  // hex DER ECDSA atas sha256(payload)
+	PublicKey string          `json:"public_key"` // hex public key signing node
+}
+
+// CreateSnapshot membuat snapshot dari chain saat ini, menandatanganinya dan
+// menyimpannya terenkripsi di data/snapshots
+func CreateSnapshot() (Snapshot, error) {
+	chainMutex.RLock()
+	chain := append([]models.Block(nil), Blockchain...)
+	chainMutex.RUnlock()
+
+	if len(chain) == 0 {
+		return Snapshot{}, ErrNoChain
+	}
+
+	snap := Snapshot{
+		Version:   snapshotVersion,
+		Height:    chain[len(chain)-1].Index,
+		CreatedAt: time.Now().Unix(),
+		Headers:   make([]models.BlockHeader, 0, len(chain)),
+	}
+	for _, b := range chain {
+		snap.Headers = append(snap.Headers, HeaderOf(b))
+		for _, tx := range b.Transactions {
+			snap.Trackers = append(snap.Trackers, SnapshotEntry{BlockIndex: b.Index, Tracker: tx})
+		}
+	}
+	snap.Tip = snap.Headers[len(snap.Headers)-1]
+
+	signed, err := signSnapshot(snap)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := saveSnapshot(snap.Height, signed); err != nil {
+		return Snapshot{}, err
+	}
+	cleanupSnapshots(utils.GetEnvInt("SNAPSHOT_KEEP", 2))
+	return snap, nil
+}
+
+func signSnapshot(snap Snapshot) (SignedSnapshot, error) {
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return SignedSnapshot{}, err
+	}
+	sig, err := keymanager.Sign(payload)
+	if err != nil {
+		return SignedSnapshot{}, fmt.Errorf("failed to sign snapshot: %v", err)
+	}
+	pub, err := keymanager.PublicKey()
+	if err != nil {
+		return SignedSnapshot{}, err
+	}
+	return SignedSnapshot{
+		Payload:   payload,
+		Signature: hex.EncodeToString(sig),
+		PublicKey: hex.EncodeToString(utils.SerializePublicKey(pub)),
+	}, nil
+}
+
+// SignerFingerprint adalah fingerprint public key penandatangan snapshot
+func (s SignedSnapshot) SignerFingerprint() string {
+	pub, err := utils.ParsePublicKeyHex(s.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return keymanager.Fingerprint(pub)
+}
+
+// VerifySnapshot memeriksa signature, rantai header dan merkle root setiap
+// block terhadap index tracker. trusted berisi fingerprint signer yang
+// diterima; nil berarti signer tidak dibatasi (snapshot lokal).
+func VerifySnapshot(signed SignedSnapshot, trusted []string) (Snapshot, error) {
+	pub, err := utils.ParsePublicKeyHex(signed.PublicKey)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	sig, err := hex.DecodeString(signed.Signature)
+	if err != nil || !keymanager.Verify(pub, signed.Payload, sig) {
+		return Snapshot{}, fmt.Errorf("%w: bad signature", ErrInvalidSnapshot)
+	}
+	if trusted != nil && !containsFingerprint(trusted, keymanager.Fingerprint(pub)) {
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrUntrustedSigner, keymanager.Fingerprint(pub))
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(signed.Payload, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Height < 0 || len(snap.Headers) == 0 {
+		return Snapshot{}, fmt.Errorf("%w: no headers", ErrInvalidSnapshot)
+	}
+	if snap.Version != snapshotVersion || len(snap.Headers) != snap.Height+1 || snap.Headers[0].Index != 0 {
+		return Snapshot{}, fmt.Errorf("%w: unexpected version or header count", ErrInvalidSnapshot)
+	}
+	if snap.Headers[snap.Height] != snap.Tip {
+		return Snapshot{}, fmt.Errorf("%w: tip does not match headers", ErrInvalidSnapshot)
+	}
+	if err := VerifyHeaders(snap.Headers); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	for _, b := range blocksFromSnapshot(snap) {
+		h := snap.Headers[b.Index]
+		if len(b.Transactions) != h.TxCount || MerkleRoot(b.Transactions) != h.MerkleRoot {
+			return Snapshot{}, fmt.Errorf("%w: trackers do not match block %d", ErrInvalidSnapshot, b.Index)
+		}
+	}
+	return snap, nil
+}
+
+// blocksFromSnapshot menyusun ulang block 0..Height dari header dan index tracker
+func blocksFromSnapshot(snap Snapshot) []models.Block {
+	txs := make(map[int][]models.Tracker, len(snap.Headers))
+	for _, e := range snap.Trackers {
+		txs[e.BlockIndex] = append(txs[e.BlockIndex], e.Tracker)
+	}
+	blocks := make([]models.Block, 0, len(snap.Headers))
+	for _, h := range snap.Headers {
+		list := txs[h.Index]
+		if list == nil {
+			list = []models.Tracker{}
+		}
+		blocks = append(blocks, blockFromHeader(h, list))
+	}
+	return blocks
+}
+
+// LatestSignedSnapshot membaca snapshot lokal terbaru (untuk dilayani ke peer)
+func LatestSignedSnapshot() (SignedSnapshot, error) {
+	heights := snapshotHeights()
+	if len(heights) == 0 {
+		return SignedSnapshot{}, ErrNoSnapshot
+	}
+	return loadSnapshot(heights[0])
+}
+
+// InstallSnapshot memasang snapshot peer pada node baru: header ditulis,
+// snapshot disimpan lokal dan chain in-memory disusun dari index tracker.
+// Block setelah Height perlu di-replay (TryAddBlock).
+func InstallSnapshot(signed SignedSnapshot, trusted []string, force bool) (Snapshot, error) {
+	snap, err := VerifySnapshot(signed, trusted)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	if HasLocalChain() && !force {
+		return Snapshot{}, ErrChainExists
+	}
+	if err := clearStorage(); err != nil {
+		return Snapshot{}, err
+	}
+	if err := os.MkdirAll(headersDir, 0755); err != nil {
+		return Snapshot{}, err
+	}
+	for _, h := range snap.Headers {
+		if err := writeJSON(headerPath(h.Index), h); err != nil {
+			return Snapshot{}, err
+		}
+	}
+	if err := writeJSON(tipFile, snap.Tip); err != nil {
+		return Snapshot{}, err
+	}
+	if err := saveSnapshot(snap.Height, signed); err != nil {
+		return Snapshot{}, err
+	}
+
+	Blockchain = blocksFromSnapshot(snap)
+	return snap, nil
+}
+
+// HasLocalChain true jika node sudah punya chain di storage (format baru atau lama)
+func HasLocalChain() bool {
+	for _, f := range []string{tipFile, legacyChainFile} {
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PruneBodies menghapus body block yang sudah tercakup snapshot terbaru,
+// menyisakan keep block terakhir. Header tetap disimpan.
+func PruneBodies(keep int) (int, error) {
+	heights := snapshotHeights()
+	if len(heights) == 0 {
+		return 0, ErrNoSnapshot
+	}
+
+	chainMutex.RLock()
+	tip := len(Blockchain) - 1
+	chainMutex.RUnlock()
+
+	limit := heights[0]
+	if tip-keep < limit {
+		limit = tip - keep
+	}
+
+	pruned := 0
+	for i := 0; i <= limit; i++ {
+		err := os.Remove(bodyPath(i))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// maybeSnapshot membuat snapshot setiap SNAPSHOT_INTERVAL block dan, jika
+// PRUNE_BODIES=true, menghapus body lama setelahnya
+func maybeSnapshot(index int) {
+	interval := utils.GetEnvInt("SNAPSHOT_INTERVAL", 100)
+	if interval <= 0 || index == 0 || index%interval != 0 {
+		return
+	}
+
+	snap, err := CreateSnapshot()
+	if err != nil {
+		log.Printf("⚠️ Failed to create snapshot: %v", err)
+		return
+	}
+	log.Printf("📸 Snapshot created at height %d", snap.Height)
+
+	if os.Getenv("PRUNE_BODIES") != "true" {
+		return
+	}
+	if n, err := PruneBodies(utils.GetEnvInt("PRUNE_KEEP_BLOCKS", 100)); err != nil {
+		log.Printf("⚠️ Failed to prune block bodies: %v", err)
+	} else if n > 0 {
+		log.Printf("✂️ Pruned %d block bodies", n)
+	}
+}
+
+// loadLocalSnapshot mencari snapshot lokal terbaru yang cocok dengan header
+func loadLocalSnapshot(headers []models.BlockHeader) (Snapshot, bool) {
+	for _, height := range snapshotHeights() {
+		if height >= len(headers) {
+			continue
+		}
+		signed, err := loadSnapshot(height)
+		if err != nil {
+			log.Printf("⚠️ Failed to read snapshot %d: %v", height, err)
+			continue
+		}
+		snap, err := VerifySnapshot(signed, nil)
+		if err != nil || snap.Tip != headers[height] {
+			log.Printf("⚠️ Snapshot %d does not match the chain, skipped", height)
+			continue
+		}
+		return snap, true
+	}
+	return Snapshot{}, false
+}
+
+func snapshotPath(height int) string {
+	return filepath.Join(snapshotsDir, fmt.Sprintf("%d.snap", height))
+}
+
+func snapshotAD(height int) []byte {
+	return []byte(fmt.Sprintf("doctracker-snapshot:%d", height))
+}
+
+func saveSnapshot(height int, signed SignedSnapshot) error {
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	encrypted, err := keymanager.Encrypt(data, snapshotAD(height))
+	if err != nil {
+		return fmt.Errorf("encryption failed: %v", err)
+	}
+	if err := os.MkdirAll(snapshotsDir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(snapshotPath(height), encrypted, 0600)
+}
+
+func loadSnapshot(height int) (SignedSnapshot, error) {
+	data, err := os.ReadFile(snapshotPath(height))
+	if err != nil {
+		return SignedSnapshot{}, err
+	}
+	plaintext, err := keymanager.Decrypt(data, snapshotAD(height))
+	if err != nil {
+		return SignedSnapshot{}, fmt.Errorf("decryption failed: %v", err)
+	}
+	var signed SignedSnapshot
+	err = json.Unmarshal(plaintext, &signed)
+	return signed, err
+}
+
+// snapshotHeights mengembalikan height snapshot lokal, terbaru lebih dulu
+func snapshotHeights() []int {
+	files, _ := filepath.Glob(filepath.Join(snapshotsDir, "*.snap"))
+	var heights []int
+	for _, f := range files {
+		if h, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(f), ".snap")); err == nil {
+			heights = append(heights, h)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(heights)))
+	return heights
+}
+
+// clearSnapshots menghapus semua snapshot lokal, dipakai saat chain diganti
+func clearSnapshots() {
+	for _, height := range snapshotHeights() {
+		os.Remove(snapshotPath(height))
+	}
+}
+
+func cleanupSnapshots(keep int) {
+	if keep < 1 {
+		keep = 1
+	}
+	heights := snapshotHeights()
+	for i := keep; i < len(heights); i++ {
+		os.Remove(snapshotPath(heights[i]))
+	}
+}
+
+// TrustedSigners membaca SNAPSHOT_TRUSTED_KEYS (fingerprint node, dipisah koma)
+func TrustedSigners() []string {
+	var list []string
+	for _, f := range strings.Split(os.Getenv("SNAPSHOT_TRUSTED_KEYS"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// IsTrustedSigner true jika fingerprint ada di SNAPSHOT_TRUSTED_KEYS
+func IsTrustedSigner(fp string) bool {
+	return fp != "" && containsFingerprint(TrustedSigners(), fp)
+}
+
+func containsFingerprint(list []string, fp string) bool {
+	for _, f := range list {
+		if strings.EqualFold(strings.TrimSpace(f), fp) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,379 @@
+package blockchain
+
+import (
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Layout storage chain:
+//
+//	data/headers/N.json : header plaintext (hash-linked, tanpa key)
+//	data/blocks/N.bin   : body (transaksi) terenkripsi key storage node
+//	data/chain.json     : header tip, untuk menemukan tip tanpa dekripsi
+//
+// data/chain.bin dan block terenkripsi utuh adalah format lama; dimigrasi
+// otomatis saat chain dimuat.
+var (
+	headersDir      = "data/headers"
+	blocksDir       = "data/blocks"
+	tipFile         = "data/chain.json"
+	legacyChainFile = "data/chain.bin"
+	legacyChainAD   = []byte("doctracker-chain-index")
+)
+
+var ErrNoChain = errors.New("no chain in storage")
+
+// bodyAD mengikat body ke header (index dan hash) agar body tidak bisa ditukar
+func bodyAD(h models.BlockHeader) []byte {
+	return []byte(fmt.Sprintf("doctracker-block-body:%d:%s", h.Index, h.Hash))
+}
+
+// blockAD adalah associated data block format lama (terenkripsi utuh)
+func blockAD(index int) []byte {
+	return []byte(fmt.Sprintf("doctracker-block:%d", index))
+}
+
+func headerPath(index int) string { return filepath.Join(headersDir, fmt.Sprintf("%d.json", index)) }
+func bodyPath(index int) string   { return filepath.Join(blocksDir, fmt.Sprintf("%d.bin", index)) }
+
+// saveEncryptedBlock menyimpan header plaintext dan body terenkripsi
+func saveEncryptedBlock(block models.Block) error {
+	h := HeaderOf(block)
+
+	body, err := json.Marshal(block.Transactions)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %v", err)
+	}
+	encrypted, err := keymanager.Encrypt(body, bodyAD(h))
+	if err != nil {
+		return fmt.Errorf("encryption failed: %v", err)
+	}
+
+	for _, dir := range []string{headersDir, blocksDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+	if err := writeFileAtomic(bodyPath(h.Index), encrypted, 0600); err != nil {
+		return fmt.Errorf("write failed: %v", err)
+	}
+	if err := writeJSON(headerPath(h.Index), h); err != nil {
+		return err
+	}
+	return writeJSON(tipFile, h)
+}
+
+// loadDecryptedBlock memuat header dan mendekripsi body block
+func loadDecryptedBlock(h models.BlockHeader) (models.Block, error) {
+	data, err := os.ReadFile(bodyPath(h.Index))
+	if err != nil {
+		return models.Block{}, fmt.Errorf("read failed: %v", err)
+	}
+	plaintext, err := keymanager.Decrypt(data, bodyAD(h))
+	if err != nil {
+		return models.Block{}, fmt.Errorf("decryption failed: %v", err)
+	}
+
+	var txs []models.Tracker
+	if err := json.Unmarshal(plaintext, &txs); err != nil {
+		return models.Block{}, fmt.Errorf("unmarshal failed: %v", err)
+	}
+	if MerkleRoot(txs) != h.MerkleRoot {
+		return models.Block{}, fmt.Errorf("body does not match merkle root")
+	}
+
+	return blockFromHeader(h, txs), nil
+}
+
+// blockFromHeader menyusun block dari header dan transaksi yang sudah dicek
+func blockFromHeader(h models.BlockHeader, txs []models.Tracker) models.Block {
+	block := models.Block{
+		Index:        h.Index,
+		Timestamp:    h.Timestamp,
+		PrevHash:     h.PrevHash,
+		Hash:         h.Hash,
+		Nonce:        h.Nonce,
+		Transactions: txs,
+		Encrypted:    true,
+	}
+	if !h.Legacy {
+		block.MerkleRoot = h.MerkleRoot
+	}
+	return block
+}
+
+// ReadHeader membaca header plaintext block index
+func ReadHeader(index int) (models.BlockHeader, error) {
+	var h models.BlockHeader
+	data, err := os.ReadFile(headerPath(index))
+	if err != nil {
+		return h, err
+	}
+	err = json.Unmarshal(data, &h)
+	return h, err
+}
+
+// LoadHeaders membaca semua header dari storage tanpa key node
+func LoadHeaders() ([]models.BlockHeader, error) {
+	data, err := os.ReadFile(tipFile)
+	if os.IsNotExist(err) {
+		return nil, ErrNoChain
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tip models.BlockHeader
+	if err := json.Unmarshal(data, &tip); err != nil {
+		return nil, fmt.Errorf("invalid chain tip: %v", err)
+	}
+
+	headers := make([]models.BlockHeader, 0, tip.Index+1)
+	for i := 0; i <= tip.Index; i++ {
+		h, err := ReadHeader(i)
+		if err != nil {
+			return nil, fmt.Errorf("header %d: %v", i, err)
+		}
+		headers = append(headers, h)
+	}
+	if headers[len(headers)-1].Hash != tip.Hash {
+		return nil, fmt.Errorf("%w: tip does not match header %d", ErrInvalidHeader, tip.Index)
+	}
+	return headers, nil
+}
+
+// loadChainFromStorage memigrasi format lama bila perlu lalu memuat chain
+func loadChainFromStorage() bool {
+	if _, err := os.Stat(tipFile); os.IsNotExist(err) {
+		if _, err := os.Stat(legacyChainFile); err != nil {
+			return false
+		}
+		if err := migrateLegacyStorage(); err != nil {
+			log.Printf("⚠️ Failed to migrate chain storage: %v", err)
+			return false
+		}
+	}
+
+	chain, err := ReadChain()
+	if err != nil {
+		log.Printf("⚠️ Failed to load chain: %v", err)
+		return false
+	}
+	Blockchain = chain
+	return true
+}
+
+// ReadChain memverifikasi header lalu membaca chain dari storage tanpa
+// mengubahnya: block sampai height snapshot lokal terbaru diambil dari
+// snapshot, sisanya dari body.
+func ReadChain() ([]models.Block, error) {
+	headers, err := LoadHeaders()
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	chain := make([]models.Block, 0, len(headers))
+	if snap, ok := loadLocalSnapshot(headers); ok {
+		chain = append(chain, blocksFromSnapshot(snap)...)
+		log.Printf("📸 Loaded snapshot at height %d, replaying %d blocks", snap.Height, len(headers)-len(chain))
+	}
+	for _, h := range headers[len(chain):] {
+		block, err := loadDecryptedBlock(h)
+		if err != nil {
+			return nil, &VerifyError{h.Index, err.Error()}
+		}
+		chain = append(chain, block)
+	}
+	return chain, nil
+}
+
+// migrateLegacyStorage mengubah chain.bin dan block terenkripsi utuh ke format
+// header + body. Block yang sudah punya header dilewati sehingga aman diulang.
+func migrateLegacyStorage() error {
+	data, err := os.ReadFile(legacyChainFile)
+	if err != nil {
+		return err
+	}
+	plaintext, err := decryptStorage(data, legacyChainAD)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chain index: %v", err)
+	}
+	indexStr, _ := utils.ConvertForAtoi(string(plaintext))
+	lastIndex, err := strconv.Atoi(indexStr)
+	if err != nil {
+		return fmt.Errorf("invalid chain index: %v", err)
+	}
+
+	log.Printf("🔄 Migrating %d blocks to header + body storage...", lastIndex+1)
+	for i := 0; i <= lastIndex; i++ {
+		if _, err := os.Stat(headerPath(i)); err == nil {
+			continue
+		}
+		block, err := loadLegacyBlock(i)
+		if err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+		if err := saveEncryptedBlock(block); err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+	}
+
+	if err := os.Remove(legacyChainFile); err != nil {
+		log.Printf("⚠️ Could not remove legacy chain index: %v", err)
+	}
+	log.Println("✅ Chain storage migrated")
+	return nil
+}
+
+// loadLegacyBlock membaca block format lama yang terenkripsi utuh
+func loadLegacyBlock(index int) (models.Block, error) {
+	data, err := os.ReadFile(bodyPath(index))
+	if err != nil {
+		return models.Block{}, fmt.Errorf("read failed: %v", err)
+	}
+	plaintext, err := decryptStorage(data, blockAD(index))
+	if err != nil {
+		return models.Block{}, fmt.Errorf("decryption failed: %v", err)
+	}
+	var block models.Block
+	if err := json.Unmarshal(plaintext, &block); err != nil {
+		return models.Block{}, fmt.Errorf("unmarshal failed: %v", err)
+	}
+	return block, nil
+}
+
+// decryptStorage membuka file format lama. File sebelum keymanager dibaca
+// dengan legacyDecrypt.
+func decryptStorage(data, ad []byte) ([]byte, error) {
+	if keymanager.IsManaged(data) {
+		return keymanager.Decrypt(data, ad)
+	}
+	legacyOnce.Do(func() {
+		log.Println("🟡 Chain storage uses the legacy format, it will be migrated")
+	})
+	return legacyDecrypt(data)
+}
+
+// legacyDecrypt membuka file block sebelum keymanager. Format lama (ditulis
+// utils.ECIESEncrypt versi awal) bukan ECIES sungguhan dan tidak memakai key:
+//
+//	pad[32] | plaintext XOR pad (pad diulang)
+//
+// Hanya untuk migrasi; format ini tidak boleh dipakai untuk menulis data baru.
+func legacyDecrypt(data []byte) ([]byte, error) {
+	const padSize = 32
+	if len(data) < padSize {
+		return nil, errors.New("legacy block file too short")
+	}
+	pt := make([]byte, len(data)-padSize)
+	for i := range pt {
+		pt[i] = data[i+padSize] ^ data[i%padSize]
+	}
+	return pt, nil
+}
+
+// ReencryptStorage mengenkripsi ulang body block dan snapshot yang belum
+// memakai key storage aktif. Header plaintext tidak berubah. Mengembalikan
+// jumlah file yang ditulis ulang.
+func ReencryptStorage() (int, error) {
+	chainMutex.Lock()
+	defer chainMutex.Unlock()
+
+	headers, err := LoadHeaders()
+	if errors.Is(err, ErrNoChain) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, h := range headers {
+		data, err := os.ReadFile(bodyPath(h.Index))
+		if os.IsNotExist(err) {
+			continue // body sudah di-prune
+		}
+		if err != nil {
+			return rewritten, err
+		}
+		if keymanager.IsCurrent(data) {
+			continue
+		}
+		plaintext, err := keymanager.Decrypt(data, bodyAD(h))
+		if err != nil {
+			return rewritten, fmt.Errorf("block %d: %v", h.Index, err)
+		}
+		encrypted, err := keymanager.Encrypt(plaintext, bodyAD(h))
+		if err != nil {
+			return rewritten, err
+		}
+		if err := writeFileAtomic(bodyPath(h.Index), encrypted, 0600); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+
+	for _, height := range snapshotHeights() {
+		data, err := os.ReadFile(snapshotPath(height))
+		if err != nil {
+			return rewritten, err
+		}
+		if keymanager.IsCurrent(data) {
+			continue
+		}
+		signed, err := loadSnapshot(height)
+		if err != nil {
+			return rewritten, fmt.Errorf("snapshot %d: %v", height, err)
+		}
+		if err := saveSnapshot(height, signed); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+// clearStorage menghapus semua header, body dan tip sebelum chain diganti
+func clearStorage() error {
+	for _, pattern := range []string{filepath.Join(headersDir, "*.json"), filepath.Join(blocksDir, "*.bin")} {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Remove(tipFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
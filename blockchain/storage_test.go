@@ -0,0 +1,233 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Key node dari token software di direktori sementara
+	dir, err := os.MkdirTemp("", "doctracker-keys")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("KEYMANAGER_SOURCE", "file")
+	os.Setenv("KEYMANAGER_DIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useTempStorage mengarahkan storage chain ke direktori sementara
+func useTempStorage(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	prev := []string{headersDir, blocksDir, tipFile, legacyChainFile, snapshotsDir}
+	headersDir = filepath.Join(dir, "headers")
+	blocksDir = filepath.Join(dir, "blocks")
+	tipFile = filepath.Join(dir, "chain.json")
+	legacyChainFile = filepath.Join(dir, "chain.bin")
+	snapshotsDir = filepath.Join(dir, "snapshots")
+	t.Cleanup(func() {
+		headersDir, blocksDir, tipFile, legacyChainFile, snapshotsDir = prev[0], prev[1], prev[2], prev[3], prev[4]
+	})
+}
+
+// storedBlock membuat block format baru (header + merkle root) dari transaksi
+func storedBlock(index int, txs []models.Tracker) models.Block {
+	b := models.Block{
+		Index:        index,
+		Timestamp:    1700000000 + int64(index),
+		PrevHash:     "0000prev",
+		MerkleRoot:   MerkleRoot(txs),
+		Transactions: txs,
+	}
+	b.Hash = CalculateHash(b)
+	return b
+}
+
+func TestEncryptedBlockRoundTrip(t *testing.T) {
+	useTempStorage(t)
+
+	block := storedBlock(1, []models.Tracker{legacyTrackerFixture(), legacyV1TrackerFixture()})
+	if err := saveEncryptedBlock(block); err != nil {
+		t.Fatalf("saveEncryptedBlock: %v", err)
+	}
+
+	raw, err := os.ReadFile(bodyPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keymanager.IsCurrent(raw) || bytes.Contains(raw, []byte("trk-legacy-1")) {
+		t.Fatalf("block body is not encrypted with the current storage key")
+	}
+
+	h, err := ReadHeader(1)
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	if h != HeaderOf(block) {
+		t.Fatalf("ReadHeader = %+v, want %+v", h, HeaderOf(block))
+	}
+	got, err := loadDecryptedBlock(h)
+	if err != nil {
+		t.Fatalf("loadDecryptedBlock: %v", err)
+	}
+	if got.Hash != block.Hash || MerkleRoot(got.Transactions) != block.MerkleRoot || len(got.Transactions) != 2 {
+		t.Fatalf("loadDecryptedBlock = %+v, want %+v", got, block)
+	}
+}
+
+func TestEncryptedBlockRejectsTampering(t *testing.T) {
+	cases := []struct {
+		name    string
+		tamper  func(t *testing.T, h *models.BlockHeader)
+		wantErr string
+	}{
+		{
+			name:    "header hash changed",
+			tamper:  func(t *testing.T, h *models.BlockHeader) { h.Hash = strings.Repeat("0", 64) },
+			wantErr: "decryption failed",
+		},
+		{
+			name: "body of another block",
+			tamper: func(t *testing.T, h *models.BlockHeader) {
+				other := storedBlock(2, []models.Tracker{legacyV1TrackerFixture()})
+				if err := saveEncryptedBlock(other); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(bodyPath(2), bodyPath(1)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "decryption failed",
+		},
+		{
+			name: "body byte flipped",
+			tamper: func(t *testing.T, h *models.BlockHeader) {
+				data, err := os.ReadFile(bodyPath(1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data[len(data)-1] ^= 0xff
+				if err := os.WriteFile(bodyPath(1), data, 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "decryption failed",
+		},
+		{
+			// Body dienkripsi ulang dengan AD yang benar tapi isi berbeda
+			name: "body does not match merkle root",
+			tamper: func(t *testing.T, h *models.BlockHeader) {
+				forged := storedBlock(1, []models.Tracker{legacyV1TrackerFixture()})
+				forged.Hash = h.Hash
+				forged.MerkleRoot = h.MerkleRoot
+				if err := saveEncryptedBlock(forged); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "merkle root",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useTempStorage(t)
+			block := storedBlock(1, []models.Tracker{legacyTrackerFixture()})
+			if err := saveEncryptedBlock(block); err != nil {
+				t.Fatal(err)
+			}
+
+			h := HeaderOf(block)
+			tc.tamper(t, &h)
+			if _, err := loadDecryptedBlock(h); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("loadDecryptedBlock err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLegacyDecrypt(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"index":1,"transactions":[]}` + strings.Repeat("x", 40))
+	data, err := utils.ECIESEncrypt(rand.Reader, utils.ImportECDSAPublic(&priv.PublicKey), plaintext, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := legacyDecrypt(data)
+	if err != nil {
+		t.Fatalf("legacyDecrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("legacyDecrypt = %q, want %q", got, plaintext)
+	}
+	if _, err := legacyDecrypt(data[:31]); err == nil {
+		t.Fatal("legacyDecrypt accepted a file shorter than the pad")
+	}
+}
+
+func TestReencryptStorageAfterRotation(t *testing.T) {
+	useTempStorage(t)
+
+	genesis := storedBlock(0, nil)
+	block := storedBlock(1, []models.Tracker{legacyTrackerFixture()})
+	for _, b := range []models.Block{genesis, block} {
+		if err := saveEncryptedBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw, err := os.ReadFile(bodyPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, err := keymanager.KeyIDOf(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keymanager.Rotate(keymanager.PurposeStorage); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	n, err := ReencryptStorage()
+	if err != nil {
+		t.Fatalf("ReencryptStorage: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("ReencryptStorage rewrote %d files, want 2", n)
+	}
+	if n, err := ReencryptStorage(); err != nil || n != 0 {
+		t.Fatalf("second ReencryptStorage = %d, %v; want 0, nil", n, err)
+	}
+
+	// Key lama dihapus: body harus tetap bisa dibuka dengan key baru
+	if err := keymanager.Retire(oldID); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	raw, err = os.ReadFile(bodyPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keymanager.IsCurrent(raw) {
+		t.Fatal("block body still uses the retired key")
+	}
+	got, err := loadDecryptedBlock(HeaderOf(block))
+	if err != nil {
+		t.Fatalf("loadDecryptedBlock after rotation: %v", err)
+	}
+	if got.Hash != block.Hash {
+		t.Fatalf("loadDecryptedBlock hash = %s, want %s", got.Hash, block.Hash)
+	}
+}
@@ -0,0 +1,127 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"doc-tracker/evidence"
+	"doc-tracker/models"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VerifyError menunjuk block pertama yang gagal diverifikasi
+type VerifyError struct {
+	Index  int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("block %d: %s", e.Index, e.Reason)
+}
+
+// VerifyReport ringkasan verifikasi chain
+type VerifyReport struct {
+	Blocks          int `json:"blocks"`
+	Trackers        int `json:"trackers"`
+	Evidence        int `json:"evidence"`         // evidence hash yang tercatat
+	EvidenceChecked int `json:"evidence_checked"` // evidence yang di-hash ulang
+	EvidenceMissing int `json:"evidence_missing"` // evidence tidak ditemukan di store
+}
+
+// VerifyChain memeriksa ulang seluruh chain: link, hash, proof-of-work, merkle
+// root dan format evidence hash. Jika checkEvidence, evidence dari store
+// di-hash ulang dan dibandingkan dengan hash on-chain.
+func VerifyChain(blocks []models.Block, checkEvidence bool) (VerifyReport, error) {
+	var report VerifyReport
+	for i, b := range blocks {
+		if i == 0 {
+			if b.Index != 0 || b.PrevHash != "0" {
+				return report, &VerifyError{b.Index, "first block is not a genesis block"}
+			}
+		} else {
+			prev := blocks[i-1]
+			if b.Index != prev.Index+1 {
+				return report, &VerifyError{b.Index, fmt.Sprintf("index does not follow %d", prev.Index)}
+			}
+			if b.PrevHash != prev.Hash {
+				return report, &VerifyError{b.Index, "prev hash does not match previous block"}
+			}
+			if !strings.HasPrefix(b.Hash, powPrefix) {
+				return report, &VerifyError{b.Index, "proof of work not satisfied"}
+			}
+		}
+		if b.MerkleRoot != "" && MerkleRoot(b.Transactions) != b.MerkleRoot {
+			return report, &VerifyError{b.Index, "merkle root does not match transactions"}
+		}
+		if CalculateHash(b) != b.Hash {
+			return report, &VerifyError{b.Index, "hash mismatch"}
+		}
+
+		for _, tx := range b.Transactions {
+			report.Trackers++
+			for _, cp := range tx.Checkpoints {
+				for _, item := range cp.EvidenceItems() {
+					if item.Hash == "" {
+						continue
+					}
+					report.Evidence++
+					if !isSHA256Hex(item.Hash) {
+						return report, &VerifyError{b.Index, fmt.Sprintf("tracker %s: malformed evidence hash", tx.ID)}
+					}
+					if !checkEvidence {
+						continue
+					}
+					expected := item.Hash
+					if len(item.Keys) > 0 {
+						// Evidence terenkripsi: bandingkan dengan hash ciphertext di store
+						expected = evidence.RefHash(item.Path)
+					}
+					ok, err := evidenceMatches(item.Path, expected)
+					if os.IsNotExist(err) || item.Path == "" {
+						report.EvidenceMissing++
+						continue
+					}
+					if err != nil {
+						return report, &VerifyError{b.Index, fmt.Sprintf("tracker %s: %v", tx.ID, err)}
+					}
+					if !ok {
+						return report, &VerifyError{b.Index, fmt.Sprintf("tracker %s: evidence %s does not match its hash", tx.ID, item.Path)}
+					}
+					report.EvidenceChecked++
+				}
+			}
+		}
+		report.Blocks++
+	}
+	return report, nil
+}
+
+// evidenceMatches meng-hash ulang evidence dari evidence store (atau path lama)
+func evidenceMatches(path, hash string) (bool, error) {
+	if path == "" {
+		return false, os.ErrNotExist
+	}
+	r, _, err := evidence.Open(context.Background(), strings.ToLower(hash), path)
+	if errors.Is(err, evidence.ErrNotFound) {
+		return false, os.ErrNotExist
+	}
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return false, err
+	}
+	return strings.EqualFold(hex.EncodeToString(h.Sum(nil)), hash), nil
+}
+
+func isSHA256Hex(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/services"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var chainCommands = map[string]command{
+	"snapshot": {
+		usage: "create a signed snapshot of the chain (tracker index + headers)",
+		run:   runChainSnapshot,
+	},
+	"prune": {
+		usage: "delete block bodies covered by the latest snapshot, keeping headers",
+		run:   runChainPrune,
+	},
+	"bootstrap": {
+		usage: "initialise an empty node from a peer snapshot and replay newer blocks",
+		run:   runChainBootstrap,
+	},
+	"export": {
+		usage: "write the chain to a signed JSON lines archive",
+		run:   runChainExport,
+	},
+	"import": {
+		usage: "verify a chain archive and replace the local chain with it (stop the node first)",
+		run:   runChainImport,
+	},
+	"verify": {
+		usage: "re-check hashes, links, proof of work, signatures and evidence hashes",
+		run:   runChainVerify,
+	},
+}
+
+// loadChain memuat key node dan chain lokal
+func loadChain() error {
+	if err := keymanager.Init(); err != nil {
+		return err
+	}
+	blockchain.InitChain()
+	return nil
+}
+
+func runChainSnapshot(args []string) error {
+	fs := flag.NewFlagSet("chain snapshot", flag.ExitOnError)
+	fs.Parse(args)
+
+	if err := loadChain(); err != nil {
+		return err
+	}
+	snap, err := blockchain.CreateSnapshot()
+	if err != nil {
+		return err
+	}
+	pub, _ := keymanager.PublicKey()
+	fmt.Printf("✅ Snapshot at height %d (%d trackers), signer %s\n", snap.Height, len(snap.Trackers), keymanager.Fingerprint(pub))
+	return nil
+}
+
+func runChainPrune(args []string) error {
+	fs := flag.NewFlagSet("chain prune", flag.ExitOnError)
+	keep := fs.Int("keep", 100, "number of most recent block bodies to keep")
+	fs.Parse(args)
+
+	if *keep < 0 {
+		return errors.New("--keep must not be negative")
+	}
+	if err := loadChain(); err != nil {
+		return err
+	}
+	n, err := blockchain.PruneBodies(*keep)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Pruned %d block bodies\n", n)
+	return nil
+}
+
+func runChainBootstrap(args []string) error {
+	fs := flag.NewFlagSet("chain bootstrap", flag.ExitOnError)
+	peer := fs.String("peer", "", "peer address, host:port or URL (required)")
+	trust := fs.String("trust", "", "comma separated signer fingerprints (default SNAPSHOT_TRUSTED_KEYS)")
+	force := fs.Bool("force", false, "replace the existing local chain")
+	fs.Parse(args)
+
+	if *peer == "" {
+		return errors.New("--peer is required")
+	}
+	trusted := services.TrustedSnapshotSigners()
+	if *trust != "" {
+		trusted = strings.Split(*trust, ",")
+	}
+	if err := keymanager.Init(); err != nil {
+		return err
+	}
+
+	result, err := services.BootstrapFromPeer(*peer, trusted, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Bootstrapped from %s: snapshot height %d signed by %s, replayed %d blocks\n",
+		result.Peer, result.Height, result.Signer, result.Replayed)
+	return nil
+}
+
+func runChainExport(args []string) error {
+	fs := flag.NewFlagSet("chain export", flag.ExitOnError)
+	out := fs.String("out", "chain-export.jsonl", "output file, - for stdout")
+	fs.Parse(args)
+
+	if err := keymanager.Init(); err != nil {
+		return err
+	}
+	blocks, err := blockchain.ReadChain()
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "-" {
+		f, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := blockchain.ExportArchive(w, blocks); err != nil {
+		return err
+	}
+	if *out != "-" {
+		fmt.Printf("✅ Exported %d blocks to %s\n", len(blocks), *out)
+	}
+	return nil
+}
+
+func runChainImport(args []string) error {
+	fs := flag.NewFlagSet("chain import", flag.ExitOnError)
+	file := fs.String("file", "", "archive created by chain export (required)")
+	trust := fs.String("trust", "", "comma separated signer fingerprints to accept in addition to this node and SNAPSHOT_TRUSTED_KEYS")
+	anySigner := fs.Bool("any-signer", false, "accept an archive signed by any key")
+	force := fs.Bool("force", false, "replace the existing local chain")
+	fs.Parse(args)
+
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+	if err := keymanager.Init(); err != nil {
+		return err
+	}
+	archive, err := readArchiveFile(*file, *trust, *anySigner)
+	if err != nil {
+		return err
+	}
+	if err := blockchain.ImportChain(archive.Blocks, *force); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Imported %d blocks signed by %s\n", len(archive.Blocks), archive.Signer)
+	return nil
+}
+
+func runChainVerify(args []string) error {
+	fs := flag.NewFlagSet("chain verify", flag.ExitOnError)
+	file := fs.String("file", "", "verify an archive instead of the local chain")
+	trust := fs.String("trust", "", "comma separated signer fingerprints to accept in addition to this node and SNAPSHOT_TRUSTED_KEYS (archives only)")
+	anySigner := fs.Bool("any-signer", false, "accept an archive signed by any key (archives only)")
+	evidence := fs.Bool("evidence", false, "re-hash evidence files found on local disk")
+	headersOnly := fs.Bool("headers", false, "verify local headers only, no node key needed")
+	fs.Parse(args)
+
+	var blocks []models.Block
+	switch {
+	case *file != "":
+		archive, err := readArchiveFile(*file, *trust, *anySigner)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archive signed by %s\n", archive.Signer)
+		blocks = archive.Blocks
+	case *headersOnly:
+		headers, err := blockchain.LoadHeaders()
+		if err != nil {
+			return err
+		}
+		if err := blockchain.VerifyHeaders(headers); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d headers verified\n", len(headers))
+		return nil
+	default:
+		if err := keymanager.Init(); err != nil {
+			return err
+		}
+		var err error
+		if blocks, err = blockchain.ReadChain(); err != nil {
+			return err
+		}
+	}
+
+	report, err := blockchain.VerifyChain(blocks, *evidence)
+	if err != nil {
+		var verr *blockchain.VerifyError
+		if errors.As(err, &verr) {
+			fmt.Printf("❌ First bad block: %d (%s)\n", verr.Index, verr.Reason)
+		}
+		return err
+	}
+	fmt.Printf("✅ %d blocks, %d trackers verified, %d evidence hashes", report.Blocks, report.Trackers, report.Evidence)
+	if *evidence {
+		fmt.Printf(" (%d files re-hashed, %d not on local disk)", report.EvidenceChecked, report.EvidenceMissing)
+	}
+	fmt.Println()
+	return nil
+}
+
+// readArchiveFile membaca archive yang ditandatangani node ini, signer di
+// SNAPSHOT_TRUSTED_KEYS atau --trust; signer lain hanya dengan --any-signer
+func readArchiveFile(path, trust string, anySigner bool) (blockchain.Archive, error) {
+	var trusted []string
+	if !anySigner {
+		if pub, err := keymanager.PublicKey(); err == nil {
+			trusted = append(trusted, keymanager.Fingerprint(pub))
+		}
+		trusted = append(trusted, blockchain.TrustedSigners()...)
+		for _, fp := range strings.Split(trust, ",") {
+			if fp = strings.TrimSpace(fp); fp != "" {
+				trusted = append(trusted, fp)
+			}
+		}
+		if len(trusted) == 0 {
+			return blockchain.Archive{}, errors.New("no trusted signers: pass --trust or --any-signer")
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return blockchain.Archive{}, err
+	}
+	defer f.Close()
+	return blockchain.ReadArchive(f, trusted)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/mempool"
+	"doc-tracker/services"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+var keysCommands = map[string]command{
+	"status": {
+		usage: "list node keys (ids and purposes only, never key material)",
+		run:   runKeysStatus,
+	},
+	"rotate": {
+		usage: "create a new node key and re-encrypt mempool and block files",
+		run:   runKeysRotate,
+	},
+	"reencrypt": {
+		usage: "re-encrypt mempool and block files with the current storage key",
+		run:   runKeysReencrypt,
+	},
+	"retire": {
+		usage: "delete an old node key after its data has been re-encrypted",
+		run:   runKeysRetire,
+	},
+}
+
+func runKeysStatus(args []string) error {
+	fs := flag.NewFlagSet("keys status", flag.ExitOnError)
+	fs.Parse(args)
+
+	keys, err := keymanager.Keys()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		state := ""
+		switch {
+		case k.Current:
+			state = "current"
+		case k.RetiredAt != 0:
+			state = "retired " + time.Unix(k.RetiredAt, 0).Format(time.RFC3339)
+		}
+		fmt.Printf("%-8s %-24s %s\n", k.Purpose, k.ID, state)
+	}
+	if pub, err := keymanager.PublicKey(); err == nil {
+		fmt.Printf("signing fingerprint: %s\n", keymanager.Fingerprint(pub))
+	}
+	return nil
+}
+
+func runKeysRotate(args []string) error {
+	fs := flag.NewFlagSet("keys rotate", flag.ExitOnError)
+	purpose := fs.String("purpose", string(keymanager.PurposeStorage), "key purpose: storage or signing")
+	retire := fs.Bool("retire", false, "retire the previous key after re-encryption")
+	force := fs.Bool("force", false, "run even if a node appears to be running")
+	fs.Parse(args)
+
+	p := keymanager.Purpose(*purpose)
+	if p != keymanager.PurposeStorage && p != keymanager.PurposeSigning {
+		return fmt.Errorf("unknown purpose %q", *purpose)
+	}
+	if err := checkNodeStopped(*force); err != nil {
+		return err
+	}
+	if err := loadNodeState(); err != nil {
+		return err
+	}
+
+	newID, result, err := services.RotateNodeKey(p, *retire)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ New %s key %s\n", p, newID)
+	if p == keymanager.PurposeStorage {
+		printReencrypt(result)
+	}
+	return nil
+}
+
+func runKeysReencrypt(args []string) error {
+	fs := flag.NewFlagSet("keys reencrypt", flag.ExitOnError)
+	force := fs.Bool("force", false, "run even if a node appears to be running")
+	fs.Parse(args)
+
+	if err := checkNodeStopped(*force); err != nil {
+		return err
+	}
+	if err := loadNodeState(); err != nil {
+		return err
+	}
+	result, err := services.ReencryptNodeData()
+	if err != nil {
+		return err
+	}
+	printReencrypt(result)
+	return nil
+}
+
+func runKeysRetire(args []string) error {
+	fs := flag.NewFlagSet("keys retire", flag.ExitOnError)
+	id := fs.String("id", "", "key id to retire (required)")
+	fs.Parse(args)
+
+	if *id == "" {
+		return errors.New("--id is required")
+	}
+	if err := keymanager.Retire(*id); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Key %s retired\n", *id)
+	return nil
+}
+
+// checkNodeStopped menolak jalan jika node lokal masih listen di PORT: node
+// yang berjalan menulis ulang mempool.bin dari state di memorinya sendiri.
+// --force hanya memberi peringatan.
+func checkNodeStopped(force bool) error {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3002"
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", port), time.Second)
+	if err != nil {
+		return nil
+	}
+	conn.Close()
+	if !force {
+		return fmt.Errorf("a node is listening on port %s; stop it before re-encrypting node data (or pass --force)", port)
+	}
+	fmt.Printf("🟡 A node is listening on port %s; it will re-wrap its mempool key on the next save\n", port)
+	return nil
+}
+
+// loadNodeState memuat mempool dan chain agar file lama bisa dibaca sebelum ditulis ulang
+func loadNodeState() error {
+	if err := keymanager.Init(); err != nil {
+		return err
+	}
+	if err := mempool.LoadFromFile(); err != nil {
+		fmt.Printf("🟡 Mempool not loaded: %v\n", err)
+	}
+	blockchain.InitChain()
+	return nil
+}
+
+func printReencrypt(r services.ReencryptResult) {
+	fmt.Printf("Storage key %s: mempool key rewrapped=%t, chain files rewritten=%d\n", r.KeyID, r.Mempool, r.Files)
+}
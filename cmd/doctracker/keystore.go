@@ -0,0 +1,78 @@
+package main
+
+import (
+	"doc-tracker/keystore"
+	"flag"
+	"fmt"
+)
+
+var keystoreCommands = map[string]command{
+	"migrate": {
+		usage: "import plaintext mnemonic files into the encrypted keystore",
+		run:   runKeystoreMigrate,
+	},
+	"upgrade": {
+		usage: "rewrite legacy (v1, P-256) entries to BIP-44 secp256k1 keys",
+		run:   runKeystoreUpgrade,
+	},
+}
+
+func runKeystoreMigrate(args []string) error {
+	fs := flag.NewFlagSet("keystore migrate", flag.ExitOnError)
+	dir := fs.String("dir", keystore.LegacyDir, "directory with <email>.txt mnemonic files")
+	keep := fs.Bool("keep", false, "keep plaintext files after import")
+	fs.Parse(args)
+
+	result, err := keystore.MigrateLegacyDir(*dir, !*keep)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range result.Imported {
+		fmt.Printf("✅ imported %s\n", email)
+	}
+	for _, email := range result.Skipped {
+		fmt.Printf("🟡 skipped %s (already in keystore)\n", email)
+	}
+	for email, err := range result.Failed {
+		fmt.Printf("❌ failed %s: %v\n", email, err)
+	}
+	fmt.Printf("Imported %d, skipped %d, failed %d\n", len(result.Imported), len(result.Skipped), len(result.Failed))
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d file(s) could not be imported", len(result.Failed))
+	}
+	return nil
+}
+
+func runKeystoreUpgrade(args []string) error {
+	fs := flag.NewFlagSet("keystore upgrade", flag.ExitOnError)
+	email := fs.String("email", "", "upgrade a single entry (default all)")
+	fs.Parse(args)
+
+	emails := []string{*email}
+	if *email == "" {
+		var err error
+		if emails, err = keystore.List(); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, e := range emails {
+		kf, upgraded, err := keystore.Upgrade(e)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("❌ %s: %v\n", e, err)
+		case upgraded && kf.LegacyAddress != "":
+			fmt.Printf("✅ upgraded %s: %s (legacy %s)\n", e, kf.Address, kf.LegacyAddress)
+		case upgraded:
+			fmt.Printf("✅ upgraded %s\n", e)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d entries failed to upgrade", failed)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Command doctracker adalah CLI administrasi node Doc-Tracker.
+//
+// Usage:
+//
+//	doctracker <group> <command> [flags]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// command adalah satu subcommand CLI, menerima argumen setelah nama command
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var groups = map[string]map[string]command{
+	"keystore": keystoreCommands,
+	"wallet":   walletCommands,
+	"notes":    notesCommands,
+	"keys":     keysCommands,
+	"chain":    chainCommands,
+}
+
+func main() {
+	// .env opsional, sama seperti server
+	_ = godotenv.Load()
+
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(1)
+	}
+
+	group, ok := groups[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+	cmd, ok := group[os.Args[2]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+
+	if err := cmd.run(os.Args[3:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <group> <command> [flags]\n\nCommands:\n", os.Args[0])
+	for name, group := range groups {
+		for cmdName, cmd := range group {
+			fmt.Fprintf(os.Stderr, "  %s %s\t%s\n", name, cmdName, cmd.usage)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/mempool"
+	"doc-tracker/services"
+	"flag"
+	"fmt"
+)
+
+var notesCommands = map[string]command{
+	"migrate": {
+		usage: "re-encrypt legacy checkpoint notes in the mempool with ECIES (stop the node first)",
+		run:   runNotesMigrate,
+	},
+}
+
+func runNotesMigrate(args []string) error {
+	fs := flag.NewFlagSet("notes migrate", flag.ExitOnError)
+	fs.Parse(args)
+
+	if err := mempool.LoadFromFile(); err != nil {
+		return fmt.Errorf("failed to load mempool: %v", err)
+	}
+	blockchain.InitChain()
+
+	result, err := services.MigrateLegacyNotes()
+	if err != nil {
+		return err
+	}
+
+	for key, ferr := range result.Failed {
+		fmt.Printf("❌ %s: %v\n", key, ferr)
+	}
+	fmt.Printf("Migrated %d notes, %d failed\n", result.Migrated, len(result.Failed))
+	if result.Mined > 0 {
+		fmt.Printf("⚠️ %d legacy notes are in mined blocks and stay readable through the compatibility decrypt path\n", result.Mined)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d notes failed to migrate", len(result.Failed))
+	}
+	return nil
+}
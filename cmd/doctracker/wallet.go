@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"doc-tracker/keystore"
+	"doc-tracker/services"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var walletCommands = map[string]command{
+	"export": {
+		usage: "write a password-protected keystore JSON for a custodial wallet",
+		run:   runWalletExport,
+	},
+	"import": {
+		usage: "import a mnemonic or exported keystore JSON for an email",
+		run:   runWalletImport,
+	},
+	"register": {
+		usage: "register a public-key-only (non-custodial) wallet for an email",
+		run:   runWalletRegister,
+	},
+}
+
+func runWalletExport(args []string) error {
+	fs := flag.NewFlagSet("wallet export", flag.ExitOnError)
+	email := fs.String("email", "", "wallet owner email (required)")
+	out := fs.String("out", "", "output file (default <email>.keystore.json)")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+	if *out == "" {
+		*out = *email + ".keystore.json"
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	data, err := services.ExportWallet(*email, password)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Wallet for %s exported to %s\n", *email, *out)
+	return nil
+}
+
+func runWalletImport(args []string) error {
+	fs := flag.NewFlagSet("wallet import", flag.ExitOnError)
+	email := fs.String("email", "", "wallet owner email (required)")
+	mnemonicFile := fs.String("mnemonic-file", "", "file containing a BIP-39 mnemonic")
+	keystoreFile := fs.String("keystore", "", "keystore JSON produced by wallet export")
+	fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+
+	var (
+		wallet services.WalletInfo
+		err    error
+	)
+	switch {
+	case *mnemonicFile != "":
+		data, rerr := os.ReadFile(*mnemonicFile)
+		if rerr != nil {
+			return rerr
+		}
+		wallet, err = services.ImportWalletMnemonic(*email, string(data))
+	case *keystoreFile != "":
+		data, rerr := os.ReadFile(*keystoreFile)
+		if rerr != nil {
+			return rerr
+		}
+		password, perr := readPassword()
+		if perr != nil {
+			return perr
+		}
+		wallet, err = services.ImportWalletExport(*email, data, password)
+	default:
+		return errors.New("--mnemonic-file or --keystore is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Wallet for %s imported, address %s\n", *email, wallet.Address)
+	return nil
+}
+
+func runWalletRegister(args []string) error {
+	fs := flag.NewFlagSet("wallet register", flag.ExitOnError)
+	email := fs.String("email", "", "wallet owner email (required)")
+	publicKey := fs.String("public-key", "", "P-256 public key in hex (required)")
+	fs.Parse(args)
+
+	if *email == "" || *publicKey == "" {
+		return errors.New("--email and --public-key are required")
+	}
+
+	// Operator CLI dipercaya, tidak perlu bukti signature seperti endpoint HTTP
+	kf, err := keystore.StorePublicKey(*email, *publicKey)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Public key registered for %s, address %s\n", *email, kf.Address)
+	return nil
+}
+
+// readPassword membaca password dari WALLET_PASSWORD atau satu baris stdin
+func readPassword() (string, error) {
+	if p := os.Getenv("WALLET_PASSWORD"); p != "" {
+		return p, nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
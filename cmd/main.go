@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"doc-tracker/blockchain"
+	"doc-tracker/evidence"
 	"doc-tracker/grpc"
+	"doc-tracker/keymanager"
 	"doc-tracker/mempool"
 	"doc-tracker/middlewares"
 	"doc-tracker/routes"
@@ -12,6 +14,7 @@ import (
 	"doc-tracker/storage/redis"
 	"doc-tracker/utils"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -61,22 +64,29 @@ func main() {
 		fmt.Println("✅ .env file loaded successfully")
 	}
 
-	fmt.Println("✅ Checking and creating ECDSA keys if not exist...")
-	utils.CreatePemIfNotExists("data/private.pem")
-	utils.CreatePemIfNotExists("data/public.pem")
+	if err := keymanager.Init(); err != nil {
+		log.Fatalf("❌ Failed to load node keys: %v", err)
+	}
 
 	fmt.Println("[Init] Starting Doc-Tracker Node...")
 
 	redis.InitRedis()
 	fmt.Println("[Redis] Redis initialized")
 
+	services.MigrateLegacyWallets()
+
+	// Node baru bisa bootstrap dari snapshot peer daripada sync dari genesis
+	if peer := os.Getenv("BOOTSTRAP_PEER"); peer != "" && !blockchain.HasLocalChain() {
+		if result, err := services.BootstrapFromPeer(peer, services.TrustedSnapshotSigners(), false); err != nil {
+			log.Printf("⚠️ Bootstrap from %s failed: %v", peer, err)
+		} else {
+			fmt.Printf("[Blockchain] Bootstrapped from %s at height %d (+%d blocks)\n", peer, result.Height, result.Replayed)
+		}
+	}
+
 	blockchain.InitChain()
 	fmt.Println("[Blockchain] Chain loaded")
 
-	// Inisialisasi kunci
-	if _, err := mempool.InitKeys(); err != nil {
-		fmt.Printf("Failed to initialize keys: %v", err)
-	}
 	// Inisialisasi mempool
 	if err := mempool.InitEncryptMempool(); err != nil {
 		fmt.Printf("Warning: %v", err)
@@ -98,6 +108,11 @@ func main() {
 	services.StartSyncWorker()
 	fmt.Println("[Sync] Worker started")
 
+	services.StartEvidenceAuditWorker()
+	fmt.Println("[EvidenceAudit] Worker started")
+
+	services.StartAuditAnchorWorker()
+
 	ctx := context.Background()
 	storage.S3 = storage.InitializeS3Storage(ctx)
 	fmt.Println("[S3] Storage initialized")
@@ -106,7 +121,14 @@ func main() {
 	go grpc.StartGRPCServer("3003")
 	fmt.Println("[GRPC] Server started on port 3003")
 
-	app := fiber.New()
+	// Body di-stream agar upload evidence tidak di-buffer; batas body untuk
+	// route lain dipasang middlewares.BodyLimit
+	bodyLimit := utils.GetEnvInt("HTTP_BODY_LIMIT", 4<<20)
+	app := fiber.New(fiber.Config{
+		BodyLimit:                    bodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
 
 	// allowedOrigins := ""
 	// if os.Getenv("ENV") == "development" {
@@ -131,7 +153,11 @@ func main() {
 		return c.Next()
 	})
 
+	// Audit log berantai hash untuk setiap request (termasuk yang ditolak limiter)
+	app.Use(middlewares.Audit())
+
 	app.Use(limiter.New(limiter.Config{Max: 100, Expiration: time.Minute}))
+	app.Use(middlewares.BodyLimit(bodyLimit, "/api/upload", "/api/evidence/uploads/", evidence.SignedPath, "/api/documents/"))
 
 	routes.P2PRoutes(app)
 	routes.SyncRoutes(app)
@@ -186,12 +212,19 @@ func HandlerApiProtectedRoute(app *fiber.App) {
 	routes.RegisterEvidenceRoutes(protected)
 	routes.RegisterCheckpointRoutes(protected)
 	routes.BlockRoutes(protected)
+	routes.WalletRoutes(protected)
+	routes.RegisterDocumentRoutes(protected)
+	routes.RegisterAuditRoutes(protected)
+	routes.TemplateRoutes(protected)
 
 }
 
 func HandlerApiRoute(app *fiber.App) {
 	api := app.Group("/api")
 	routes.SetupAuthRoutes(api)
+	routes.RegisterEvidenceSignedRoutes(api)
+	routes.RegisterDocumentPublicRoutes(api)
+	routes.TrackerPublicRoutes(api)
 }
 
 func killProcessOnPort(port int) error {
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"bytes"
+	"doc-tracker/services"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireAuditor memastikan user login adalah admin atau auditor
+func requireAuditor(c *fiber.Ctx) (string, error) {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if !services.IsAuditorOrAdmin(email) {
+		return "", fiber.NewError(fiber.StatusForbidden, "Admin or auditor role required")
+	}
+	return email, nil
+}
+
+// auditFilter membaca filter dari query: actor, action (prefix), resource,
+// result, ip, from/to (RFC3339 atau unix milli), limit dan offset
+func auditFilter(c *fiber.Ctx) (services.AuditFilter, error) {
+	f := services.AuditFilter{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Resource: c.Query("resource"),
+		Result:   c.Query("result"),
+		IP:       c.Query("ip"),
+		Limit:    c.QueryInt("limit", 100),
+		Offset:   c.QueryInt("offset", 0),
+	}
+	var err error
+	if f.From, err = auditTime(c.Query("from")); err != nil {
+		return f, fiber.NewError(fiber.StatusBadRequest, "from must be RFC3339 or unix milliseconds")
+	}
+	if f.To, err = auditTime(c.Query("to")); err != nil {
+		return f, fiber.NewError(fiber.StatusBadRequest, "to must be RFC3339 or unix milliseconds")
+	}
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = 1000
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return f, nil
+}
+
+func auditTime(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// GetAuditLogs daftar audit log terbaru dengan filter (admin/auditor)
+func GetAuditLogs(c *fiber.Ctx) error {
+	if _, err := requireAuditor(c); err != nil {
+		return err
+	}
+	filter, err := auditFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries, total, err := services.QueryAudit(filter)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"status":  200,
+		"message": "Audit log",
+		"data":    fiber.Map{"entries": entries, "total": total, "limit": filter.Limit, "offset": filter.Offset},
+	})
+}
+
+// ExportAuditLogs mengunduh seluruh entry yang cocok dengan filter sebagai
+// JSONL (default) atau CSV, urut seq
+func ExportAuditLogs(c *fiber.Ctx) error {
+	if _, err := requireAuditor(c); err != nil {
+		return err
+	}
+	filter, err := auditFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.Limit, filter.Offset = 0, 0
+
+	format := c.Query("format", "jsonl")
+	var buf bytes.Buffer
+	if err := services.ExportAudit(&buf, filter, format); err != nil {
+		if errors.Is(err, services.ErrAuditExportFormat) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	contentType := "application/x-ndjson"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment("audit-log-" + time.Now().UTC().Format("20060102-150405") + "." + format)
+	return c.Send(buf.Bytes())
+}
+
+// VerifyAuditLog menghitung ulang rantai hash audit log (admin/auditor)
+func VerifyAuditLog(c *fiber.Ctx) error {
+	if _, err := requireAuditor(c); err != nil {
+		return err
+	}
+	result, err := services.VerifyAuditLog()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	message := "Audit log chain is intact"
+	if !result.Valid {
+		message = "Audit log chain is broken"
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": message, "data": result})
+}
+
+// GetAuditHead head rantai audit log dan anchor terakhirnya di blockchain
+func GetAuditHead(c *fiber.Ctx) error {
+	if _, err := requireAuditor(c); err != nil {
+		return err
+	}
+	head, err := services.AuditLogHead()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	anchor, err := services.LastAuditAnchor()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Audit log head", "data": fiber.Map{"head": head, "anchor": anchor}})
+}
+
+// AnchorAuditLog mencatat head audit log ke blockchain sekarang (admin)
+func AnchorAuditLog(c *fiber.Ctx) error {
+	email, err := requireAuditor(c)
+	if err != nil {
+		return err
+	}
+	if services.GetUserRole(email) != services.RoleAdmin {
+		return fiber.NewError(fiber.StatusForbidden, "Admin role required")
+	}
+	anchor, err := services.AnchorAuditLog()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if anchor == nil {
+		return fiber.NewError(fiber.StatusNotFound, "Audit log is empty")
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Audit log head anchored", "data": anchor})
+}
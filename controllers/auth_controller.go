@@ -6,8 +6,8 @@ import (
 	"doc-tracker/storage/jwt"
 	"doc-tracker/storage/redis"
 	"doc-tracker/utils"
+	"errors"
 	"fmt"
-	"math/rand"
 	"os"
 	"time"
 
@@ -18,6 +18,21 @@ type LoginRequest struct {
 	Mnemonic string `json:"mnemonic"`
 }
 
+type ChallengeRequest struct {
+	Address string `json:"address"`
+}
+
+type SignatureLoginRequest struct {
+	Address      string `json:"address"`
+	PublicKey    string `json:"public_key"` // hex secp256k1 (BIP-44), uncompressed/compressed; P-256 hanya wallet lama
+	Nonce        string `json:"nonce"`
+	Signature    string `json:"signature"` // hex, DER atau r||s atas SHA-256(message)
+	Totp         string `json:"totp,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// Login Deprecated: menerima mnemonic lewat jaringan. Client sebaiknya memakai
+// /auth/challenge + /auth/verify-signature.
 func Login(c *fiber.Ctx) error {
 	var input LoginRequest
 	if err := c.BodyParser(&input); err != nil {
@@ -28,6 +43,7 @@ func Login(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
+	services.SetAuditActor(c, address)
 
 	return c.JSON(fiber.Map{
 		"address": address,
@@ -45,13 +61,14 @@ func SendOtp(c *fiber.Ctx) error {
 		fmt.Println("❌ Email kosong")
 		return fiber.NewError(fiber.StatusBadRequest, "Email is required")
 	}
+	services.SetAuditActor(c, req.Email)
 
-	otp := fmt.Sprintf("%06d", rand.Intn(1000000))
-	fmt.Printf("📨 Sending OTP %s to %s\n", otp, req.Email)
-
-	if err := redis.StoreOtpInMemoryOrRedis(req.Email, otp); err != nil {
-		fmt.Println("❌ Failed storing OTP:", err)
+	otp, err := services.IssueOtp(req.Email, c.IP())
+	if err != nil {
+		fmt.Println("❌ Failed issuing OTP:", err)
+		return otpError(err)
 	}
+	fmt.Printf("📨 Sending OTP to %s\n", req.Email)
 
 	// Kirim ke email (SMTP)
 	if err := utils.SendEmailOTP(req.Email, otp); err != nil {
@@ -69,27 +86,76 @@ func VerifyOtp(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
 	}
 
-	expectedOtp := redis.GetOtpFromMemoryOrRedis(req.Email)
-	if expectedOtp == "" || req.Otp != expectedOtp {
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid OTP")
+	if req.Email == "" || req.Otp == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Email and OTP are required")
 	}
+	services.SetAuditActor(c, req.Email)
 
-	// Hapus OTP setelah digunakan
-	redis.Client.Del(redis.Ctx, "otp:"+req.Email)
+	if err := services.VerifyOtp(req.Email, c.IP(), req.Otp); err != nil {
+		return otpError(err)
+	}
 	fmt.Println("✅ OTP verified successfully, removing from cache")
 
+	return completeLogin(c, req.Email, req.Totp, req.RecoveryCode, nil, "OTP verified successfully")
+}
+
+// completeLogin mengecek faktor kedua (TOTP) lalu menerbitkan JWT dan cookie
+func completeLogin(c *fiber.Ctx, email, totp, recoveryCode string, claims map[string]interface{}, message string) error {
+	if email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Account is not registered")
+	}
+	if claims == nil {
+		claims = map[string]interface{}{}
+	}
+
+	// Faktor kedua (TOTP) jika sudah aktif atau diwajibkan untuk role user
+	if services.IsTotpEnabled(email) {
+		if err := services.VerifySecondFactor(email, totp, recoveryCode); err != nil {
+			if errors.Is(err, services.ErrTotpLocked) {
+				return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+			}
+			if errors.Is(err, services.ErrTotpSecret) {
+				return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":        401,
+				"message":       err.Error(),
+				"totp_required": true,
+			})
+		}
+	} else if services.IsTotpRequired(email) {
+		// Token terbatas, hanya untuk menyelesaikan enrollment TOTP
+		claims["mfa_enroll"] = true
+	}
+
 	// Buat JWT token
-	token, expUnix, err := jwt.GenerateJWT(req.Email)
+	token, expUnix, err := jwt.GenerateJWTWithClaims(email, claims)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate token")
 	}
 	fmt.Println("✅ JWT token generated successfully")
 
+	setAuthCookie(c, token)
+	fmt.Printf("✅ Cookie set with token, expires at %d\n", expUnix)
+
+	return c.JSON(fiber.Map{
+		"status":  200,
+		"message": message,
+		"token":   token,
+		"email":   email,
+		"address": claims["address"],
+		"exp":     expUnix,
+		// true jika token hanya bisa dipakai untuk enrollment TOTP
+		"totp_enroll_required": claims["mfa_enroll"] == true,
+	})
+}
+
+// setAuthCookie menyimpan JWT di cookie authToken sesuai konfigurasi COOKIE_*
+func setAuthCookie(c *fiber.Ctx, token string) {
 	maxAge := 0
 	if v := os.Getenv("COOKIE_MAX_AGE"); v != "" {
 		fmt.Sscanf(v, "%d", &maxAge)
 	}
-	// Set cookie
 	c.Cookie(&fiber.Cookie{
 		Name:     "authToken",
 		Value:    token,
@@ -100,18 +166,23 @@ func VerifyOtp(c *fiber.Ctx) error {
 		SameSite: os.Getenv("COOKIE_SAMESITE"),    // ⬅️ WAJIB "None" agar bisa cross-domain
 		Domain:   os.Getenv("COOKIE_DOMAIN_NAME"), // ⬅️ optional tapi bisa bantu konsisten
 	})
-	fmt.Printf("✅ Cookie set with token, expires at %d\n", expUnix)
-	fmt.Printf("Cookie details: Name=%s, Value=%s, MaxAge=%d, Secure=%t, SameSite=%s, Domain=%s\n",
-		"authToken", token, maxAge, os.Getenv("COOKIE_SECURE") == "true", os.Getenv("COOKIE_SAMESITE"), os.Getenv("COOKIE_DOMAIN_NAME"))
-	fmt.Println("✅ OTP verified successfully, token set in cookie")
+}
 
-	return c.JSON(fiber.Map{
-		"status":  200,
-		"message": "OTP verified successfully",
-		"token":   token,
-		"email":   req.Email,
-		"exp":     expUnix,
-	})
+// otpError memetakan error OTP ke HTTP status
+func otpError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrOtpLocked),
+		errors.Is(err, services.ErrOtpResendLimit),
+		errors.Is(err, services.ErrOtpResendTooSoon):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, services.ErrOtpInvalid),
+		errors.Is(err, services.ErrOtpExpired):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrOtpSecret):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process OTP")
+	}
 }
 
 func GetQR(c *fiber.Ctx) error {
@@ -206,3 +277,51 @@ func AuthMe(c *fiber.Ctx) error {
 		"address": claims.Address,
 	})
 }
+
+// LoginChallenge menerbitkan nonce yang harus ditandatangani private key wallet
+func LoginChallenge(c *fiber.Ctx) error {
+	var req ChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	challenge, err := services.CreateLoginChallenge(req.Address, c.IP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWalletInput) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, services.ErrChallengeLimit) {
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create challenge")
+	}
+
+	return c.JSON(fiber.Map{"status": 200, "data": challenge})
+}
+
+// LoginWithSignature memverifikasi signature challenge dan menerbitkan JWT yang terikat ke address
+func LoginWithSignature(c *fiber.Ctx) error {
+	var req SignatureLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+	if req.Address == "" || req.PublicKey == "" || req.Nonce == "" || req.Signature == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "address, public_key, nonce and signature are required")
+	}
+	services.SetAuditActor(c, req.Address)
+
+	address, err := services.VerifyLoginSignature(req.Address, req.PublicKey, req.Nonce, req.Signature)
+	if err != nil {
+		fmt.Println("❌ Wallet signature login failed:", err)
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Hanya address wallet terdaftar (punya email) yang boleh login; keypair
+	// baru atau wallet lama tanpa email tidak mendapat token
+	email, ok := services.GetEmailByAddress(address)
+	if !ok || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Address is not registered")
+	}
+	services.SetAuditActor(c, email)
+	return completeLogin(c, email, req.Totp, req.RecoveryCode, map[string]interface{}{"address": address}, "Signature verified successfully")
+}
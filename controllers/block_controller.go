@@ -34,3 +34,14 @@ func ReceiveBlock(c *fiber.Ctx) error {
 func GetChain(c *fiber.Ctx) error {
 	return c.JSON(blockchain.GetAllBlocks())
 }
+
+// GET /api/blocks/headers?from=0&limit=100
+// Header plaintext bisa diverifikasi tanpa key node (light client / auditor)
+func GetBlockHeaders(c *fiber.Ctx) error {
+	from := c.QueryInt("from", 0)
+	limit := c.QueryInt("limit", 0)
+	if from < 0 || limit < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "from and limit must not be negative")
+	}
+	return c.JSON(blockchain.GetHeaders(from, limit))
+}
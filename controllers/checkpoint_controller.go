@@ -3,8 +3,8 @@ package controllers
 import (
 	"doc-tracker/models"
 	"doc-tracker/services"
+	"errors"
 	"log"
-	"os"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -25,39 +25,49 @@ func CompleteCheckpoint(c *fiber.Ctx) error {
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
-	if body.TrackerID == "" || body.Email == "" || body.Evidence == nil || *body.Evidence == "" {
+	uploads := body.EvidenceItems
+	if body.Evidence != nil && *body.Evidence != "" {
+		uploads = append([]models.EvidenceUpload{{Data: *body.Evidence}}, uploads...)
+	}
+	if body.TrackerID == "" || body.Email == "" || len(uploads) == 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "tracker_id, email, and base64 evidence are required"})
 	}
 
+	services.SetAuditResource(c, "tracker_id="+body.TrackerID+" checkpoint_email="+body.Email)
+
 	checkpointAddr := services.GetCheckpointAddressByEmail(body.TrackerID, body.Email)
 	if checkpointAddr == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "checkpoint address not found"})
 	}
 
-	var (
-		info  services.EvidenceInfo
-		err   error
-		useS3 = os.Getenv("S3_STORAGE") == "true"
-	)
+	items := make([]models.EvidenceItem, 0, len(uploads))
+	for _, u := range uploads {
+		info, err := services.SaveEvidenceBase64(body.TrackerID, checkpointAddr, &u.Data)
+		if errors.Is(err, services.ErrEvidenceTooLarge) {
+			return c.Status(413).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrEvidenceType) {
+			return c.Status(415).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 
-	if useS3 {
-		info, err = services.SaveEvidenceBase64ToS3(body.TrackerID, checkpointAddr, body.Evidence)
-	} else {
-		info, err = services.SaveEvidenceFileLocal(body.TrackerID, checkpointAddr, body.Evidence)
+		log.Printf("[Evidence] tracker=%s checkpoint=%s saved path=%s hash=%s", body.TrackerID, checkpointAddr, info.Path, info.Hash)
+		items = append(items, info.Item(u.Label, body.Email))
 	}
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	log.Printf("[Evidence] tracker=%s checkpoint=%s saved path=%s hash=%s", body.TrackerID, checkpointAddr, info.Path, info.Hash)
 
-	if err := services.UpdateCheckpointStatus(body.TrackerID, checkpointAddr, info.Hash, info.Path); err != nil {
+	if err := services.UpdateCheckpointStatus(body.TrackerID, checkpointAddr, items, true); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error update": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
 		"status":        "checkpoint complete",
-		"evidence_hash": info.Hash,
-		"evidence_path": info.Path,
+		"evidence_hash": items[0].Hash,
+		"evidence_path": items[0].Path,
+		"evidence_type": items[0].ContentType,
+		"evidence_size": items[0].Size,
+		"encrypted":     len(items[0].Keys) > 0,
+		"evidence":      items,
 	})
 }
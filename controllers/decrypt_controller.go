@@ -1,39 +1,63 @@
 package controllers
 
 import (
-	"crypto/rsa"
+	"doc-tracker/keystore"
+	"doc-tracker/services"
 	"doc-tracker/utils"
-	"encoding/hex"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type DecryptRequest struct {
-	Mnemonic string `json:"mnemonic"`
-	Cipher   string `json:"cipher"`
+	TrackerID string `json:"tracker_id"`
 }
 
+// DecryptNote membuka note checkpoint milik user login. Wallet non-custodial
+// mendapat ciphertext untuk didekripsi di client (lihat utils.NotePrefix).
 func DecryptNote(c *fiber.Ctx) error {
-	var payload struct {
-		Address string `json:"address"`
-		NoteHex string `json:"note"`
-		PEMKey  string `json:"pem_key"` // private key user
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 	}
 
-	if err := c.BodyParser(&payload); err != nil {
-		return c.Status(400).SendString("Invalid")
+	var req DecryptRequest
+	if err := c.BodyParser(&req); err != nil || req.TrackerID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "tracker_id is required")
 	}
+	services.SetAuditResource(c, "tracker_id="+req.TrackerID)
 
-	noteBytes, _ := hex.DecodeString(payload.NoteHex)
-	privKey := utils.PrivateKeyFromPEM(payload.PEMKey)
-	rsaPrivKey, ok := privKey.(*rsa.PrivateKey)
-	if !ok {
-		return c.Status(500).SendString("Invalid private key type")
+	note, err := services.DecryptCheckpointNote(email, req.TrackerID)
+	if errors.Is(err, keystore.ErrNonCustodial) {
+		cp, ferr := services.FindCheckpointNote(email, req.TrackerID)
+		if ferr != nil {
+			return noteError(ferr)
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":          fiber.StatusConflict,
+			"message":         "Wallet is non-custodial, decrypt the note on the client",
+			"encrypted_note":  cp.EncryptedNote,
+			"address":         cp.Address,
+			"associated_data": string(utils.NoteAssociatedData(req.TrackerID, cp.Address)),
+		})
 	}
-	decrypted, err := utils.DecryptMessage(rsaPrivKey, noteBytes)
 	if err != nil {
-		return c.Status(500).SendString("Decryption failed")
+		return noteError(err)
 	}
 
-	return c.JSON(fiber.Map{"message": string(decrypted)})
+	return c.JSON(fiber.Map{"status": 200, "message": "Note decrypted", "data": fiber.Map{"note": note}})
+}
+
+func noteError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrNoteNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrNotCheckpointOwner),
+		errors.Is(err, services.ErrNoteNotViewable):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, utils.ErrNoteDecrypt):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to decrypt note")
+	}
 }
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"doc-tracker/models"
+	"doc-tracker/services"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FingerprintDocument menghitung sidik jari dokumen (sha256, ukuran, tipe dan
+// hash per halaman) untuk didaftarkan di field document saat membuat tracker.
+// Body multipart berisi part file (dokumen) dan part page untuk tiap halaman
+// secara berurutan, atau body mentah berisi dokumen saja. File tidak disimpan.
+func FingerprintDocument(c *fiber.Ctx) error {
+	doc, err := readDocumentUpload(c, true)
+	if err != nil {
+		return documentError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Document fingerprint computed", "data": doc})
+}
+
+// VerifyDocument endpoint publik: cocokkan file (multipart part file atau body
+// mentah) dengan dokumen yang terdaftar di tracker dan kembalikan riwayat
+// checkpoint-nya. File hanya di-hash, tidak disimpan.
+func VerifyDocument(c *fiber.Ctx) error {
+	doc, err := readDocumentUpload(c, false)
+	if err != nil {
+		return documentError(err)
+	}
+	result, err := services.VerifyDocument(doc.Hash)
+	if err != nil {
+		return documentError(err)
+	}
+	result.Size = doc.Size
+	return documentVerificationResponse(c, result)
+}
+
+// VerifyDocumentHash endpoint publik seperti VerifyDocument untuk client yang
+// menghitung sha256 dokumen sendiri (query hash)
+func VerifyDocumentHash(c *fiber.Ctx) error {
+	if c.Query("hash") == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "hash is required")
+	}
+	result, err := services.VerifyDocument(c.Query("hash"))
+	if err != nil {
+		return documentError(err)
+	}
+	return documentVerificationResponse(c, result)
+}
+
+func documentVerificationResponse(c *fiber.Ctx, result services.DocumentVerification) error {
+	message := "Document is not registered in any tracker"
+	if result.Matched {
+		message = "Document matches a tracked document"
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": message, "data": result})
+}
+
+// readDocumentUpload membaca dokumen dari body multipart atau body mentah.
+// withPages: part page dihitung sebagai hash halaman, selain itu diabaikan.
+func readDocumentUpload(c *fiber.Ctx, withPages bool) (models.DocumentFingerprint, error) {
+	mediaType, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm {
+		return services.FingerprintDocument(requestBody(c))
+	}
+	if params["boundary"] == "" {
+		return models.DocumentFingerprint{}, fiber.NewError(fiber.StatusBadRequest, "Invalid multipart body")
+	}
+	mr := multipart.NewReader(requestBody(c), params["boundary"])
+
+	var doc *models.DocumentFingerprint
+	var pages []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return models.DocumentFingerprint{}, fiber.NewError(fiber.StatusBadRequest, "Invalid multipart body")
+		}
+
+		switch {
+		case part.FormName() == "file" && doc == nil:
+			fp, err := services.FingerprintDocument(part)
+			if err != nil {
+				return models.DocumentFingerprint{}, err
+			}
+			fp.Name = part.FileName()
+			doc = &fp
+		case part.FormName() == "page" && withPages:
+			fp, err := services.FingerprintDocument(part)
+			if err != nil {
+				return models.DocumentFingerprint{}, err
+			}
+			pages = append(pages, fp.Hash)
+		}
+	}
+	if doc == nil {
+		return models.DocumentFingerprint{}, fiber.NewError(fiber.StatusBadRequest, "File not found")
+	}
+	doc.PageHashes = pages
+	return *doc, nil
+}
+
+func documentError(err error) error {
+	var fe *fiber.Error
+	switch {
+	case errors.As(err, &fe):
+		return fe
+	case errors.Is(err, services.ErrDocumentTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, services.ErrDocumentInvalid):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read document: "+err.Error())
+	}
+}
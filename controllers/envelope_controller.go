@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"doc-tracker/keystore"
+	"doc-tracker/services"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GrantViewerRequest struct {
+	Email      string `json:"email"`
+	WrappedKey string `json:"wrapped_key,omitempty"` // wajib jika wallet creator non-custodial
+}
+
+// GetTrackerContent mendekripsi note dan daftar attachment tracker untuk viewer terdaftar
+func GetTrackerContent(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	content, err := services.DecryptTrackerContent(email, c.Params("id"))
+	if err != nil {
+		return envelopeError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Content decrypted", "data": content})
+}
+
+// DownloadAttachment mengirim attachment tracker yang sudah didekripsi
+func DownloadAttachment(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	meta, data, err := services.DecryptAttachment(email, c.Params("id"), c.Params("name"))
+	if err != nil {
+		return envelopeError(err)
+	}
+
+	contentType := meta.ContentType
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", meta.Name))
+	return c.Send(data)
+}
+
+// GrantViewer memberi akses konten tracker (belum di-mine) ke email lain
+func GrantViewer(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req GrantViewerRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "email is required")
+	}
+
+	address, err := services.GrantViewer(email, c.Params("id"), req.Email, req.WrappedKey)
+	if errors.Is(err, services.ErrWrappedKeyRequired) {
+		// Client membungkus content key sendiri lalu mengirim ulang dengan wrapped_key
+		viewer, _ := services.GetWalletPublic(req.Email)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":          fiber.StatusConflict,
+			"message":         err.Error(),
+			"address":         address,
+			"encryption_key":  hex.EncodeToString(utils.SerializePublicKey(viewer.EncryptionKey)),
+			"associated_data": string(utils.EnvelopeAssociatedData(c.Params("id"), address)),
+		})
+	}
+	if err != nil {
+		return envelopeError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Viewer granted", "data": fiber.Map{"email": req.Email, "address": address}})
+}
+
+// RevokeViewer mencabut akses konten tracker (belum di-mine) dari email
+func RevokeViewer(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	rotated, err := services.RevokeViewer(email, c.Params("id"), c.Params("email"))
+	if err != nil {
+		return envelopeError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Viewer revoked", "data": fiber.Map{"email": c.Params("email"), "key_rotated": rotated}})
+}
+
+func envelopeError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrNotFound),
+		errors.Is(err, services.ErrAttachmentNotFound),
+		errors.Is(err, keystore.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrNoEnvelope),
+		errors.Is(err, services.ErrNotTrackerCreator):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrTrackerMined),
+		errors.Is(err, services.ErrCannotRevokeCreator),
+		errors.Is(err, keystore.ErrNonCustodial):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, utils.ErrNoteDecrypt):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process tracker content")
+	}
+}
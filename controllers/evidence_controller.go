@@ -1,103 +1,575 @@
 package controllers
 
 import (
-	"crypto/sha256"
+	"bytes"
+	"doc-tracker/evidence"
+	"doc-tracker/keystore"
+	"doc-tracker/models"
 	"doc-tracker/services"
-	"doc-tracker/storage"
-	"encoding/hex"
-	"fmt"
+	"doc-tracker/utils"
+	"errors"
 	"io"
 	"mime"
-	"os"
-	"path/filepath"
+	"mime/multipart"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// UploadEvidence men-stream file multipart langsung ke evidence store tanpa
+// di-buffer. Field tracker_id (atau query dengan nama sama) harus dikirim
+// sebelum part file; checkpoint diambil dari user login, checkpoint_address
+// opsional dan harus milik user tersebut. Boleh berisi beberapa part file;
+// field label berlaku untuk part file berikutnya. Query complete=false
+// membiarkan checkpoint terbuka untuk evidence berikutnya.
 func UploadEvidence(c *fiber.Ctx) error {
-	trackerID := c.FormValue("tracker_id")
-	checkpointAddr := c.FormValue("checkpoint_address")
+	trackerID := c.Query("tracker_id")
+	requestedAddr := c.Query("checkpoint_address")
+	checkpointAddr := ""
+	complete := c.QueryBool("complete", true)
+	uploader, err := services.GetLoginEmail(c)
+	if err != nil || uploader == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
 
-	// Get file
-	file, err := c.FormFile("file")
-	if err != nil {
+	_, params, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || params["boundary"] == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "multipart/form-data body is required")
+	}
+	mr := multipart.NewReader(requestBody(c), params["boundary"])
+
+	var items []models.EvidenceItem
+	label := ""
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid multipart body")
+		}
+
+		switch part.FormName() {
+		case "tracker_id", "checkpoint_address", "label":
+			value, err := io.ReadAll(io.LimitReader(part, 256))
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid multipart body")
+			}
+			if checkpointAddr != "" && part.FormName() != "label" {
+				return fiber.NewError(fiber.StatusBadRequest, "tracker_id and checkpoint_address must be sent before the file")
+			}
+			switch part.FormName() {
+			case "tracker_id":
+				trackerID = string(value)
+			case "checkpoint_address":
+				requestedAddr = string(value)
+			default:
+				label = string(value)
+			}
+		case "file":
+			if trackerID == "" {
+				return fiber.NewError(fiber.StatusBadRequest, "tracker_id must be sent before the file")
+			}
+			if checkpointAddr == "" {
+				if checkpointAddr, err = uploaderCheckpoint(trackerID, uploader, requestedAddr); err != nil {
+					return saveEvidenceError(err)
+				}
+			}
+
+			// Hash dihitung sambil streaming, dienkripsi kecuali tracker public
+			info, err := services.SaveCheckpointEvidence(trackerID, checkpointAddr, part)
+			if err != nil {
+				return saveEvidenceError(err)
+			}
+			items = append(items, info.Item(label, uploader))
+			label = ""
+		}
+	}
+	if len(items) == 0 {
 		return fiber.NewError(fiber.StatusBadRequest, "File not found")
 	}
+	if c.Query("tracker_id") == "" {
+		services.SetAuditResource(c, "tracker_id="+trackerID+" checkpoint_address="+checkpointAddr)
+	}
 
-	// Open file stream
-	src, err := file.Open()
+	// Update checkpoint status
+	err = services.UpdateCheckpointStatus(trackerID, checkpointAddr, items, complete)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Can't open file")
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
-	defer src.Close()
 
-	// Hash the content
-	hasher := sha256.New()
-	io.Copy(hasher, src)
+	return c.JSON(fiber.Map{
+		"message":       "Evidence uploaded",
+		"evidence_hash": items[0].Hash,
+		"evidence_path": items[0].Path,
+		"evidence_type": items[0].ContentType,
+		"evidence_size": items[0].Size,
+		"encrypted":     len(items[0].Keys) > 0,
+		"evidence":      items,
+	})
+}
 
-	hash := hex.EncodeToString(hasher.Sum(nil))
+type CreateUploadRequest struct {
+	TrackerID string `json:"tracker_id"`
+	Size      int64  `json:"size"`
+	Label     string `json:"label"`
+	KeepOpen  bool   `json:"keep_open"` // jangan selesaikan checkpoint setelah upload
+}
 
-	// Reset src reader
-	src.Seek(0, io.SeekStart)
+// CreateEvidenceUpload memulai upload evidence bertahap (resumable) untuk
+// checkpoint milik user login
+func CreateEvidenceUpload(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
 
-	// Save file
-	dir := fmt.Sprintf("./storage/evidence/%s", trackerID)
-	os.MkdirAll(dir, 0755)
-	dstPath := filepath.Join(dir, checkpointAddr+filepath.Ext(file.Filename))
+	var req CreateUploadRequest
+	if err := c.BodyParser(&req); err != nil || req.TrackerID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "tracker_id and size are required")
+	}
 
-	dst, err := os.Create(dstPath)
+	session, err := services.CreateUploadSession(email, req.TrackerID, req.Size, req.Label, req.KeepOpen)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save file")
+		return saveEvidenceError(err)
 	}
-	defer dst.Close()
-	io.Copy(dst, src)
+	return c.JSON(fiber.Map{"status": 200, "message": "Upload created", "data": session})
+}
 
-	// Update checkpoint status
-	err = services.UpdateCheckpointStatus(trackerID, checkpointAddr, hash, dstPath)
+// GetEvidenceUpload mengembalikan offset upload untuk melanjutkan setelah terputus
+func GetEvidenceUpload(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	session, err := services.GetUploadSession(email, c.Params("id"))
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return saveEvidenceError(err)
 	}
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	return c.JSON(fiber.Map{"status": 200, "message": "Upload status", "data": session})
+}
 
-	return c.JSON(fiber.Map{
-		"message":       "Evidence uploaded",
-		"evidence_hash": hash,
-		"evidence_path": dstPath,
-	})
+// UploadEvidenceChunk menerima satu potongan body mentah mulai dari header
+// Upload-Offset (atau query offset)
+func UploadEvidenceChunk(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	offsetStr := c.Get("Upload-Offset", c.Query("offset"))
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil || offset < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Upload-Offset header is required")
+	}
+
+	session, err := services.AppendUploadChunk(email, c.Params("id"), offset, requestBody(c))
+	if errors.Is(err, services.ErrUploadOffset) {
+		c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": fiber.StatusConflict, "message": err.Error(), "data": session})
+	}
+	if err != nil {
+		return saveEvidenceError(err)
+	}
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	return c.JSON(fiber.Map{"status": 200, "message": "Chunk received", "data": session})
 }
 
+// CompleteEvidenceUpload memproses upload yang sudah lengkap dan menandai checkpoint selesai
+func CompleteEvidenceUpload(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	info, err := services.CompleteUpload(email, c.Params("id"))
+	if err != nil {
+		return saveEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence uploaded", "data": fiber.Map{
+		"evidence_hash": info.Hash,
+		"evidence_path": info.Path,
+		"evidence_type": info.ContentType,
+		"evidence_size": info.Size,
+		"encrypted":     info.Encrypted(),
+	}})
+}
+
+// AbortEvidenceUpload membatalkan upload bertahap
+func AbortEvidenceUpload(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	if err := services.AbortUpload(email, c.Params("id")); err != nil {
+		return saveEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Upload aborted"})
+}
+
+// GetEvidenceURL membuat URL download langsung (presigned S3 atau URL bertanda
+// tangan node) untuk evidence hash
+func GetEvidenceURL(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	hash := c.Query("hash")
+	if hash == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing evidence hash")
+	}
+
+	u, err := services.EvidenceDownloadURL(email, hash)
+	if err != nil {
+		return viewEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence url", "data": u})
+}
+
+// CreateEvidenceTicket membuat ticket upload langsung ke storage untuk
+// checkpoint milik user login
+func CreateEvidenceTicket(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req services.UploadTicketRequest
+	if err := c.BodyParser(&req); err != nil || req.TrackerID == "" || req.Hash == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "tracker_id, hash and size are required")
+	}
+
+	ticket, err := services.CreateUploadTicket(email, req)
+	if err != nil {
+		return saveEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Upload ticket created", "data": ticket})
+}
+
+// CompleteEvidenceTicket memverifikasi file yang diunggah lewat ticket dan
+// mencatatnya di checkpoint
+func CompleteEvidenceTicket(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	info, err := services.CompleteUploadTicket(email, c.Params("id"))
+	if err != nil {
+		return saveEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence uploaded", "data": fiber.Map{
+		"evidence_hash": info.Hash,
+		"evidence_path": info.Path,
+		"evidence_type": info.ContentType,
+		"evidence_size": info.Size,
+		"encrypted":     info.Encrypted(),
+	}})
+}
+
+// AbortEvidenceTicket membatalkan ticket upload
+func AbortEvidenceTicket(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	if err := services.AbortUploadTicket(email, c.Params("id")); err != nil {
+		return saveEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Upload ticket aborted"})
+}
+
+// ServeSignedEvidence melayani URL GET bertanda tangan untuk backend local/memory
+func ServeSignedEvidence(c *fiber.Ctx) error {
+	r, obj, err := evidence.OpenSigned(c.Context(), c.Params("hash"), c.Query("expires"), c.Query("sig"))
+	if err != nil {
+		return signedEvidenceError(err)
+	}
+	if obj.ContentType != "" {
+		c.Set(fiber.HeaderContentType, obj.ContentType)
+	}
+	return c.SendStream(r, int(obj.Size))
+}
+
+// PutSignedEvidence menerima upload dari URL PUT bertanda tangan untuk backend local/memory
+func PutSignedEvidence(c *fiber.Ctx) error {
+	err := evidence.WriteSigned(c.Context(), c.Params("id"), c.Query("expires"), c.Query("size"), c.Query("sig"), requestBody(c))
+	if err != nil {
+		return signedEvidenceError(err)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func signedEvidenceError(err error) error {
+	switch {
+	case errors.Is(err, evidence.ErrInvalidSignature):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, evidence.ErrNotFound), errors.Is(err, evidence.ErrInvalidHash):
+		return fiber.NewError(fiber.StatusNotFound, evidence.ErrNotFound.Error())
+	case errors.Is(err, evidence.ErrStagingSize):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, evidence.ErrDirectUnsupported):
+		return fiber.NewError(fiber.StatusNotImplemented, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to access evidence")
+	}
+}
+
+// requestBody mengembalikan body sebagai stream; body kecil sudah dibaca fasthttp.
+// Koneksi stream ditutup setelah response karena sisa body yang tidak dibaca
+// (mis. upload ditolak) tidak boleh dibaca sebagai request berikutnya.
+func requestBody(c *fiber.Ctx) io.Reader {
+	if r := c.Context().RequestBodyStream(); r != nil {
+		c.Context().SetConnectionClose()
+		return r
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// uploaderCheckpoint mencari checkpoint milik uploader di tracker. Address dari
+// client tidak dipercaya: hanya boleh address (baru atau lama) milik uploader.
+func uploaderCheckpoint(trackerID, uploader, requested string) (string, error) {
+	addr := services.GetCheckpointAddressByEmail(trackerID, uploader)
+	if addr == "" {
+		return "", services.ErrNotCheckpointOwner
+	}
+	if requested != "" && requested != addr {
+		if email, ok := services.GetEmailByAddress(requested); !ok || email != uploader {
+			return "", services.ErrNotCheckpointOwner
+		}
+	}
+	return addr, nil
+}
+
+func saveEvidenceError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrNotFound), errors.Is(err, services.ErrUploadNotFound), errors.Is(err, services.ErrTicketNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, evidence.ErrDirectUnsupported):
+		return fiber.NewError(fiber.StatusNotImplemented, err.Error())
+	case errors.Is(err, services.ErrUploadHash):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrNotCheckpointOwner):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrEvidenceTooLarge), errors.Is(err, services.ErrUploadChunkSize):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, services.ErrEvidenceType):
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, services.ErrUploadIncomplete):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrEvidenceEmpty), errors.Is(err, services.ErrUploadInvalid), errors.Is(err, evidence.ErrInvalidHash):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save evidence: "+err.Error())
+	}
+}
+
+// ViewEvidence mengirim evidence berdasarkan hash. Evidence terenkripsi hanya
+// didekripsi untuk pemegang key evidence; raw=1 mengirim ciphertext beserta
+// key terbungkus untuk didekripsi di client (wallet non-custodial).
 func ViewEvidence(c *fiber.Ctx) error {
 	hash := c.Query("hash")
 	if hash == "" {
 		return c.Status(400).SendString("Missing image hash")
 	}
 
-	track, err := services.GetTrackerByHash(hash)
-	if err != nil {
-		return c.Status(404).SendString("Tracker not found: " + err.Error())
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 	}
 
-	filePath := services.GetEvidencePath(track, hash)
-	fmt.Printf("Load File from path: %s\n", filePath)
+	// redirect=1: arahkan ke URL storage langsung jika backend mendukung, agar
+	// file besar tidak di-proxy node. Evidence terenkripsi tetap didekripsi node
+	// kecuali raw=1.
+	if c.QueryBool("redirect") {
+		u, err := services.EvidenceDownloadURL(email, hash)
+		if err == nil && (!u.Encrypted || c.QueryBool("raw")) {
+			if u.Key != nil {
+				c.Set("X-Evidence-Tracker", u.Key.TrackerID)
+				c.Set("X-Evidence-Checkpoint", u.Key.Checkpoint)
+				c.Set("X-Evidence-Address", u.Key.Address)
+				c.Set("X-Evidence-Key", u.Key.WrappedKey)
+			}
+			return c.Redirect(u.URL, fiber.StatusFound)
+		}
+		if err != nil && !errors.Is(err, evidence.ErrDirectUnsupported) {
+			return viewEvidenceError(err)
+		}
+	}
 
-	if os.Getenv("S3_STORAGE") == "true" {
-		key := filepath.Base(filePath)
-		content, err := storage.S3.DownloadS3File(key)
+	if c.QueryBool("raw") {
+		r, obj, enc, err := services.OpenEncryptedEvidence(email, hash)
 		if err != nil {
-			return c.Status(404).SendString("Evidence not found on S3: " + err.Error())
+			return viewEvidenceError(err)
 		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+		c.Set("X-Evidence-Tracker", enc.TrackerID)
+		c.Set("X-Evidence-Checkpoint", enc.Checkpoint)
+		c.Set("X-Evidence-Address", enc.Address)
+		c.Set("X-Evidence-Key", enc.WrappedKey)
+		return c.SendStream(r, int(obj.Size))
+	}
+
+	r, obj, err := services.OpenCheckpointEvidence(email, hash)
+	if err != nil {
+		return viewEvidenceError(err)
+	}
+	if obj.ContentType != "" {
+		c.Set("Content-Type", obj.ContentType)
+	}
+	return c.SendStream(r, int(obj.Size))
+}
+
+// ViewEvidenceThumbnail mengirim thumbnail (atau variant=preview) evidence
+// tanpa EXIF; PDF memakai render halaman pertama
+func ViewEvidenceThumbnail(c *fiber.Ctx) error {
+	hash := c.Query("hash")
+	if hash == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing evidence hash")
+	}
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	variant := c.Query("variant", services.VariantThumbnail)
+	if variant != services.VariantThumbnail && variant != services.VariantPreview {
+		return fiber.NewError(fiber.StatusBadRequest, "variant must be thumbnail or preview")
+	}
+	r, obj, err := services.OpenEvidenceDerivative(email, hash, variant)
+	if err != nil {
+		return viewEvidenceError(err)
+	}
+	c.Set(fiber.HeaderContentType, obj.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	return c.SendStream(r, int(obj.Size))
+}
+
+// GetEvidenceMetadata mengembalikan metadata EXIF/GPS yang dipisah dari foto evidence
+func GetEvidenceMetadata(c *fiber.Ctx) error {
+	hash := c.Query("hash")
+	if hash == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Missing evidence hash")
+	}
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	meta, err := services.EvidenceMetadata(email, hash)
+	if err != nil {
+		return viewEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence metadata", "data": meta})
+}
+
+// GetTrackerThumbnails daftar thumbnail evidence tracker untuk tampilan daftar
+func GetTrackerThumbnails(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	thumbs, err := services.TrackerThumbnails(email, c.Params("id"))
+	if errors.Is(err, utils.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Tracker not found")
+	}
+	if err != nil {
+		return viewEvidenceError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence thumbnails", "data": thumbs})
+}
+
+func viewEvidenceError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrEvidenceNotFound), errors.Is(err, evidence.ErrNotFound),
+		errors.Is(err, services.ErrDerivativeNotReady), errors.Is(err, evidence.ErrNoExif):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, evidence.ErrDirectUnsupported):
+		return fiber.NewError(fiber.StatusNotImplemented, err.Error())
+	case errors.Is(err, services.ErrNoEvidenceKey), errors.Is(err, services.ErrTrackerAccessDenied):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, keystore.ErrNonCustodial), errors.Is(err, evidence.ErrNotEncrypted):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, utils.ErrNoteDecrypt), errors.Is(err, evidence.ErrDecrypt):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to load evidence")
+	}
+}
 
-		ext := filepath.Ext(filePath)
-		mimeType := mime.TypeByExtension(ext)
-		if mimeType != "" {
-			c.Set("Content-Type", mimeType)
+// VerifyTrackerEvidence meng-hash ulang evidence tracker dan membandingkannya dengan hash on-chain
+func VerifyTrackerEvidence(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	checks, err := services.VerifyTrackerEvidenceByID(email, c.Params("id"))
+	if err != nil {
+		return evidenceAuditError(err)
+	}
+
+	valid := true
+	for _, check := range checks {
+		if check.Status != services.EvidenceOK {
+			valid = false
 		}
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence verified", "data": fiber.Map{
+		"tracker_id": c.Params("id"),
+		"valid":      valid,
+		"evidence":   checks,
+	}})
+}
 
-		return c.Send(content)
+// GetEvidenceAudit mengembalikan laporan audit evidence terakhir (admin/auditor)
+func GetEvidenceAudit(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if !services.IsAuditorOrAdmin(email) {
+		return fiber.NewError(fiber.StatusForbidden, "Admin or auditor role required")
 	}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return c.Status(404).SendString("File not found: " + filePath)
+	report, err := services.LastEvidenceAudit()
+	if err != nil {
+		return evidenceAuditError(err)
 	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Last evidence audit", "data": report})
+}
 
-	return c.SendFile(filePath)
+// RunEvidenceAudit menjalankan audit evidence sekarang (admin/auditor)
+func RunEvidenceAudit(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if !services.IsAuditorOrAdmin(email) {
+		return fiber.NewError(fiber.StatusForbidden, "Admin or auditor role required")
+	}
+
+	report, err := services.RunEvidenceAudit()
+	if err != nil {
+		return evidenceAuditError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Evidence audit finished", "data": report})
+}
+
+func evidenceAuditError(err error) error {
+	switch {
+	case errors.Is(err, utils.ErrNotFound), errors.Is(err, services.ErrAuditNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTrackerAccessDenied):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrAuditRunning):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
 }
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"doc-tracker/services"
+	"doc-tracker/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTrackerLabel label cetak (png/svg/pdf) berisi QR ke URL verifikasi
+// bertanda tangan node, untuk ditempel di dokumen fisik
+func GetTrackerLabel(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	data, contentType, err := services.TrackerLabel(email, c.Params("id"), c.Query("format"), c.QueryInt("size"))
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Tracker not found")
+	case errors.Is(err, services.ErrTrackerAccessDenied):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrLabelFormat):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to render label: "+err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	if c.QueryBool("download") {
+		c.Attachment("tracker-" + c.Params("id") + "-label" + labelExtension(contentType))
+	}
+	return c.Send(data)
+}
+
+func labelExtension(contentType string) string {
+	switch contentType {
+	case "image/svg+xml":
+		return ".svg"
+	case "application/pdf":
+		return ".pdf"
+	}
+	return ".png"
+}
+
+// VerifyTracker endpoint publik tujuan QR label: status tracker yang sudah
+// disamarkan, timeline checkpoint, posisi di block dan validitas tanda tangan
+func VerifyTracker(c *fiber.Ctx) error {
+	result, err := services.VerifyTrackerLabel(c.Params("id"), c.Query("sig"), c.Query("pk"))
+	if errors.Is(err, utils.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Tracker not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	message := "Tracker found"
+	switch {
+	case result.Signature.Present && !result.Signature.Valid:
+		message = "Label signature is invalid"
+	case result.Signature.Valid && !result.Signature.Trusted:
+		message = "Label is signed by an untrusted node"
+	case result.Signature.Trusted:
+		message = "Label verified"
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": message, "data": result})
+}
+
+// GetTrackerCertificate sertifikat PDF chain of custody untuk auditor. Signature
+// node atas certificate.json (tersemat di PDF) juga dikirim lewat header.
+func GetTrackerCertificate(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	cert, err := services.TrackerCertificate(email, c.Params("id"))
+	switch {
+	case errors.Is(err, utils.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Tracker not found")
+	case errors.Is(err, services.ErrTrackerAccessDenied):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to render certificate: "+err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set("X-Certificate-Digest", cert.Digest)
+	c.Set("X-Certificate-Signature", cert.Signature)
+	c.Set("X-Certificate-Signer", cert.PublicKey)
+	filename := "certificate-" + cert.Content.TrackerID + ".pdf"
+	if c.QueryBool("download") {
+		c.Attachment(filename)
+	} else {
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+filename+`"`)
+	}
+	return c.Send(cert.PDF)
+}
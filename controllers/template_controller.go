@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"doc-tracker/services"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ManagerUpdateRequest mapping email -> email manager; manager kosong menghapus
+type ManagerUpdateRequest struct {
+	Managers map[string]string `json:"managers"`
+	Replace  bool              `json:"replace,omitempty"`
+}
+
+// GetTemplates daftar template tracker
+func GetTemplates(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": 200, "message": "Tracker templates", "data": services.ListTemplates()})
+}
+
+// GetTemplate detail satu template
+func GetTemplate(c *fiber.Ctx) error {
+	tpl, err := services.GetTemplate(c.Params("id"))
+	if err != nil {
+		return templateError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Tracker template", "data": tpl})
+}
+
+// CreateTemplate menyimpan template baru milik user login
+func CreateTemplate(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	var input services.TrackerTemplate
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	tpl, err := services.CreateTemplate(email, input)
+	if err != nil {
+		return templateError(err)
+	}
+	services.SetAuditResource(c, "template="+tpl.ID)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": 201, "message": "Template created", "data": tpl})
+}
+
+// UpdateTemplate mengganti isi template (pemilik atau admin)
+func UpdateTemplate(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	var input services.TrackerTemplate
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	tpl, err := services.UpdateTemplate(email, c.Params("id"), input)
+	if err != nil {
+		return templateError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Template updated", "data": tpl})
+}
+
+// DeleteTemplate menghapus template (pemilik atau admin)
+func DeleteTemplate(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if err := services.DeleteTemplate(email, c.Params("id")); err != nil {
+		return templateError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Template deleted"})
+}
+
+// PreviewTemplateTracker menampilkan checkpoint hasil template, variabel dan
+// override tanpa membuat tracker
+func PreviewTemplateTracker(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	var input services.TemplateInstance
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	tracker, err := services.BuildTrackerFromTemplate(email, c.Params("id"), input)
+	if err != nil {
+		return templateError(err)
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Tracker preview", "data": tracker})
+}
+
+// CreateTrackerFromTemplate membuat tracker dari template dengan user login
+// sebagai creator
+func CreateTrackerFromTemplate(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	var input services.TemplateInstance
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	data, err := services.CreateTrackerFromTemplate(email, c.Params("id"), input)
+	if errors.Is(err, services.ErrAttachmentInvalid) || errors.Is(err, services.ErrAttachmentTooLarge) || errors.Is(err, services.ErrDocumentInvalid) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return templateError(err)
+	}
+
+	services.SetAuditResource(c, "tracker_id="+data.ID)
+	return c.JSON(fiber.Map{"status": 201, "message": "Tracker created successfully", "data": data})
+}
+
+// GetManagers direktori atasan untuk variabel {{...manager}} (admin/auditor)
+func GetManagers(c *fiber.Ctx) error {
+	if _, err := requireAuditor(c); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Manager directory", "data": services.ListManagers()})
+}
+
+// UpdateManagers mengubah direktori atasan (admin)
+func UpdateManagers(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	if services.GetUserRole(email) != services.RoleAdmin {
+		return fiber.NewError(fiber.StatusForbidden, "Admin role required")
+	}
+	var req ManagerUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	managers, err := services.UpdateManagers(req.Managers, req.Replace)
+	if errors.Is(err, services.ErrManagerInvalid) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"status": 200, "message": "Manager directory updated", "data": managers})
+}
+
+func templateError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTemplateForbidden):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrTemplateInvalid), errors.Is(err, services.ErrTemplateVariable):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"doc-tracker/models"
+	"doc-tracker/services"
+	"doc-tracker/storage/jwt"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TotpStatus mengembalikan status enrollment TOTP user yang login
+func TotpStatus(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	return c.JSON(fiber.Map{
+		"email":               email,
+		"role":                services.GetUserRole(email),
+		"enabled":             services.IsTotpEnabled(email),
+		"required":            services.IsTotpRequired(email),
+		"recovery_codes_left": services.RecoveryCodesLeft(email),
+	})
+}
+
+// TotpEnroll membuat secret baru dan QR untuk authenticator app
+func TotpEnroll(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	enrollment, err := services.EnrollTotp(email)
+	if err != nil {
+		return totpError(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  200,
+		"message": "Scan the QR code, then activate with the first code",
+		"data":    enrollment,
+	})
+}
+
+// TotpActivate memverifikasi kode pertama, mengaktifkan TOTP dan
+// menerbitkan token penuh (menggantikan token enrollment)
+func TotpActivate(c *fiber.Ctx) error {
+	login, err := services.GetLoginClaims(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	email := login.Email
+
+	var req models.TotpCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Code is required")
+	}
+
+	codes, err := services.ActivateTotp(email, req.Code)
+	if err != nil {
+		return totpError(err)
+	}
+
+	// Claim token lama (mis. address dari login signature) dipertahankan, tanpa mfa_enroll
+	claims := map[string]interface{}{}
+	if login.Address != "" {
+		claims["address"] = login.Address
+	}
+	token, expUnix, err := jwt.GenerateJWTWithClaims(email, claims)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to generate token")
+	}
+	setAuthCookie(c, token)
+	fmt.Printf("✅ TOTP activated for %s\n", email)
+
+	return c.JSON(fiber.Map{
+		"status":         200,
+		"message":        "TOTP activated. Store the recovery codes safely, they are shown only once",
+		"recovery_codes": codes,
+		"token":          token,
+		"exp":            expUnix,
+	})
+}
+
+// TotpRegenerateRecovery mengganti seluruh recovery code
+func TotpRegenerateRecovery(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req models.TotpCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Code is required")
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(email, req.Code)
+	if err != nil {
+		return totpError(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":         200,
+		"recovery_codes": codes,
+	})
+}
+
+// TotpDisable menonaktifkan TOTP (ditolak untuk role yang wajib TOTP)
+func TotpDisable(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req models.TotpCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Code is required")
+	}
+
+	if err := services.DisableTotp(email, req.Code); err != nil {
+		return totpError(err)
+	}
+
+	return c.JSON(fiber.Map{"status": 200, "message": "TOTP disabled"})
+}
+
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTotpInvalid), errors.Is(err, services.ErrTotpRequired):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrTotpSecret):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, services.ErrTotpLocked):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, services.ErrTotpNotEnrolled):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTotpAlreadyOn), errors.Is(err, services.ErrTotpCannotRemove):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to process TOTP")
+	}
+}
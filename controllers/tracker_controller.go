@@ -3,6 +3,7 @@ package controllers
 import (
 	"doc-tracker/models"
 	"doc-tracker/services"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -34,10 +35,14 @@ func CreateTracker(c *fiber.Ctx) error {
 	}
 
 	data, err := services.CreateTracker(input)
+	if errors.Is(err, services.ErrAttachmentInvalid) || errors.Is(err, services.ErrAttachmentTooLarge) || errors.Is(err, services.ErrDocumentInvalid) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create tracker"})
 	}
 
+	services.SetAuditResource(c, "tracker_id="+data.ID)
 	response := fiber.Map{"status": 201, "message": "Tracker created successfully", "data": data}
 	return c.JSON(response)
 }
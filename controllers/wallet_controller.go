@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"doc-tracker/keystore"
+	"doc-tracker/services"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WalletExportRequest struct {
+	Password string `json:"password"`
+}
+
+type WalletImportRequest struct {
+	Mnemonic string          `json:"mnemonic,omitempty"`
+	Keystore json.RawMessage `json:"keystore,omitempty"` // hasil /wallet/export
+	Password string          `json:"password,omitempty"` // password untuk keystore
+}
+
+type WalletRegisterRequest struct {
+	PublicKey string `json:"public_key"`
+	Nonce     string `json:"nonce"`     // dari /auth/challenge untuk address public key ini
+	Signature string `json:"signature"` // signature atas message challenge
+}
+
+// GetMyWallet mengembalikan info publik wallet user yang login
+func GetMyWallet(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	wallet, err := services.GetWalletPublic(email)
+	if err != nil {
+		return walletError(err)
+	}
+	return c.JSON(walletResponse(email, wallet))
+}
+
+// ExportWallet mengunduh keystore JSON terenkripsi password (hanya wallet custodial)
+func ExportWallet(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req WalletExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	data, err := services.ExportWallet(email, req.Password)
+	if err != nil {
+		return walletError(err)
+	}
+	fmt.Printf("🔑 Wallet exported for %s\n", email)
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.keystore.json\"", email))
+	c.Type("json")
+	return c.Send(data)
+}
+
+// ImportWallet mengimpor mnemonic atau keystore export milik user (wallet custodial)
+func ImportWallet(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req WalletImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	var wallet services.WalletInfo
+	switch {
+	case req.Mnemonic != "":
+		wallet, err = services.ImportWalletMnemonic(email, req.Mnemonic)
+	case len(req.Keystore) > 0:
+		wallet, err = services.ImportWalletExport(email, req.Keystore, req.Password)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "mnemonic or keystore is required")
+	}
+	if err != nil {
+		return walletError(err)
+	}
+
+	return c.JSON(fiber.Map{"status": 200, "message": "Wallet imported", "data": walletResponse(email, wallet)})
+}
+
+// RegisterWallet mendaftarkan wallet non-custodial (public key saja)
+func RegisterWallet(c *fiber.Ctx) error {
+	email, err := services.GetLoginEmail(c)
+	if err != nil || email == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	var req WalletRegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+	if req.PublicKey == "" || req.Nonce == "" || req.Signature == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "public_key, nonce and signature are required")
+	}
+
+	wallet, err := services.RegisterPublicWallet(email, req.PublicKey, req.Nonce, req.Signature)
+	if err != nil {
+		return walletError(err)
+	}
+
+	return c.JSON(fiber.Map{"status": 200, "message": "Public key registered", "data": walletResponse(email, wallet)})
+}
+
+func walletResponse(email string, wallet services.WalletInfo) fiber.Map {
+	resp := fiber.Map{
+		"email":           email,
+		"address":         wallet.Address,
+		"public_key":      hex.EncodeToString(utils.SerializePublicKey(wallet.PublicKey)),
+		"encryption_key":  hex.EncodeToString(utils.SerializePublicKey(wallet.EncryptionKey)),
+		"custodial":       wallet.Custodial,
+		"derivation_path": utils.DerivationPath(utils.KeyPurposeSigning, 0),
+	}
+	if !utils.IsSecp256k1(wallet.PublicKey) {
+		resp["derivation_path"] = "legacy"
+	}
+	if wallet.LegacyAddress != "" {
+		resp["legacy_address"] = wallet.LegacyAddress
+	}
+	return resp
+}
+
+func walletError(err error) error {
+	switch {
+	case errors.Is(err, keystore.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Wallet not found")
+	case errors.Is(err, keystore.ErrAlreadyExist):
+		return fiber.NewError(fiber.StatusConflict, "A wallet is already registered for this account")
+	case errors.Is(err, keystore.ErrNonCustodial):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, keystore.ErrWeakPassword):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrChallengeNotFound),
+		errors.Is(err, services.ErrChallengeMismatch),
+		errors.Is(err, services.ErrAddressMismatch),
+		errors.Is(err, services.ErrInvalidSignature):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,183 @@
+package evidence
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Evidence terenkripsi memakai AES-256-GCM per segmen agar file besar bisa
+// di-stream tanpa dimuat ke memori.
+//
+// Format: "DTE1" | noncePrefix (7 byte) | segmen...
+//   - segmen  : ciphertext+tag dari maksimal 64 KiB plaintext
+//   - nonce   : noncePrefix || counter (uint32 big-endian) || flag segmen terakhir (1 byte)
+//
+// Flag segmen terakhir mencegah file dipotong di batas segmen tanpa ketahuan.
+const (
+	cryptMagic     = "DTE1"
+	noncePrefixLen = 7
+	segmentSize    = 64 << 10
+	tagSize        = 16 // overhead GCM per segmen
+	KeySize        = 32
+)
+
+var (
+	ErrDecrypt       = errors.New("evidence decryption failed")
+	ErrNotEncrypted  = errors.New("evidence is not encrypted")
+	ErrInvalidKey    = errors.New("evidence key must be 32 bytes")
+	errTooManyChunks = errors.New("evidence is too large to encrypt")
+)
+
+// NewKey membuat key AES-256 acak untuk satu evidence
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// AssociatedData mengikat ciphertext evidence ke tracker dan address checkpoint
+func AssociatedData(trackerID, checkpointAddr string) []byte {
+	return []byte("doctracker-evidence:" + trackerID + ":" + checkpointAddr)
+}
+
+// DerivativeAssociatedData associated data turunan evidence (thumbnail, preview,
+// metadata) agar ciphertext turunan tidak bisa ditukar dengan evidence aslinya
+func DerivativeAssociatedData(trackerID, checkpointAddr, variant string) []byte {
+	return append(AssociatedData(trackerID, checkpointAddr), ":"+variant...)
+}
+
+// EncryptedSize ukuran ciphertext EncryptReader untuk plaintext n byte
+func EncryptedSize(n int64) int64 {
+	segments := (n + segmentSize - 1) / segmentSize
+	if segments == 0 {
+		segments = 1
+	}
+	return int64(len(cryptMagic)+noncePrefixLen) + n + segments*tagSize
+}
+
+// EncryptReader mengembalikan reader yang menghasilkan ciphertext dari r
+func EncryptReader(r io.Reader, key, ad []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, noncePrefixLen)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	return &segmentReader{
+		src:    bufio.NewReaderSize(r, segmentSize),
+		aead:   aead,
+		prefix: prefix,
+		ad:     ad,
+		seal:   true,
+		in:     make([]byte, segmentSize),
+		out:    append([]byte(cryptMagic), prefix...),
+	}, nil
+}
+
+// DecryptReader mengembalikan reader plaintext dari ciphertext EncryptReader.
+// Segmen yang rusak atau stream yang terpotong menghasilkan ErrDecrypt.
+func DecryptReader(r io.Reader, key, ad []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(cryptMagic)+noncePrefixLen)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(cryptMagic)]) != cryptMagic {
+		return nil, ErrNotEncrypted
+	}
+	return &segmentReader{
+		src:    bufio.NewReaderSize(r, segmentSize+aead.Overhead()),
+		aead:   aead,
+		prefix: header[len(cryptMagic):],
+		ad:     ad,
+		in:     make([]byte, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+type segmentReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	ad      []byte
+	seal    bool
+	counter uint32
+	done    bool
+	in      []byte
+	out     []byte // hasil segmen yang belum dibaca
+	err     error
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.err = s.next()
+	}
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+	return n, nil
+}
+
+func (s *segmentReader) next() error {
+	n, err := io.ReadFull(s.src, s.in)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	last := n < len(s.in)
+	if !last {
+		if _, err := s.src.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if s.counter == ^uint32(0) && !last {
+		return errTooManyChunks
+	}
+
+	nonce := make([]byte, 0, noncePrefixLen+5)
+	nonce = append(nonce, s.prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, s.counter)
+	if last {
+		nonce = append(nonce, 1)
+	} else {
+		nonce = append(nonce, 0)
+	}
+
+	if s.seal {
+		s.out = s.aead.Seal(nil, nonce, s.in[:n], s.ad)
+	} else {
+		pt, err := s.aead.Open(nil, nonce, s.in[:n], s.ad)
+		if err != nil {
+			return ErrDecrypt
+		}
+		s.out = pt
+	}
+	s.counter++
+	s.done = last
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
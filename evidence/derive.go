@@ -0,0 +1,178 @@
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedPreview = errors.New("evidence type has no preview")
+	ErrImageTooLarge      = errors.New("image dimensions exceed the processing limit")
+)
+
+// DecodeImage men-decode foto evidence; gambar di atas maxPixels ditolak
+// sebelum di-decode
+func DecodeImage(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w (%dx%d)", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Resize mengecilkan img agar sisi terpanjang maksimal side piksel (tidak pernah memperbesar)
+func Resize(img image.Image, side int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= side && h <= side {
+		return img
+	}
+	if w >= h {
+		h, w = max(1, h*side/w), side
+	} else {
+		w, h = max(1, w*side/h), side
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG meng-encode ulang gambar; hasilnya tidak membawa metadata EXIF
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PDFPreview merender halaman pertama PDF. pdftoppm (poppler, bisa diganti
+// dengan PDF_PREVIEW_CMD) dipakai jika tersedia; jika tidak, gambar JPEG
+// pertama di PDF (umumnya halaman hasil scan) dipakai sebagai preview.
+func PDFPreview(data []byte, size, maxPixels int) (image.Image, error) {
+	if img, err := renderPDFPage(data, size); err == nil {
+		return img, nil
+	}
+	jpg := embeddedJPEG(data)
+	if jpg == nil {
+		return nil, ErrUnsupportedPreview
+	}
+	return DecodeImage(jpg, maxPixels)
+}
+
+func renderPDFPage(data []byte, size int) (image.Image, error) {
+	bin, err := exec.LookPath(envOr("PDF_PREVIEW_CMD", "pdftoppm"))
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "pdf-preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	if err := os.WriteFile(in, data, 0600); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	out := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, bin, "-f", "1", "-l", "1", "-singlefile", "-png", "-scale-to", fmt.Sprint(size), in, out)
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(out + ".png")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// embeddedJPEG mengambil stream DCTDecode pertama di PDF
+func embeddedJPEG(data []byte) []byte {
+	for off := 0; ; {
+		i := bytes.Index(data[off:], []byte("/DCTDecode"))
+		if i < 0 {
+			return nil
+		}
+		off += i
+		s := bytes.Index(data[off:], []byte("stream"))
+		if s < 0 {
+			return nil
+		}
+		start := off + s + len("stream")
+		for start < len(data) && (data[start] == '\r' || data[start] == '\n') {
+			start++
+		}
+		if bytes.HasPrefix(data[start:], []byte{0xFF, 0xD8}) {
+			end := bytes.Index(data[start:], []byte("endstream"))
+			if end < 0 {
+				end = len(data) - start
+			}
+			return data[start : start+end]
+		}
+		off = start
+	}
+}
+
+// Orient memutar/mencerminkan gambar sesuai tag EXIF Orientation (1-8).
+// Per piksel, jadi sebaiknya dipanggil setelah Resize.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
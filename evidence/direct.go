@@ -0,0 +1,168 @@
+package evidence
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignedPath prefix URL bertanda tangan yang dilayani node untuk backend
+// local dan memory (padanan presigned URL S3)
+const SignedPath = "/api/evidence/files/"
+
+var (
+	ErrDirectUnsupported = errors.New("evidence store does not support direct urls")
+	ErrInvalidSignature  = errors.New("invalid or expired evidence url")
+	ErrStagingSize       = errors.New("uploaded body does not match the signed size")
+
+	// ErrURLSecret URL bertanda tangan node dimatikan (fail closed) selama
+	// EVIDENCE_URL_SECRET kosong; dibungkus ErrDirectUnsupported agar download
+	// kembali di-proxy node
+	ErrURLSecret = fmt.Errorf("%w: EVIDENCE_URL_SECRET is not configured", ErrDirectUnsupported)
+)
+
+// DirectStore backend yang bisa diakses client langsung lewat URL sementara,
+// sehingga file besar tidak melewati proses node. Upload masuk ke object
+// staging dulu dan baru dipindah ke key content-addressed setelah diverifikasi.
+type DirectStore interface {
+	Store
+	// GetURL URL GET sementara untuk object hash
+	GetURL(ctx context.Context, hash, contentType string, ttl time.Duration) (string, error)
+	// StagingURL URL PUT sementara untuk object staging id berukuran size byte
+	StagingURL(ctx context.Context, id string, size int64, ttl time.Duration) (string, error)
+	OpenStaging(ctx context.Context, id string) (io.ReadCloser, error)
+	// CommitStaging memindahkan object staging ke object content-addressed hash
+	CommitStaging(ctx context.Context, id, hash, contentType string) error
+	DeleteStaging(ctx context.Context, id string) error
+}
+
+// stagingWriter backend yang menerima upload staging lewat URL bertanda tangan node
+type stagingWriter interface {
+	writeStaging(ctx context.Context, id string, r io.Reader) error
+}
+
+// signedURL membuat URL node bertanda tangan HMAC yang berlaku selama ttl.
+// EVIDENCE_URL_SECRET wajib diisi; tanpa secret tidak ada URL yang diterbitkan.
+func signedURL(method, path string, size int64, ttl time.Duration) (string, error) {
+	expires := time.Now().Add(ttl).Unix()
+	sig, err := signature(method, path, expires, size)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	if size > 0 {
+		q.Set("size", strconv.FormatInt(size, 10))
+	}
+	q.Set("sig", sig)
+	return os.Getenv("EVIDENCE_PUBLIC_URL") + path + "?" + q.Encode(), nil
+}
+
+func signature(method, path string, expires, size int64) (string, error) {
+	secret := os.Getenv("EVIDENCE_URL_SECRET")
+	if secret == "" {
+		return "", ErrURLSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + strconv.FormatInt(expires, 10) + "\n" + strconv.FormatInt(size, 10)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifySignature memeriksa tanda tangan dan masa berlaku URL; mengembalikan size
+func verifySignature(method, path, expires, size, sig string) (int64, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return 0, ErrInvalidSignature
+	}
+	var n int64
+	if size != "" {
+		if n, err = strconv.ParseInt(size, 10, 64); err != nil || n <= 0 {
+			return 0, ErrInvalidSignature
+		}
+	}
+	want, err := signature(method, path, exp, n)
+	if err != nil {
+		return 0, err
+	}
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return 0, ErrInvalidSignature
+	}
+	return n, nil
+}
+
+// OpenSigned membuka object untuk URL GET bertanda tangan dari GetURL
+func OpenSigned(ctx context.Context, hash, expires, sig string) (io.ReadCloser, Object, error) {
+	if _, err := verifySignature("GET", SignedPath+hash, expires, "", sig); err != nil {
+		return nil, Object{}, err
+	}
+	s, err := Default()
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return s.Get(ctx, hash)
+}
+
+// WriteSigned menyimpan body PUT bertanda tangan dari StagingURL ke object
+// staging; body harus tepat sebesar size yang ditandatangani
+func WriteSigned(ctx context.Context, id, expires, size, sig string, r io.Reader) error {
+	n, err := verifySignature("PUT", SignedPath+"staging/"+id, expires, size, sig)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvalidSignature
+	}
+	s, err := Default()
+	if err != nil {
+		return err
+	}
+	w, ok := s.(stagingWriter)
+	if !ok {
+		return ErrDirectUnsupported
+	}
+
+	counted := &exactReader{r: r, want: n}
+	err = w.writeStaging(ctx, id, counted)
+	if err == nil && counted.read != n {
+		err = ErrStagingSize
+	}
+	if err != nil {
+		// Upload tidak lengkap dibuang; client mengulang dengan URL yang sama
+		s.(DirectStore).DeleteStaging(ctx, id)
+	}
+	return err
+}
+
+// exactReader gagal dengan ErrStagingSize jika body melebihi want byte
+type exactReader struct {
+	r    io.Reader
+	want int64
+	read int64
+}
+
+func (e *exactReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	e.read += int64(n)
+	if e.read > e.want {
+		return n, ErrStagingSize
+	}
+	return n, err
+}
+
+// validStagingID id staging selalu UUID agar tidak bisa keluar dari direktori staging
+func validStagingID(id string) error {
+	if u, err := uuid.Parse(id); err != nil || u.String() != id {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedURLRequiresSecret(t *testing.T) {
+	t.Setenv("EVIDENCE_URL_SECRET", "")
+	t.Setenv("JWT_SECRET", "jwt-secret-must-not-be-used")
+
+	if _, err := signedURL("GET", SignedPath+sampleHash(), 0, time.Minute); !errors.Is(err, ErrURLSecret) {
+		t.Fatalf("signedURL err = %v, want ErrURLSecret", err)
+	}
+	if !errors.Is(ErrURLSecret, ErrDirectUnsupported) {
+		t.Fatalf("ErrURLSecret must wrap ErrDirectUnsupported so downloads fall back to the node")
+	}
+	// Tanda tangan dengan key kosong tidak boleh diterima
+	exp := time.Now().Add(time.Minute).Unix()
+	forged := strings.Repeat("0", 64)
+	if _, err := verifySignature("GET", SignedPath+sampleHash(), strconv.FormatInt(exp, 10), "", forged); err == nil {
+		t.Fatalf("verifySignature accepted a url without a configured secret")
+	}
+	if err := WriteSigned(context.Background(), "x", strconv.FormatInt(exp, 10), "1", forged, strings.NewReader("a")); err == nil {
+		t.Fatalf("WriteSigned accepted a url without a configured secret")
+	}
+}
+
+func TestSignedURLRoundTrip(t *testing.T) {
+	t.Setenv("EVIDENCE_URL_SECRET", "test-secret")
+
+	raw, err := signedURL("GET", SignedPath+sampleHash(), 0, time.Minute)
+	if err != nil {
+		t.Fatalf("signedURL: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	q := u.Query()
+	if _, err := verifySignature("GET", u.Path, q.Get("expires"), "", q.Get("sig")); err != nil {
+		t.Fatalf("verifySignature: %v", err)
+	}
+	if _, err := verifySignature("PUT", u.Path, q.Get("expires"), "", q.Get("sig")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("verifySignature with other method err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,244 @@
+package evidence
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNoExif = errors.New("image has no exif metadata")
+
+// ExifData metadata kamera dan lokasi dari EXIF foto. Disimpan terpisah dari
+// turunan evidence (thumbnail/preview) yang selalu bebas EXIF.
+type ExifData struct {
+	Make             string   `json:"make,omitempty"`
+	Model            string   `json:"model,omitempty"`
+	Software         string   `json:"software,omitempty"`
+	DateTime         string   `json:"date_time,omitempty"`
+	DateTimeOriginal string   `json:"date_time_original,omitempty"`
+	Orientation      int      `json:"orientation,omitempty"`
+	GPS              *GPSInfo `json:"gps,omitempty"`
+}
+
+// GPSInfo lokasi pengambilan foto dalam derajat desimal
+type GPSInfo struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+	DateStamp string   `json:"date_stamp,omitempty"`
+}
+
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+	tagGPSDateStamp    = 0x001D
+)
+
+// ReadExif membaca segmen EXIF (APP1) dari JPEG
+func ReadExif(data []byte) (ExifData, error) {
+	tiff, err := jpegExifSegment(data)
+	if err != nil {
+		return ExifData{}, err
+	}
+	return parseTIFF(tiff)
+}
+
+func jpegExifSegment(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrNoExif
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, ErrNoExif
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // padding
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, ErrNoExif
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		i += 2 + length
+	}
+	return nil, ErrNoExif
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // isi mentah entry (inline atau dari offset)
+}
+
+func parseTIFF(data []byte) (ExifData, error) {
+	if len(data) < 8 {
+		return ExifData{}, ErrNoExif
+	}
+	t := tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return ExifData{}, ErrNoExif
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return ExifData{}, ErrNoExif
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:]))
+	if err != nil {
+		return ExifData{}, err
+	}
+	exif := ExifData{
+		Make:        t.ascii(ifd0[tagMake]),
+		Model:       t.ascii(ifd0[tagModel]),
+		Software:    t.ascii(ifd0[tagSoftware]),
+		DateTime:    t.ascii(ifd0[tagDateTime]),
+		Orientation: int(t.uintValue(ifd0[tagOrientation])),
+	}
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := t.readIFD(t.uintValue(e)); err == nil {
+			exif.DateTimeOriginal = t.ascii(sub[tagDateTimeOriginal])
+		}
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := t.readIFD(t.uintValue(e)); err == nil {
+			exif.GPS = t.gps(gps)
+		}
+	}
+	return exif, nil
+}
+
+func (t tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("%w: ifd out of range", ErrNoExif)
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		pos := int(offset) + 2 + i*12
+		if pos+12 > len(t.data) {
+			break
+		}
+		e := ifdEntry{typ: t.order.Uint16(t.data[pos+2:]), count: t.order.Uint32(t.data[pos+4:])}
+		size := uint64(typeSize(e.typ)) * uint64(e.count)
+		if size == 0 {
+			continue
+		}
+		if size <= 4 {
+			e.value = t.data[pos+8 : pos+8+int(size)]
+		} else {
+			off := uint64(t.order.Uint32(t.data[pos+8:]))
+			if off+size > uint64(len(t.data)) {
+				continue
+			}
+			e.value = t.data[off : off+size]
+		}
+		entries[t.order.Uint16(t.data[pos:])] = e
+	}
+	return entries, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 7: // BYTE, ASCII, UNDEFINED
+		return 1
+	case 3: // SHORT
+		return 2
+	case 4, 9: // LONG, SLONG
+		return 4
+	case 5, 10: // RATIONAL, SRATIONAL
+		return 8
+	}
+	return 0
+}
+
+func (t tiffReader) ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+func (t tiffReader) uintValue(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value))
+	case e.typ == 4 && len(e.value) >= 4:
+		return t.order.Uint32(e.value)
+	case e.typ == 1 && len(e.value) >= 1:
+		return uint32(e.value[0])
+	}
+	return 0
+}
+
+func (t tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != 5 {
+		return nil
+	}
+	out := make([]float64, 0, len(e.value)/8)
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num, den := t.order.Uint32(e.value[i:]), t.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		out = append(out, float64(num)/float64(den))
+	}
+	return out
+}
+
+func (t tiffReader) gps(ifd map[uint16]ifdEntry) *GPSInfo {
+	lat, lon := t.rationals(ifd[tagGPSLatitude]), t.rationals(ifd[tagGPSLongitude])
+	if len(lat) != 3 || len(lon) != 3 {
+		return nil
+	}
+	g := &GPSInfo{
+		Latitude:  lat[0] + lat[1]/60 + lat[2]/3600,
+		Longitude: lon[0] + lon[1]/60 + lon[2]/3600,
+		DateStamp: t.ascii(ifd[tagGPSDateStamp]),
+	}
+	if t.ascii(ifd[tagGPSLatitudeRef]) == "S" {
+		g.Latitude = -g.Latitude
+	}
+	if t.ascii(ifd[tagGPSLongitudeRef]) == "W" {
+		g.Longitude = -g.Longitude
+	}
+	if alt := t.rationals(ifd[tagGPSAltitude]); len(alt) == 1 {
+		a := alt[0]
+		if t.uintValue(ifd[tagGPSAltitudeRef]) == 1 { // di bawah permukaan laut
+			a = -a
+		}
+		g.Altitude = &a
+	}
+	return g
+}
@@ -0,0 +1,184 @@
+package evidence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore menyimpan object di <root>/sha256/<2 hex pertama>/<hash>
+type LocalStore struct {
+	root string
+}
+
+func NewLocalStore(root string) *LocalStore {
+	return &LocalStore{root: root}
+}
+
+func (s *LocalStore) Name() string { return "local" }
+
+func (s *LocalStore) path(hash string) string {
+	return filepath.Join(s.root, "sha256", hash[:2], hash)
+}
+
+func (s *LocalStore) Put(ctx context.Context, r io.Reader, contentType string) (Object, error) {
+	tmpDir := filepath.Join(s.root, "tmp")
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return Object{}, err
+	}
+	tmp, err := os.CreateTemp(tmpDir, "upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Object{}, err
+	}
+
+	obj := Object{Hash: hex.EncodeToString(h.Sum(nil)), Size: size, ContentType: contentType}
+	dst := s.path(obj.Hash)
+	if _, err := os.Stat(dst); err == nil {
+		return obj, nil // sudah ada, isi identik
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return Object{}, err
+	}
+	return obj, os.Rename(tmp.Name(), dst)
+}
+
+func (s *LocalStore) Get(ctx context.Context, hash string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, hash)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	f, err := os.Open(s.path(hash))
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return f, obj, nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, hash string) (Object, error) {
+	if err := ValidHash(hash); err != nil {
+		return Object{}, err
+	}
+	f, err := os.Open(s.path(hash))
+	if os.IsNotExist(err) {
+		return Object{}, ErrNotFound
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Object{}, err
+	}
+	// Filesystem tidak menyimpan content type, deteksi dari isi file
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return Object{Hash: hash, Size: info.Size(), ContentType: http.DetectContentType(head[:n])}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, hash string) error {
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(hash))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *LocalStore) stagingPath(id string) string {
+	return filepath.Join(s.root, "staging", id)
+}
+
+// GetURL URL node bertanda tangan HMAC (lihat OpenSigned)
+func (s *LocalStore) GetURL(ctx context.Context, hash, contentType string, ttl time.Duration) (string, error) {
+	if _, err := s.Stat(ctx, hash); err != nil {
+		return "", err
+	}
+	return signedURL("GET", SignedPath+hash, 0, ttl)
+}
+
+// StagingURL URL node bertanda tangan HMAC (lihat WriteSigned)
+func (s *LocalStore) StagingURL(ctx context.Context, id string, size int64, ttl time.Duration) (string, error) {
+	if err := validStagingID(id); err != nil {
+		return "", err
+	}
+	return signedURL("PUT", SignedPath+"staging/"+id, size, ttl)
+}
+
+func (s *LocalStore) writeStaging(ctx context.Context, id string, r io.Reader) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(s.root, "staging"), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(s.stagingPath(id))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(s.stagingPath(id))
+	}
+	return err
+}
+
+func (s *LocalStore) OpenStaging(ctx context.Context, id string) (io.ReadCloser, error) {
+	if err := validStagingID(id); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.stagingPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) CommitStaging(ctx context.Context, id, hash, contentType string) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	dst := s.path(hash)
+	if _, err := os.Stat(dst); err == nil {
+		return s.DeleteStaging(ctx, id) // sudah ada, isi identik
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(s.stagingPath(id), dst)
+}
+
+func (s *LocalStore) DeleteStaging(ctx context.Context, id string) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	err := os.Remove(s.stagingPath(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore adalah Store in-memory untuk pengujian dan development
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	staging map[string][]byte
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+func (s *MemoryStore) Name() string { return "memory" }
+
+func (s *MemoryStore) Put(ctx context.Context, r io.Reader, contentType string) (Object, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Object{}, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[hash]; !ok {
+		s.objects[hash] = memoryObject{data: data, contentType: contentType}
+	}
+	return Object{Hash: hash, Size: int64(len(data)), ContentType: contentType}, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, hash string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, hash)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	s.mu.RLock()
+	data := s.objects[hash].data
+	s.mu.RUnlock()
+	return io.NopCloser(bytes.NewReader(data)), obj, nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, hash string) (Object, error) {
+	if err := ValidHash(hash); err != nil {
+		return Object{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.objects[hash]
+	if !ok {
+		return Object{}, ErrNotFound
+	}
+	return Object{Hash: hash, Size: int64(len(o.data)), ContentType: o.contentType}, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, hash string) error {
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[hash]; !ok {
+		return ErrNotFound
+	}
+	delete(s.objects, hash)
+	return nil
+}
+
+func (s *MemoryStore) GetURL(ctx context.Context, hash, contentType string, ttl time.Duration) (string, error) {
+	if _, err := s.Stat(ctx, hash); err != nil {
+		return "", err
+	}
+	return signedURL("GET", SignedPath+hash, 0, ttl)
+}
+
+func (s *MemoryStore) StagingURL(ctx context.Context, id string, size int64, ttl time.Duration) (string, error) {
+	if err := validStagingID(id); err != nil {
+		return "", err
+	}
+	return signedURL("PUT", SignedPath+"staging/"+id, size, ttl)
+}
+
+func (s *MemoryStore) writeStaging(ctx context.Context, id string, r io.Reader) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.staging == nil {
+		s.staging = make(map[string][]byte)
+	}
+	s.staging[id] = data
+	return nil
+}
+
+func (s *MemoryStore) OpenStaging(ctx context.Context, id string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.staging[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStore) CommitStaging(ctx context.Context, id, hash, contentType string) error {
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.staging[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.staging, id)
+	if _, ok := s.objects[hash]; !ok {
+		s.objects[hash] = memoryObject{data: data, contentType: contentType}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteStaging(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.staging, id)
+	return nil
+}
@@ -0,0 +1,140 @@
+package evidence
+
+import (
+	"context"
+	"crypto/sha256"
+	"doc-tracker/storage"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// S3Store menyimpan object di bucket dengan key <prefix><hash>; upload
+// langsung dari client masuk ke <prefix>staging/<id> sebelum diverifikasi
+type S3Store struct {
+	s3     *storage.S3Storage
+	prefix string
+}
+
+func NewS3Store(s3 *storage.S3Storage, prefix string) *S3Store {
+	return &S3Store{s3: s3, prefix: prefix}
+}
+
+func (s *S3Store) stagingKey(id string) string {
+	return s.prefix + "staging/" + id
+}
+
+func (s *S3Store) Name() string { return "s3" }
+
+// Put menampung stream di file sementara untuk menghitung hash (key object)
+// sebelum diunggah, sehingga memori tetap kecil untuk file besar
+func (s *S3Store) Put(ctx context.Context, r io.Reader, contentType string) (Object, error) {
+	tmp, err := os.CreateTemp("", "evidence-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return Object{}, err
+	}
+	obj := Object{Hash: hex.EncodeToString(h.Sum(nil)), Size: size, ContentType: contentType}
+
+	if _, err := s.Stat(ctx, obj.Hash); err == nil {
+		return obj, nil // sudah ada, isi identik
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Object{}, err
+	}
+	return obj, s.s3.PutS3Stream(s.prefix+obj.Hash, tmp, size, contentType)
+}
+
+func (s *S3Store) Get(ctx context.Context, hash string) (io.ReadCloser, Object, error) {
+	obj, err := s.Stat(ctx, hash)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	r, err := s.s3.OpenS3File(s.prefix + hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	return r, obj, err
+}
+
+func (s *S3Store) Stat(ctx context.Context, hash string) (Object, error) {
+	if err := ValidHash(hash); err != nil {
+		return Object{}, err
+	}
+	size, contentType, err := s.s3.HeadS3File(s.prefix + hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return Object{}, ErrNotFound
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Hash: hash, Size: size, ContentType: contentType}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, hash string) error {
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	return s.s3.DeleteS3File(s.prefix + hash)
+}
+
+// GetURL presigned GET S3
+func (s *S3Store) GetURL(ctx context.Context, hash, contentType string, ttl time.Duration) (string, error) {
+	if _, err := s.Stat(ctx, hash); err != nil {
+		return "", err
+	}
+	return s.s3.PresignGetS3(s.prefix+hash, ttl, contentType)
+}
+
+// StagingURL presigned PUT S3 dengan Content-Length yang ditandatangani
+func (s *S3Store) StagingURL(ctx context.Context, id string, size int64, ttl time.Duration) (string, error) {
+	if err := validStagingID(id); err != nil {
+		return "", err
+	}
+	return s.s3.PresignPutS3(s.stagingKey(id), size, ttl)
+}
+
+func (s *S3Store) OpenStaging(ctx context.Context, id string) (io.ReadCloser, error) {
+	if err := validStagingID(id); err != nil {
+		return nil, err
+	}
+	r, err := s.s3.OpenS3File(s.stagingKey(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// CommitStaging menyalin object staging ke key content-addressed di sisi S3
+func (s *S3Store) CommitStaging(ctx context.Context, id, hash, contentType string) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	if err := ValidHash(hash); err != nil {
+		return err
+	}
+	if _, err := s.Stat(ctx, hash); errors.Is(err, ErrNotFound) {
+		if err := s.s3.CopyS3File(s.stagingKey(id), s.prefix+hash, contentType); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return s.DeleteStaging(ctx, id)
+}
+
+func (s *S3Store) DeleteStaging(ctx context.Context, id string) error {
+	if err := validStagingID(id); err != nil {
+		return err
+	}
+	return s.s3.DeleteS3File(s.stagingKey(id))
+}
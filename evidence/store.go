@@ -0,0 +1,220 @@
+// Package evidence menyimpan file evidence checkpoint secara content-addressed:
+// setiap object dikunci dengan SHA-256 isinya.
+//
+// Backend dipilih dengan EVIDENCE_STORE:
+//   - local  : filesystem di EVIDENCE_DIR (default storage/evidence)
+//   - s3     : bucket Storj/S3 (storage.S3)
+//   - memory : in-memory, untuk pengujian dan development
+//
+// Jika EVIDENCE_STORE kosong, S3_STORAGE=true memilih s3, selain itu local.
+//
+// URL bertanda tangan untuk backend local/memory (SignedPath) membutuhkan
+// EVIDENCE_URL_SECRET; tanpa secret URL langsung dimatikan dan evidence
+// hanya bisa diunduh lewat node.
+package evidence
+
+import (
+	"context"
+	"crypto/sha256"
+	"doc-tracker/storage"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RefPrefix menandai EvidencePath yang merujuk ke object content-addressed
+const RefPrefix = "sha256:"
+
+var (
+	ErrNotFound     = errors.New("evidence not found")
+	ErrInvalidHash  = errors.New("invalid evidence hash")
+	ErrUnknownStore = errors.New("unknown evidence store")
+	ErrInvalidPath  = errors.New("invalid evidence path")
+)
+
+// Object metadata evidence yang tersimpan
+type Object struct {
+	Hash        string `json:"hash"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Store backend penyimpanan evidence, dikunci dengan hash SHA-256 (hex)
+type Store interface {
+	Name() string
+	// Put men-stream r ke storage sambil menghitung hash-nya
+	Put(ctx context.Context, r io.Reader, contentType string) (Object, error)
+	Get(ctx context.Context, hash string) (io.ReadCloser, Object, error)
+	Stat(ctx context.Context, hash string) (Object, error)
+	Delete(ctx context.Context, hash string) error
+}
+
+var (
+	active Store
+	mu     sync.Mutex
+)
+
+// Default mengembalikan store aktif, memilihnya dari environment saat pertama dipakai
+func Default() (Store, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if active != nil {
+		return active, nil
+	}
+	name := os.Getenv("EVIDENCE_STORE")
+	if name == "" {
+		name = "local"
+		if os.Getenv("S3_STORAGE") == "true" {
+			name = "s3"
+		}
+	}
+
+	switch name {
+	case "local":
+		active = NewLocalStore(envOr("EVIDENCE_DIR", "storage/evidence"))
+	case "s3":
+		if storage.S3 == nil {
+			return nil, errors.New("s3 evidence store requires storage.S3 to be initialized")
+		}
+		active = NewS3Store(storage.S3, "evidence/sha256/")
+	case "memory":
+		active = NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStore, name)
+	}
+	return active, nil
+}
+
+// SetDefault mengganti store aktif (mis. dengan MemoryStore saat pengujian)
+func SetDefault(s Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = s
+}
+
+// Ref adalah nilai EvidencePath untuk object content-addressed
+func Ref(hash string) string {
+	return RefPrefix + hash
+}
+
+// RefHash mengembalikan hash object dari path RefPrefix, atau "" untuk path lama
+func RefHash(path string) string {
+	if !strings.HasPrefix(path, RefPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(path, RefPrefix)
+}
+
+// Open membuka evidence checkpoint. Path dengan RefPrefix dibaca dari store
+// aktif dengan hash di path (hash ciphertext untuk evidence terenkripsi), path
+// kosong dengan hash; path lama (file lokal atau key S3 penuh seperti
+// "evidence/<tracker>_<addr>.jpg") dibaca langsung dari lokasinya.
+func Open(ctx context.Context, hash, path string) (io.ReadCloser, Object, error) {
+	if ref := RefHash(path); ref != "" {
+		hash = ref
+	} else if path != "" {
+		r, obj, err := openLegacy(path)
+		if err == nil {
+			obj.Hash = hash
+			return r, obj, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, Object{}, err
+		}
+	}
+
+	s, err := Default()
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return s.Get(ctx, hash)
+}
+
+// legacyLocation memvalidasi path lama dari tracker: hanya file di bawah
+// EVIDENCE_DIR (atau storage/evidence, lokasi lama) atau key S3 di bawah
+// evidence/. Path absolut dan ".." ditolak karena path berasal dari data tracker.
+func legacyLocation(path string) (local, s3Key string, err error) {
+	slashed := filepath.ToSlash(path)
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(slashed, "/") {
+		return "", "", ErrInvalidPath
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", "", ErrInvalidPath
+		}
+	}
+
+	clean := filepath.Clean(path)
+	for _, dir := range []string{envOr("EVIDENCE_DIR", "storage/evidence"), "storage/evidence"} {
+		rel, err := filepath.Rel(filepath.Clean(dir), clean)
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			local = clean
+			break
+		}
+	}
+	if key := strings.TrimPrefix(filepath.ToSlash(clean), "./"); strings.HasPrefix(key, "evidence/") {
+		s3Key = key
+	}
+	if local == "" && s3Key == "" {
+		return "", "", ErrInvalidPath
+	}
+	return local, s3Key, nil
+}
+
+func openLegacy(path string) (io.ReadCloser, Object, error) {
+	local, s3Key, err := legacyLocation(path)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	obj := Object{ContentType: mime.TypeByExtension(filepath.Ext(path))}
+
+	if local == "" {
+		return openLegacyS3(s3Key, obj)
+	}
+	f, err := os.Open(local)
+	if err == nil {
+		if info, err := f.Stat(); err == nil {
+			obj.Size = info.Size()
+		}
+		return f, obj, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, Object{}, err
+	}
+
+	return openLegacyS3(s3Key, obj)
+}
+
+func openLegacyS3(key string, obj Object) (io.ReadCloser, Object, error) {
+	if key != "" && os.Getenv("S3_STORAGE") == "true" && storage.S3 != nil {
+		r, err := storage.S3.OpenS3File(key)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, Object{}, ErrNotFound
+		}
+		return r, obj, err
+	}
+	return nil, Object{}, ErrNotFound
+}
+
+// ValidHash memeriksa hash SHA-256 hex (lowercase)
+func ValidHash(hash string) error {
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != sha256.Size || strings.ToLower(hash) != hash {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
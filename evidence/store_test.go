@@ -0,0 +1,193 @@
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var samplePDF = []byte("%PDF-1.4\n% evidence test document\n")
+
+func sampleHash() string {
+	sum := sha256.Sum256(samplePDF)
+	return hex.EncodeToString(sum[:])
+}
+
+// storeCases backend yang harus berperilaku sama terhadap interface Store
+func storeCases(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"local":  NewLocalStore(t.TempDir()),
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storeCases(t) {
+		t.Run(name, func(t *testing.T) {
+			obj, err := s.Put(ctx, bytes.NewReader(samplePDF), "application/pdf")
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if obj.Hash != sampleHash() {
+				t.Fatalf("Put hash = %s, want %s", obj.Hash, sampleHash())
+			}
+
+			r, got, err := s.Get(ctx, obj.Hash)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !bytes.Equal(data, samplePDF) {
+				t.Fatalf("Get returned %q, want %q", data, samplePDF)
+			}
+			if got.Hash != obj.Hash {
+				t.Fatalf("Get object hash = %s, want %s", got.Hash, obj.Hash)
+			}
+		})
+	}
+}
+
+func TestStoreStat(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storeCases(t) {
+		t.Run(name, func(t *testing.T) {
+			obj, err := s.Put(ctx, bytes.NewReader(samplePDF), "application/pdf")
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			stat, err := s.Stat(ctx, obj.Hash)
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if stat.Size != int64(len(samplePDF)) {
+				t.Errorf("Stat size = %d, want %d", stat.Size, len(samplePDF))
+			}
+			if stat.ContentType != "application/pdf" {
+				t.Errorf("Stat content type = %q, want application/pdf", stat.ContentType)
+			}
+		})
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storeCases(t) {
+		t.Run(name, func(t *testing.T) {
+			obj, err := s.Put(ctx, bytes.NewReader(samplePDF), "application/pdf")
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if err := s.Delete(ctx, obj.Hash); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, _, err := s.Get(ctx, obj.Hash); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get after Delete err = %v, want ErrNotFound", err)
+			}
+			if err := s.Delete(ctx, obj.Hash); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("second Delete err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreDuplicatePut(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storeCases(t) {
+		t.Run(name, func(t *testing.T) {
+			first, err := s.Put(ctx, bytes.NewReader(samplePDF), "application/pdf")
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			var before os.FileInfo
+			if ls, ok := s.(*LocalStore); ok {
+				before, _ = os.Stat(ls.path(first.Hash))
+			}
+
+			second, err := s.Put(ctx, bytes.NewReader(samplePDF), "text/plain")
+			if err != nil {
+				t.Fatalf("duplicate Put: %v", err)
+			}
+			if second.Hash != first.Hash || second.Size != first.Size {
+				t.Fatalf("duplicate Put = %+v, want %+v", second, first)
+			}
+			stat, err := s.Stat(ctx, first.Hash)
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if stat.ContentType != "application/pdf" {
+				t.Errorf("duplicate Put changed content type to %q", stat.ContentType)
+			}
+			if ls, ok := s.(*LocalStore); ok {
+				after, err := os.Stat(ls.path(first.Hash))
+				if err != nil || !os.SameFile(before, after) {
+					t.Errorf("duplicate Put replaced the stored file")
+				}
+			}
+		})
+	}
+}
+
+func TestStoreRejectsInvalidHash(t *testing.T) {
+	ctx := context.Background()
+	upper := strings.ToUpper(sampleHash())
+	short := sampleHash()[:32]
+	for name, s := range storeCases(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, hash := range []string{upper, short} {
+				if _, err := s.Stat(ctx, hash); !errors.Is(err, ErrInvalidHash) {
+					t.Errorf("Stat(%s) err = %v, want ErrInvalidHash", hash, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidHash(t *testing.T) {
+	cases := map[string]bool{
+		sampleHash():                       true,
+		strings.ToUpper(sampleHash()):      false,
+		sampleHash()[:62]:                  false,
+		"":                                 false,
+		strings.Repeat("z", 64):            false,
+		sampleHash() + sampleHash()[:2]:    false,
+		strings.Repeat("0", sha256.Size*2): true,
+	}
+	for hash, ok := range cases {
+		if err := ValidHash(hash); (err == nil) != ok {
+			t.Errorf("ValidHash(%q) = %v, want ok=%v", hash, err, ok)
+		}
+	}
+}
+
+func TestLegacyLocation(t *testing.T) {
+	t.Setenv("EVIDENCE_DIR", "")
+	cases := map[string]bool{
+		"storage/evidence/t1/a.jpg":                     true,
+		"./storage/evidence/t1_addr.jpg":                true,
+		"evidence/t1_addr.jpg":                          true,
+		"data/keys/keyring.json":                        false,
+		"/etc/passwd":                                   false,
+		"storage/evidence/../../data/keys/keyring.json": false,
+		"evidence/../data/keys/keyring.json":            false,
+		"storage/evidence":                              false,
+		filepath.Join(os.TempDir(), "x.jpg"):            false,
+	}
+	for path, ok := range cases {
+		_, _, err := legacyLocation(path)
+		if (err == nil) != ok {
+			t.Errorf("legacyLocation(%q) err = %v, want ok=%v", path, err, ok)
+		}
+	}
+}
@@ -30,6 +30,12 @@ func (s *server) GetLatestBlock(ctx context.Context, in *pb.Empty) (*pb.Block, e
 	return utils.ConvertToProto(block), nil // ✅ convert back to proto
 }
 
+// GetHeaders melayani header plaintext untuk light client dan auditor
+func (s *server) GetHeaders(ctx context.Context, in *pb.HeaderRequest) (*pb.HeaderList, error) {
+	headers := blockchain.GetHeaders(int(in.FromIndex), int(in.Limit))
+	return utils.ConvertToProtoHeaders(headers), nil
+}
+
 func StartGRPCServer(port string) {
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
@@ -0,0 +1,65 @@
+package keymanager
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envProvider membaca key dari environment:
+//
+//	NODE_STORAGE_KEY           hex 32 byte, key storage aktif
+//	NODE_STORAGE_KEY_PREVIOUS  hex 32 byte, opsional, hanya untuk membuka data lama saat rotasi
+//	NODE_SIGNING_KEY           hex 32 byte, scalar ECDSA P-256
+//
+// Rotasi dilakukan dengan mengganti variabel lalu menjalankan `doctracker keys reencrypt`.
+type envProvider struct {
+	*softwareProvider
+}
+
+func newEnvProvider() (Provider, error) {
+	sp := &softwareProvider{ring: keyringFile{Current: map[Purpose]string{}}}
+
+	load := func(name string, p Purpose, current bool) error {
+		v := strings.TrimSpace(os.Getenv(name))
+		if v == "" {
+			if current {
+				return fmt.Errorf("%s is not set", name)
+			}
+			return nil
+		}
+		material, err := hex.DecodeString(v)
+		if err != nil || len(material) != 32 {
+			return fmt.Errorf("%s must be 32 bytes of hex", name)
+		}
+		if p == PurposeSigning {
+			if _, err := p256Key(material); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+		rec := keyRecord{ID: keyID(p, material), Purpose: p, Material: hex.EncodeToString(material)}
+		sp.ring.Keys = append(sp.ring.Keys, rec)
+		if current {
+			sp.ring.Current[p] = rec.ID
+		}
+		return nil
+	}
+
+	if err := load("NODE_STORAGE_KEY", PurposeStorage, true); err != nil {
+		return nil, err
+	}
+	if err := load("NODE_STORAGE_KEY_PREVIOUS", PurposeStorage, false); err != nil {
+		return nil, err
+	}
+	if err := load("NODE_SIGNING_KEY", PurposeSigning, true); err != nil {
+		return nil, err
+	}
+	return &envProvider{sp}, nil
+}
+
+func (ep *envProvider) Name() string { return "env" }
+
+func (ep *envProvider) Rotate(Purpose) (string, error) { return "", ErrReadOnly }
+
+func (ep *envProvider) Retire(string) error { return ErrReadOnly }
@@ -0,0 +1,230 @@
+// Package keymanager mengelola key milik node sendiri (bukan wallet user).
+//
+// Sumber key dipilih dengan KEYMANAGER_SOURCE:
+//   - file (default) : token software, keyring di KEYMANAGER_DIR/keyring.json
+//   - env            : NODE_STORAGE_KEY / NODE_SIGNING_KEY
+//   - nama lain      : plugin yang didaftarkan lewat Register (mis. PKCS#11/HSM)
+//
+// Ciphertext dari Encrypt membawa key ID sehingga data lama tetap bisa dibuka
+// setelah rotasi sampai key lama di-Retire.
+package keymanager
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Factory membuat provider dari konfigurasi environment
+type Factory func() (Provider, error)
+
+var (
+	ErrUnknownSource = errors.New("unknown key source")
+	ErrNotManaged    = errors.New("data was not encrypted by the key manager")
+)
+
+// magic header ciphertext: "DTK1" | len(keyID) | keyID | ciphertext provider
+var magic = []byte("DTK1")
+
+var (
+	factories = map[string]Factory{
+		"file": newSoftwareProvider,
+		"env":  newEnvProvider,
+	}
+	active Provider
+	mu     sync.Mutex
+)
+
+// Register menambahkan provider plugin, dipanggil sebelum Init
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = f
+}
+
+// Init memuat provider dari KEYMANAGER_SOURCE. Aman dipanggil berkali-kali.
+func Init() error {
+	_, err := Active()
+	return err
+}
+
+// Active mengembalikan provider aktif, memuatnya saat pertama dipakai
+func Active() (Provider, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if active != nil {
+		return active, nil
+	}
+
+	source := os.Getenv("KEYMANAGER_SOURCE")
+	if source == "" {
+		source = "file"
+	}
+	factory, ok := factories[source]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, source)
+	}
+	p, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("key source %s: %v", source, err)
+	}
+
+	if os.Getenv("TRACKER_SECRET") != "" {
+		log.Println("⚠️ TRACKER_SECRET is no longer used, node keys are managed by the key manager")
+	}
+	for _, purpose := range Purposes {
+		if id, err := p.CurrentKeyID(purpose); err == nil {
+			log.Printf("🔑 Node %s key %s (source %s)", purpose, id, p.Name())
+		}
+	}
+
+	active = p
+	return active, nil
+}
+
+// Encrypt mengenkripsi dengan key storage aktif; ad mengikat ciphertext ke konteksnya
+func Encrypt(plaintext, ad []byte) ([]byte, error) {
+	p, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	id, err := p.CurrentKeyID(PurposeStorage)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := p.Seal(id, plaintext, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+1+len(id)+len(ct))
+	out = append(out, magic...)
+	out = append(out, byte(len(id)))
+	out = append(out, id...)
+	return append(out, ct...), nil
+}
+
+// Decrypt membuka hasil Encrypt dengan key yang tercatat di header
+func Decrypt(blob, ad []byte) ([]byte, error) {
+	id, ct, err := parse(blob)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	return p.Open(id, ct, ad)
+}
+
+// IsManaged true jika blob berformat keymanager (bukan format lama)
+func IsManaged(blob []byte) bool {
+	_, _, err := parse(blob)
+	return err == nil
+}
+
+// KeyIDOf mengembalikan key ID yang mengenkripsi blob
+func KeyIDOf(blob []byte) (string, error) {
+	id, _, err := parse(blob)
+	return id, err
+}
+
+// IsCurrent true jika blob dienkripsi dengan key storage aktif
+func IsCurrent(blob []byte) bool {
+	id, err := KeyIDOf(blob)
+	if err != nil {
+		return false
+	}
+	current, err := CurrentKeyID(PurposeStorage)
+	return err == nil && id == current
+}
+
+// CurrentKeyID mengembalikan key aktif untuk purpose
+func CurrentKeyID(purpose Purpose) (string, error) {
+	p, err := Active()
+	if err != nil {
+		return "", err
+	}
+	return p.CurrentKeyID(purpose)
+}
+
+// Sign menandatangani sha256(data) dengan key signing node (DER)
+func Sign(data []byte) ([]byte, error) {
+	p, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+	return p.Sign(digest[:])
+}
+
+// PublicKey adalah public key signing node
+func PublicKey() (*ecdsa.PublicKey, error) {
+	p, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	return p.PublicKey()
+}
+
+// Verify memeriksa signature Sign terhadap public key node tertentu
+func Verify(pub *ecdsa.PublicKey, data, sig []byte) bool {
+	digest := sha256.Sum256(data)
+	return pub != nil && ecdsa.VerifyASN1(pub, digest[:], sig)
+}
+
+// Rotate membuat key baru untuk purpose. Data lama perlu dienkripsi ulang
+// (lihat services.ReencryptNodeData) sebelum key lama di-Retire.
+func Rotate(purpose Purpose) (string, error) {
+	p, err := Active()
+	if err != nil {
+		return "", err
+	}
+	return p.Rotate(purpose)
+}
+
+// Retire menghapus key lama dari provider
+func Retire(keyID string) error {
+	p, err := Active()
+	if err != nil {
+		return err
+	}
+	return p.Retire(keyID)
+}
+
+// Keys mengembalikan metadata semua key provider aktif
+func Keys() ([]KeyInfo, error) {
+	p, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	return p.Keys()
+}
+
+// Fingerprint ringkas public key signing, untuk ditampilkan ke operator
+func Fingerprint(pub *ecdsa.PublicKey) string {
+	if pub == nil {
+		return ""
+	}
+	sum := sha256.Sum256(append(pub.X.Bytes(), pub.Y.Bytes()...))
+	return hex.EncodeToString(sum[:8])
+}
+
+func parse(blob []byte) (string, []byte, error) {
+	if len(blob) < len(magic)+1 || !bytes.Equal(blob[:len(magic)], magic) {
+		return "", nil, ErrNotManaged
+	}
+	n := int(blob[len(magic)])
+	start := len(magic) + 1
+	if n == 0 || len(blob) < start+n {
+		return "", nil, ErrNotManaged
+	}
+	return string(blob[start : start+n]), blob[start+n:], nil
+}
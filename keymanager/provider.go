@@ -0,0 +1,57 @@
+package keymanager
+
+import (
+	"crypto/ecdsa"
+	"errors"
+)
+
+// Purpose membedakan key milik node. Satu key hanya dipakai untuk satu purpose.
+type Purpose string
+
+const (
+	PurposeStorage Purpose = "storage" // AES-256-GCM untuk mempool.bin dan data/blocks
+	PurposeSigning Purpose = "signing" // ECDSA P-256 untuk tanda tangan node
+)
+
+// Purposes adalah semua purpose yang dikelola node
+var Purposes = []Purpose{PurposeStorage, PurposeSigning}
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrReadOnly     = errors.New("key source does not support rotation, update its configuration instead")
+	ErrWrongPurpose = errors.New("operation not supported for this key purpose")
+)
+
+// KeyInfo adalah metadata key tanpa material rahasia
+type KeyInfo struct {
+	ID        string  `json:"id"`
+	Purpose   Purpose `json:"purpose"`
+	Current   bool    `json:"current"`
+	CreatedAt int64   `json:"created_at"`
+	RetiredAt int64   `json:"retired_at,omitempty"`
+}
+
+// Provider adalah antarmuka gaya PKCS#11: material key tidak pernah keluar dari
+// provider, pemanggil hanya meminta operasi dengan key ID. Implementasi HSM
+// didaftarkan lewat Register.
+type Provider interface {
+	Name() string
+
+	// CurrentKeyID mengembalikan key aktif untuk purpose
+	CurrentKeyID(p Purpose) (string, error)
+
+	// Seal/Open: AEAD dengan key storage tertentu
+	Seal(keyID string, plaintext, ad []byte) ([]byte, error)
+	Open(keyID string, ciphertext, ad []byte) ([]byte, error)
+
+	// Sign menandatangani digest dengan key signing aktif (DER)
+	Sign(digest []byte) ([]byte, error)
+	PublicKey() (*ecdsa.PublicKey, error)
+
+	// Rotate membuat key baru untuk purpose dan menjadikannya aktif. Key lama
+	// tetap bisa Open sampai di-Retire.
+	Rotate(p Purpose) (string, error)
+	Retire(keyID string) error
+
+	Keys() ([]KeyInfo, error)
+}
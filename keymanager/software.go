@@ -0,0 +1,320 @@
+package keymanager
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// keyRecord adalah satu key di keyring software. Material hanya ada di file
+// keyring (0600) dan di memori proses, tidak pernah dicetak.
+type keyRecord struct {
+	ID        string  `json:"id"`
+	Purpose   Purpose `json:"purpose"`
+	Material  string  `json:"material"` // hex: key AES atau scalar ECDSA
+	CreatedAt int64   `json:"created_at"`
+	RetiredAt int64   `json:"retired_at,omitempty"`
+}
+
+type keyringFile struct {
+	Current map[Purpose]string `json:"current"`
+	Keys    []keyRecord        `json:"keys"`
+}
+
+// softwareProvider adalah token software: keyring JSON di KEYMANAGER_DIR
+// (default data/keys/keyring.json). Mendukung rotasi.
+type softwareProvider struct {
+	mu      sync.RWMutex
+	path    string // kosong untuk keyring di memori (envProvider)
+	ring    keyringFile
+	modTime time.Time
+}
+
+func newSoftwareProvider() (Provider, error) {
+	dir := os.Getenv("KEYMANAGER_DIR")
+	if dir == "" {
+		dir = "data/keys"
+	}
+	sp := &softwareProvider{path: filepath.Join(dir, "keyring.json")}
+
+	data, err := os.ReadFile(sp.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &sp.ring); err != nil {
+			return nil, fmt.Errorf("invalid keyring %s: %v", sp.path, err)
+		}
+	case os.IsNotExist(err):
+		sp.ring = keyringFile{Current: map[Purpose]string{}}
+	default:
+		return nil, err
+	}
+	if sp.ring.Current == nil {
+		sp.ring.Current = map[Purpose]string{}
+	}
+
+	// Buat key yang belum ada (node baru)
+	created := false
+	for _, p := range Purposes {
+		if _, ok := sp.ring.Current[p]; ok {
+			continue
+		}
+		rec, err := newKeyRecord(p)
+		if err != nil {
+			return nil, err
+		}
+		sp.ring.Keys = append(sp.ring.Keys, rec)
+		sp.ring.Current[p] = rec.ID
+		created = true
+	}
+	if created {
+		if err := sp.save(); err != nil {
+			return nil, err
+		}
+	} else if info, err := os.Stat(sp.path); err == nil {
+		sp.modTime = info.ModTime()
+	}
+	return sp, nil
+}
+
+// reloadIfChanged memuat ulang keyring jika file diubah proses lain
+// (mis. `doctracker keys rotate` saat node berjalan)
+func (sp *softwareProvider) reloadIfChanged() {
+	if sp.path == "" {
+		return
+	}
+	info, err := os.Stat(sp.path)
+	if err != nil {
+		return
+	}
+	sp.mu.RLock()
+	same := info.ModTime().Equal(sp.modTime)
+	sp.mu.RUnlock()
+	if same {
+		return
+	}
+
+	data, err := os.ReadFile(sp.path)
+	if err != nil {
+		return
+	}
+	var ring keyringFile
+	if err := json.Unmarshal(data, &ring); err != nil || ring.Current == nil {
+		return
+	}
+	sp.mu.Lock()
+	sp.ring = ring
+	sp.modTime = info.ModTime()
+	sp.mu.Unlock()
+}
+
+func (sp *softwareProvider) Name() string { return "file" }
+
+func (sp *softwareProvider) CurrentKeyID(p Purpose) (string, error) {
+	sp.reloadIfChanged()
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+	id, ok := sp.ring.Current[p]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return id, nil
+}
+
+func (sp *softwareProvider) Seal(keyID string, plaintext, ad []byte) ([]byte, error) {
+	key, err := sp.material(keyID, PurposeStorage, true)
+	if err != nil {
+		return nil, err
+	}
+	return utils.EncryptDataWithAD(key, plaintext, ad)
+}
+
+func (sp *softwareProvider) Open(keyID string, ciphertext, ad []byte) ([]byte, error) {
+	key, err := sp.material(keyID, PurposeStorage, false)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecryptDataWithAD(key, ciphertext, ad)
+}
+
+func (sp *softwareProvider) Sign(digest []byte) ([]byte, error) {
+	priv, err := sp.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.SignASN1(rand.Reader, priv, digest)
+}
+
+func (sp *softwareProvider) PublicKey() (*ecdsa.PublicKey, error) {
+	priv, err := sp.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	return &priv.PublicKey, nil
+}
+
+func (sp *softwareProvider) Rotate(p Purpose) (string, error) {
+	rec, err := newKeyRecord(p)
+	if err != nil {
+		return "", err
+	}
+
+	sp.reloadIfChanged()
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.ring.Keys = append(sp.ring.Keys, rec)
+	sp.ring.Current[p] = rec.ID
+	if err := sp.save(); err != nil {
+		return "", err
+	}
+	return rec.ID, nil
+}
+
+// Retire menghapus material key lama. Data yang masih terenkripsi dengan key
+// ini tidak bisa dibuka lagi, jadi panggil setelah re-enkripsi selesai.
+func (sp *softwareProvider) Retire(keyID string) error {
+	sp.reloadIfChanged()
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for i, rec := range sp.ring.Keys {
+		if rec.ID != keyID {
+			continue
+		}
+		if sp.ring.Current[rec.Purpose] == keyID {
+			return fmt.Errorf("key %s is still current", keyID)
+		}
+		if rec.RetiredAt != 0 {
+			return nil
+		}
+		sp.ring.Keys[i].Material = ""
+		sp.ring.Keys[i].RetiredAt = time.Now().Unix()
+		return sp.save()
+	}
+	return ErrKeyNotFound
+}
+
+func (sp *softwareProvider) Keys() ([]KeyInfo, error) {
+	sp.reloadIfChanged()
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	infos := make([]KeyInfo, 0, len(sp.ring.Keys))
+	for _, rec := range sp.ring.Keys {
+		infos = append(infos, KeyInfo{
+			ID:        rec.ID,
+			Purpose:   rec.Purpose,
+			Current:   sp.ring.Current[rec.Purpose] == rec.ID,
+			CreatedAt: rec.CreatedAt,
+			RetiredAt: rec.RetiredAt,
+		})
+	}
+	return infos, nil
+}
+
+func (sp *softwareProvider) material(keyID string, p Purpose, mustBeCurrent bool) ([]byte, error) {
+	sp.reloadIfChanged()
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+
+	if mustBeCurrent && sp.ring.Current[p] != keyID {
+		return nil, fmt.Errorf("key %s is not the current %s key", keyID, p)
+	}
+	for _, rec := range sp.ring.Keys {
+		if rec.ID != keyID {
+			continue
+		}
+		if rec.Purpose != p {
+			return nil, ErrWrongPurpose
+		}
+		if rec.RetiredAt != 0 {
+			return nil, fmt.Errorf("key %s is retired", keyID)
+		}
+		return hex.DecodeString(rec.Material)
+	}
+	return nil, ErrKeyNotFound
+}
+
+func (sp *softwareProvider) signingKey() (*ecdsa.PrivateKey, error) {
+	id, err := sp.CurrentKeyID(PurposeSigning)
+	if err != nil {
+		return nil, err
+	}
+	d, err := sp.material(id, PurposeSigning, true)
+	if err != nil {
+		return nil, err
+	}
+	return p256Key(d)
+}
+
+func (sp *softwareProvider) save() error {
+	if err := utils.CreateDirIfNotExists(filepath.Dir(sp.path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sp.ring, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := sp.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, sp.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(sp.path); err == nil {
+		sp.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func newKeyRecord(p Purpose) (keyRecord, error) {
+	var material []byte
+	switch p {
+	case PurposeStorage:
+		material = make([]byte, 32)
+		if _, err := rand.Read(material); err != nil {
+			return keyRecord{}, err
+		}
+	case PurposeSigning:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return keyRecord{}, err
+		}
+		material = priv.D.FillBytes(make([]byte, 32))
+	default:
+		return keyRecord{}, ErrWrongPurpose
+	}
+
+	return keyRecord{
+		ID:        keyID(p, material),
+		Purpose:   p,
+		Material:  hex.EncodeToString(material),
+		CreatedAt: time.Now().Unix(),
+	}, nil
+}
+
+// keyID adalah fingerprint key, aman dicatat di log dan header ciphertext
+func keyID(p Purpose, material []byte) string {
+	sum := sha256.Sum256(append([]byte("doctracker-node-key:"+string(p)+":"), material...))
+	return string(p) + "-" + hex.EncodeToString(sum[:6])
+}
+
+func p256Key(d []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	k := new(big.Int).SetBytes(d)
+	if k.Sign() == 0 || k.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid P-256 signing key")
+	}
+	x, y := curve.ScalarBaseMult(d)
+	return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: k}, nil
+}
@@ -0,0 +1,56 @@
+package keystore
+
+import (
+	"doc-tracker/utils"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	auditFile = "data/keystore/audit.log"
+	auditMu   sync.Mutex
+)
+
+// AuditEntry dicatat setiap kali key user di-unwrap
+type AuditEntry struct {
+	Time    int64  `json:"time"`
+	Email   string `json:"email"`
+	Address string `json:"address,omitempty"`
+	Purpose string `json:"purpose"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+// logUnwrap menambahkan satu baris JSON ke audit log (append-only)
+func logUnwrap(email, address, purpose string, err error) {
+	entry := AuditEntry{
+		Time:    time.Now().Unix(),
+		Email:   email,
+		Address: address,
+		Purpose: purpose,
+		OK:      err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, _ := json.Marshal(entry)
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if err := utils.CreateDirIfNotExists("data/keystore"); err != nil {
+		log.Printf("⚠️ Keystore audit: %v", err)
+		return
+	}
+	f, ferr := os.OpenFile(auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if ferr != nil {
+		log.Printf("⚠️ Keystore audit: %v", ferr)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
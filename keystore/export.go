@@ -0,0 +1,149 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ExportVersion adalah versi format file export yang dipegang user
+const ExportVersion = 1
+
+var (
+	ErrWeakPassword = errors.New("password must be at least 8 characters")
+	ErrExportParams = errors.New("unsupported export kdf parameters")
+)
+
+// ExportFile adalah keystore JSON portabel yang dilindungi password user
+// (scrypt + AES-256-GCM), bisa diimpor lagi ke node mana pun.
+type ExportFile struct {
+	Version    int          `json:"version"`
+	Type       string       `json:"type"`
+	Address    string       `json:"address"`
+	PublicKey  string       `json:"public_key"`
+	Crypto     ExportCrypto `json:"crypto"`
+	ExportedAt int64        `json:"exported_at"`
+}
+
+type ExportCrypto struct {
+	Cipher     string       `json:"cipher"`
+	Ciphertext string       `json:"ciphertext"`
+	KDF        string       `json:"kdf"`
+	KDFParams  ScryptParams `json:"kdfparams"`
+}
+
+type ScryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// ExportMnemonic membuka mnemonic (tercatat di audit log) lalu mengenkripsinya dengan password
+func ExportMnemonic(email, password string) ([]byte, error) {
+	if len(password) < 8 {
+		return nil, ErrWeakPassword
+	}
+
+	kf, err := Load(email)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err := UnwrapMnemonic(email, "export")
+	if err != nil {
+		return nil, err
+	}
+
+	params := ScryptParams{N: scryptN, R: scryptR, P: scryptP, DKLen: 32}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	params.Salt = base64.StdEncoding.EncodeToString(salt)
+
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+
+	out := ExportFile{
+		Version:    ExportVersion,
+		Type:       TypeMnemonic,
+		Address:    kf.Address,
+		PublicKey:  kf.PublicKey,
+		ExportedAt: time.Now().Unix(),
+	}
+	ct, err := utils.EncryptDataWithAD(key, []byte(mnemonic), out.associatedData())
+	if err != nil {
+		return nil, err
+	}
+	out.Crypto = ExportCrypto{
+		Cipher:     "aes-256-gcm",
+		Ciphertext: base64.StdEncoding.EncodeToString(ct),
+		KDF:        "scrypt",
+		KDFParams:  params,
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// DecryptExport membuka file export dengan password dan mengembalikan mnemonic
+func DecryptExport(data []byte, password string) (string, error) {
+	var ef ExportFile
+	if err := json.Unmarshal(data, &ef); err != nil {
+		return "", fmt.Errorf("invalid export file: %v", err)
+	}
+	if ef.Version != ExportVersion || ef.Type != TypeMnemonic {
+		return "", ErrUnsupported
+	}
+	if ef.Crypto.KDF != "scrypt" || ef.Crypto.Cipher != "aes-256-gcm" {
+		return "", ErrUnsupported
+	}
+
+	// Parameter scrypt berasal dari file upload: hanya terima yang ditulis
+	// ExportMnemonic agar N/r besar tidak menghabiskan memori node
+	p := ef.Crypto.KDFParams
+	if !p.supported() {
+		return "", ErrExportParams
+	}
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, p.N, p.R, p.P, p.DKLen)
+	if err != nil {
+		return "", err
+	}
+	ct, err := base64.StdEncoding.DecodeString(ef.Crypto.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+	pt, err := utils.DecryptDataWithAD(key, ct, ef.associatedData())
+	if err != nil {
+		return "", errors.New("wrong password or corrupted export file")
+	}
+
+	mnemonic := string(pt)
+	// Export dari entry legacy membawa address P-256 lama
+	_, _, address := utils.PrivateKeyFromMnemonic(mnemonic)
+	_, _, legacy := utils.LegacyPrivateKeyFromMnemonic(mnemonic)
+	if ef.Address != address && ef.Address != legacy {
+		return "", errors.New("export file address does not match its mnemonic")
+	}
+	return mnemonic, nil
+}
+
+func (ef *ExportFile) associatedData() []byte {
+	return []byte(fmt.Sprintf("doctracker-export:v%d:%s:%s", ef.Version, ef.Type, ef.Address))
+}
+
+func (p ScryptParams) supported() bool {
+	return p.N == scryptN && p.R == scryptR && p.P == scryptP && p.DKLen == 32
+}
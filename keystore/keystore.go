@@ -0,0 +1,424 @@
+// Package keystore menyimpan secret wallet custodial (mnemonic) terenkripsi.
+//
+// Setiap user punya data key (DEK) acak yang mengenkripsi mnemonic dengan
+// AES-GCM. DEK dibungkus (wrap) oleh master key/KEK node. Email, address dan
+// versi format ikut diautentikasi sebagai associated data.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CurrentVersion adalah versi format key file yang ditulis saat ini.
+// v1: satu key P-256 dari child 0 (legacy). v2: subkey secp256k1 per purpose (BIP-44).
+const CurrentVersion = 2
+
+// Tipe entry keystore
+const (
+	TypeMnemonic = "mnemonic" // custodial, mnemonic disimpan terenkripsi
+	TypePublic   = "public"   // non-custodial, hanya public key yang terdaftar
+)
+
+// Skema derivasi key
+const (
+	SchemeBIP44      = "bip44-secp256k1" // utils.DeriveKey
+	SchemeLegacyP256 = "legacy-p256"     // utils.LegacyPrivateKeyFromMnemonic
+)
+
+var (
+	ErrNotFound     = errors.New("keystore entry not found")
+	ErrWrongKEK     = errors.New("keystore entry was wrapped with a different master key")
+	ErrUnsupported  = errors.New("unsupported keystore version")
+	ErrAlreadyExist = errors.New("keystore entry already exists")
+	ErrNonCustodial = errors.New("wallet is non-custodial, the private key is held by the user")
+)
+
+var (
+	dir = "wallet/keystore"
+	mu  sync.Mutex
+)
+
+// KeyFile adalah format file wallet/keystore/<email>.json
+type KeyFile struct {
+	Version    int    `json:"version"`
+	Type       string `json:"type"`
+	Email      string `json:"email"`
+	Address    string `json:"address"`
+	Scheme     string `json:"scheme,omitempty"`      // kosong di v1 = SchemeLegacyP256
+	PublicKey  string `json:"public_key"`            // hex uncompressed key signing
+	KEKID      string `json:"kek_id,omitempty"`      // fingerprint master key yang membungkus DEK
+	WrappedKey string `json:"wrapped_key,omitempty"` // DEK terenkripsi oleh KEK (base64)
+	Ciphertext string `json:"ciphertext,omitempty"`  // mnemonic terenkripsi oleh DEK (base64)
+	CreatedAt  int64  `json:"created_at"`
+
+	EncryptionKey   string `json:"encryption_key,omitempty"`    // hex public key untuk enkripsi note tanpa unwrap
+	LegacyAddress   string `json:"legacy_address,omitempty"`    // address lama (P-256 child 0) agar riwayat chain tetap resolve
+	LegacyPublicKey string `json:"legacy_public_key,omitempty"` // public key lama, untuk membaca note skema lama
+}
+
+// Keys adalah subkey wallet custodial yang sudah dibuka
+type Keys struct {
+	Address    string
+	Signing    *ecdsa.PrivateKey
+	Encryption *ecdsa.PrivateKey
+	Legacy     *ecdsa.PrivateKey // key P-256 lama (child 0), sama dengan Signing untuk entry legacy
+}
+
+// StoreMnemonic mengenkripsi mnemonic dan menyimpannya untuk email
+func StoreMnemonic(email, mnemonic string) (*KeyFile, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := os.Stat(keyPath(email)); err == nil {
+		return nil, ErrAlreadyExist
+	}
+
+	kf, err := sealMnemonic(email, mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyFile(kf); err != nil {
+		return nil, err
+	}
+	return kf, nil
+}
+
+// StorePublicKey mendaftarkan wallet non-custodial (hanya public key) untuk email
+func StorePublicKey(email, publicKeyHex string) (*KeyFile, error) {
+	pub, err := utils.ParsePublicKeyHex(publicKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := os.Stat(keyPath(email)); err == nil {
+		return nil, ErrAlreadyExist
+	}
+
+	scheme := SchemeBIP44
+	if !utils.IsSecp256k1(pub) {
+		scheme = SchemeLegacyP256
+	}
+	pubHex := hex.EncodeToString(utils.SerializePublicKey(pub))
+	kf := &KeyFile{
+		Version:       CurrentVersion,
+		Type:          TypePublic,
+		Email:         email,
+		Address:       utils.PublicKeyToAddress(pub),
+		Scheme:        scheme,
+		PublicKey:     pubHex,
+		EncryptionKey: pubHex, // user non-custodial memakai satu key
+		CreatedAt:     time.Now().Unix(),
+	}
+	if err := writeKeyFile(kf); err != nil {
+		return nil, err
+	}
+	return kf, nil
+}
+
+// Custodial true jika private key dipegang node (mnemonic terenkripsi)
+func (kf *KeyFile) Custodial() bool {
+	return kf.Type == TypeMnemonic
+}
+
+// Legacy true jika key file memakai derivasi lama P-256 (mode kompatibilitas)
+func (kf *KeyFile) Legacy() bool {
+	return kf.Version < 2 || kf.Scheme == SchemeLegacyP256
+}
+
+// HasAddress true jika address adalah address utama atau address legacy entry ini
+func (kf *KeyFile) HasAddress(address string) bool {
+	return address != "" && (kf.Address == address || kf.LegacyAddress == address)
+}
+
+// Load membaca metadata key file tanpa unwrap
+func Load(email string) (*KeyFile, error) {
+	data, err := os.ReadFile(keyPath(email))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var kf KeyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("invalid key file: %v", err)
+	}
+	if kf.Version < 1 || kf.Version > CurrentVersion {
+		return nil, ErrUnsupported
+	}
+	return &kf, nil
+}
+
+// Exists true jika email punya entry di keystore
+func Exists(email string) bool {
+	_, err := os.Stat(keyPath(email))
+	return err == nil
+}
+
+// UnwrapMnemonic membuka mnemonic user. Setiap pemanggilan dicatat di audit log
+// beserta purpose-nya (mis. "sign", "decrypt-note", "export").
+func UnwrapMnemonic(email, purpose string) (string, error) {
+	kf, err := Load(email)
+	if err != nil {
+		logUnwrap(email, "", purpose, err)
+		return "", err
+	}
+
+	if !kf.Custodial() {
+		logUnwrap(email, kf.Address, purpose, ErrNonCustodial)
+		return "", ErrNonCustodial
+	}
+
+	mnemonic, err := openMnemonic(kf)
+	logUnwrap(email, kf.Address, purpose, err)
+	if err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// List mengembalikan email semua entry keystore
+func List() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var kf KeyFile
+		if err := json.Unmarshal(data, &kf); err != nil || kf.Email == "" {
+			continue
+		}
+		emails = append(emails, kf.Email)
+	}
+	return emails, nil
+}
+
+// UnwrapKeys membuka mnemonic lalu menurunkan subkey sesuai skema key file.
+// Entry legacy memakai satu key P-256 untuk signing dan enkripsi.
+func UnwrapKeys(email, purpose string) (*Keys, error) {
+	kf, err := Load(email)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err := UnwrapMnemonic(email, purpose)
+	if err != nil {
+		return nil, err
+	}
+	return deriveKeys(kf, mnemonic)
+}
+
+// Upgrade menulis ulang entry v1 ke format saat ini. Address lama tetap bisa
+// di-resolve lewat LegacyAddress.
+func Upgrade(email string) (*KeyFile, bool, error) {
+	kf, err := Load(email)
+	if err != nil {
+		return nil, false, err
+	}
+	if kf.Version == CurrentVersion {
+		return kf, false, nil
+	}
+
+	var upgraded *KeyFile
+	if kf.Custodial() {
+		mnemonic, err := UnwrapMnemonic(email, "upgrade")
+		if err != nil {
+			return nil, false, err
+		}
+		upgraded, err = sealMnemonic(email, mnemonic)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		upgraded = &KeyFile{
+			Version:       CurrentVersion,
+			Type:          TypePublic,
+			Email:         kf.Email,
+			Address:       kf.Address,
+			Scheme:        SchemeLegacyP256,
+			PublicKey:     kf.PublicKey,
+			EncryptionKey: kf.PublicKey,
+		}
+	}
+	upgraded.CreatedAt = kf.CreatedAt
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := writeKeyFile(upgraded); err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
+
+// FindByAddress mencari key file berdasarkan address wallet (termasuk address legacy)
+func FindByAddress(address string) (*KeyFile, bool) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, false
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var kf KeyFile
+		if err := json.Unmarshal(data, &kf); err != nil {
+			continue
+		}
+		if kf.HasAddress(address) {
+			return &kf, true
+		}
+	}
+	return nil, false
+}
+
+func sealMnemonic(email, mnemonic string) (*KeyFile, error) {
+	kek, err := MasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	_, pub, address := utils.PrivateKeyFromMnemonic(mnemonic)
+	if pub == nil {
+		return nil, utils.ErrInvalidDerivedKey
+	}
+	enc, err := utils.EncryptionKeyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	_, legacyPub, legacyAddress := utils.LegacyPrivateKeyFromMnemonic(mnemonic)
+
+	kf := &KeyFile{
+		Version:       CurrentVersion,
+		Type:          TypeMnemonic,
+		Email:         email,
+		Address:       address,
+		Scheme:        SchemeBIP44,
+		PublicKey:     hex.EncodeToString(utils.SerializePublicKey(pub)),
+		EncryptionKey: hex.EncodeToString(utils.SerializePublicKey(&enc.PublicKey)),
+		LegacyAddress: legacyAddress,
+		KEKID:         KEKID(kek),
+
+		LegacyPublicKey: hex.EncodeToString(utils.SerializePublicKey(legacyPub)),
+		CreatedAt:       time.Now().Unix(),
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+
+	ad := kf.associatedData()
+	ct, err := utils.EncryptDataWithAD(dek, []byte(mnemonic), ad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := utils.EncryptDataWithAD(kek, dek, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	kf.Ciphertext = base64.StdEncoding.EncodeToString(ct)
+	kf.WrappedKey = base64.StdEncoding.EncodeToString(wrapped)
+	return kf, nil
+}
+
+func openMnemonic(kf *KeyFile) (string, error) {
+	kek, err := MasterKey()
+	if err != nil {
+		return "", err
+	}
+	if kf.KEKID != KEKID(kek) {
+		return "", ErrWrongKEK
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(kf.WrappedKey)
+	if err != nil {
+		return "", err
+	}
+	ct, err := base64.StdEncoding.DecodeString(kf.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	ad := kf.associatedData()
+	dek, err := utils.DecryptDataWithAD(kek, wrapped, ad)
+	if err != nil {
+		return "", fmt.Errorf("unwrap failed: %v", err)
+	}
+	pt, err := utils.DecryptDataWithAD(dek, ct, ad)
+	if err != nil {
+		return "", fmt.Errorf("decrypt failed: %v", err)
+	}
+	return string(pt), nil
+}
+
+func deriveKeys(kf *KeyFile, mnemonic string) (*Keys, error) {
+	if kf.Legacy() {
+		priv, _, address := utils.LegacyPrivateKeyFromMnemonic(mnemonic)
+		if priv == nil || address != kf.Address {
+			return nil, fmt.Errorf("mnemonic does not match address %s", kf.Address)
+		}
+		return &Keys{Address: address, Signing: priv, Encryption: priv, Legacy: priv}, nil
+	}
+
+	signing, _, address := utils.PrivateKeyFromMnemonic(mnemonic)
+	if signing == nil || address != kf.Address {
+		return nil, fmt.Errorf("mnemonic does not match address %s", kf.Address)
+	}
+	enc, err := utils.EncryptionKeyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	legacy, _, _ := utils.LegacyPrivateKeyFromMnemonic(mnemonic)
+	return &Keys{Address: address, Signing: signing, Encryption: enc, Legacy: legacy}, nil
+}
+
+// associatedData mengikat ciphertext ke versi, tipe, (skema), email dan address
+func (kf *KeyFile) associatedData() []byte {
+	if kf.Version < 2 {
+		return []byte(fmt.Sprintf("doctracker-keystore:v%d:%s:%s:%s", kf.Version, kf.Type, kf.Email, kf.Address))
+	}
+	return []byte(fmt.Sprintf("doctracker-keystore:v%d:%s:%s:%s:%s", kf.Version, kf.Type, kf.Scheme, kf.Email, kf.Address))
+}
+
+func writeKeyFile(kf *KeyFile) error {
+	if err := utils.CreateDirIfNotExists(dir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := keyPath(kf.Email) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, keyPath(kf.Email))
+}
+
+func keyPath(email string) string {
+	// email dipakai sebagai nama file seperti wallet/mnemonic sebelumnya
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(email)
+	return filepath.Join(dir, name+".json")
+}
@@ -0,0 +1,125 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	masterKey  []byte
+	masterErr  error
+	masterOnce sync.Once
+
+	saltFile      = "data/keystore/master.salt"
+	generatedFile = "data/keystore/master.key"
+)
+
+// scrypt parameter untuk KEYSTORE_PASSPHRASE
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// MasterKey mengembalikan KEK (32 byte) untuk membungkus key per-user.
+// Urutan sumber:
+//  1. KEYSTORE_MASTER_KEY       - hex 32 byte
+//  2. KEYSTORE_MASTER_KEY_FILE  - file berisi hex atau raw 32 byte
+//  3. KEYSTORE_PASSPHRASE       - diturunkan dengan scrypt + salt di data/keystore/master.salt
+//  4. data/keystore/master.key  - dibuat otomatis untuk development
+func MasterKey() ([]byte, error) {
+	masterOnce.Do(func() {
+		masterKey, masterErr = loadMasterKey()
+	})
+	return masterKey, masterErr
+}
+
+func loadMasterKey() ([]byte, error) {
+	if v := os.Getenv("KEYSTORE_MASTER_KEY"); v != "" {
+		return decodeKey(v)
+	}
+
+	if path := os.Getenv("KEYSTORE_MASTER_KEY_FILE"); path != "" {
+		return readKeyFile(path)
+	}
+
+	if pass := os.Getenv("KEYSTORE_PASSPHRASE"); pass != "" {
+		salt, err := loadOrCreateSalt()
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key([]byte(pass), salt, scryptN, scryptR, scryptP, 32)
+	}
+
+	if _, err := os.Stat(generatedFile); err == nil {
+		return readKeyFile(generatedFile)
+	}
+
+	log.Printf("⚠️ No keystore master key configured, generating %s (set KEYSTORE_MASTER_KEY in production)", generatedFile)
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := utils.CreateDirIfNotExists("data/keystore"); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(generatedFile, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save master key: %v", err)
+	}
+	return key, nil
+}
+
+// KEKID adalah fingerprint master key, disimpan di key file untuk deteksi salah key
+func KEKID(kek []byte) string {
+	sum := sha256.Sum256(append([]byte("doctracker-kek:"), kek...))
+	return hex.EncodeToString(sum[:8])
+}
+
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %v", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	return decodeKey(string(data))
+}
+
+func decodeKey(v string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key hex: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, errors.New("master key must be 32 bytes")
+	}
+	return key, nil
+}
+
+func loadOrCreateSalt() ([]byte, error) {
+	if salt, err := os.ReadFile(saltFile); err == nil {
+		return salt, nil
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if err := utils.CreateDirIfNotExists("data/keystore"); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(saltFile, salt, 0600); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
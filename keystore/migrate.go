@@ -0,0 +1,90 @@
+package keystore
+
+import (
+	"doc-tracker/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LegacyDir adalah lokasi lama mnemonic plaintext (wallet/mnemonic/<email>.txt)
+const LegacyDir = "wallet/mnemonic"
+
+// MigrateResult ringkasan hasil import mnemonic plaintext
+type MigrateResult struct {
+	Imported []string
+	Skipped  []string
+	Failed   map[string]error
+}
+
+// ImportLegacyFile mengimpor satu file mnemonic plaintext ke keystore.
+// File lama dihapus jika remove=true dan import berhasil.
+func ImportLegacyFile(path string, remove bool) (*KeyFile, error) {
+	email := strings.TrimSuffix(filepath.Base(path), ".txt")
+	if email == "" {
+		return nil, fmt.Errorf("empty email in file name %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic := strings.TrimSpace(string(data))
+	if !utils.IsValidMnemonic(mnemonic) {
+		return nil, fmt.Errorf("invalid mnemonic in %s", path)
+	}
+
+	kf, err := StoreMnemonic(email, mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	if remove {
+		if err := os.Remove(path); err != nil {
+			return kf, fmt.Errorf("imported but failed to remove plaintext file: %v", err)
+		}
+	}
+	return kf, nil
+}
+
+// MigrateLegacyDir mengimpor semua *.txt di legacyDir ke keystore
+func MigrateLegacyDir(legacyDir string, remove bool) (MigrateResult, error) {
+	result := MigrateResult{Failed: map[string]error{}}
+
+	files, err := filepath.Glob(filepath.Join(legacyDir, "*.txt"))
+	if err != nil {
+		return result, err
+	}
+
+	for _, f := range files {
+		email := strings.TrimSuffix(filepath.Base(f), ".txt")
+		if Exists(email) {
+			result.Skipped = append(result.Skipped, email)
+			if remove && sameAddress(email, f) {
+				os.Remove(f)
+			}
+			continue
+		}
+		if _, err := ImportLegacyFile(f, remove); err != nil {
+			result.Failed[email] = err
+			continue
+		}
+		result.Imported = append(result.Imported, email)
+	}
+	return result, nil
+}
+
+// sameAddress true jika mnemonic di file legacy menghasilkan address (legacy) yang sama dengan keystore
+func sameAddress(email, path string) bool {
+	kf, err := Load(email)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, _, legacy := utils.LegacyPrivateKeyFromMnemonic(strings.TrimSpace(string(data)))
+	return kf.HasAddress(legacy)
+}
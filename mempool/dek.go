@@ -0,0 +1,186 @@
+package mempool
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"doc-tracker/keymanager"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format mempool.bin v2:
+//
+//	"DTMP" | version | uint16 len(wrappedDEK) | wrappedDEK | nonce | AES-GCM(payload)
+//
+// Payload dienkripsi dengan DEK acak; DEK dibungkus key storage node
+// (keymanager). Rotasi key node cukup membungkus ulang DEK tanpa menyentuh payload.
+const fileVersion byte = 2
+
+var (
+	fileMagic = []byte("DTMP")
+	dekAD     = []byte("doctracker-mempool-dek:v2")
+
+	ErrInvalidFile = errors.New("invalid encrypted mempool file")
+)
+
+// dekCache menyimpan DEK yang sudah dibuka agar tidak unwrap di setiap save
+var dekCache struct {
+	key     []byte
+	wrapped []byte
+}
+
+type sealedFile struct {
+	wrapped []byte
+	nonce   []byte
+	payload []byte
+}
+
+func isSealedFile(data []byte) bool {
+	return len(data) > len(fileMagic) && bytes.Equal(data[:len(fileMagic)], fileMagic)
+}
+
+// header mengikat payload ke magic dan versi file lewat associated data
+func header() []byte {
+	return append(append([]byte{}, fileMagic...), fileVersion)
+}
+
+func payloadAD() []byte {
+	return append(header(), mempoolAD...)
+}
+
+func parseSealedFile(data []byte) (sealedFile, error) {
+	h := header()
+	if len(data) < len(h)+2 || !bytes.Equal(data[:len(h)], h) {
+		return sealedFile{}, ErrInvalidFile
+	}
+	rest := data[len(h):]
+	n := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < n+12 {
+		return sealedFile{}, ErrInvalidFile
+	}
+	return sealedFile{wrapped: rest[:n], nonce: rest[n : n+12], payload: rest[n+12:]}, nil
+}
+
+func (f sealedFile) bytes() []byte {
+	out := header()
+	out = binary.BigEndian.AppendUint16(out, uint16(len(f.wrapped)))
+	out = append(out, f.wrapped...)
+	out = append(out, f.nonce...)
+	return append(out, f.payload...)
+}
+
+// currentDEK mengembalikan DEK dari cache, atau membuat DEK baru. DEK cache
+// dibungkus ulang jika key storage sudah dirotasi (mis. oleh `doctracker keys
+// rotate` di proses lain) agar file tidak ditulis dengan key lama.
+func currentDEK() ([]byte, []byte, error) {
+	if dekCache.key != nil {
+		if keymanager.IsCurrent(dekCache.wrapped) {
+			return dekCache.key, dekCache.wrapped, nil
+		}
+		wrapped, err := keymanager.Encrypt(dekCache.key, dekAD)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to wrap mempool key: %v", err)
+		}
+		dekCache.wrapped = wrapped
+		return dekCache.key, wrapped, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := keymanager.Encrypt(key, dekAD)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap mempool key: %v", err)
+	}
+	dekCache.key, dekCache.wrapped = key, wrapped
+	return key, wrapped, nil
+}
+
+// unwrapDEK membuka DEK file dan menyimpannya ke cache
+func unwrapDEK(wrapped []byte) ([]byte, error) {
+	if dekCache.key != nil && bytes.Equal(dekCache.wrapped, wrapped) {
+		return dekCache.key, nil
+	}
+	key, err := keymanager.Decrypt(wrapped, dekAD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap mempool key: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, ErrInvalidFile
+	}
+	dekCache.key, dekCache.wrapped = key, append([]byte{}, wrapped...)
+	return key, nil
+}
+
+func sealMempool(plaintext []byte) ([]byte, error) {
+	key, wrapped, err := currentDEK()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	f := sealedFile{wrapped: wrapped, nonce: nonce, payload: gcm.Seal(nil, nonce, plaintext, payloadAD())}
+	return f.bytes(), nil
+}
+
+func openMempool(data []byte) ([]byte, error) {
+	f, err := parseSealedFile(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := unwrapDEK(f.wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, f.nonce, f.payload, payloadAD())
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %v", err)
+	}
+	return plaintext, nil
+}
+
+// rewrapDEK membungkus ulang DEK dengan key storage aktif; payload tidak berubah
+func rewrapDEK(data []byte) ([]byte, bool, error) {
+	f, err := parseSealedFile(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if keymanager.IsCurrent(f.wrapped) {
+		return data, false, nil
+	}
+	key, err := unwrapDEK(f.wrapped)
+	if err != nil {
+		return nil, false, err
+	}
+	wrapped, err := keymanager.Encrypt(key, dekAD)
+	if err != nil {
+		return nil, false, err
+	}
+	dekCache.key, dekCache.wrapped = key, wrapped
+	f.wrapped = wrapped
+	return f.bytes(), true, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
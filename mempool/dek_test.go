@@ -0,0 +1,189 @@
+package mempool
+
+import (
+	"bytes"
+	"doc-tracker/keymanager"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Key node dari token software di direktori sementara
+	dir, err := os.MkdirTemp("", "doctracker-keys")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("KEYMANAGER_SOURCE", "file")
+	os.Setenv("KEYMANAGER_DIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// resetDEKCache memaksa DEK dibuka ulang dari file, seperti node yang baru start
+func resetDEKCache() {
+	dekCache.key, dekCache.wrapped = nil, nil
+}
+
+var samplePayload = []byte(`[{"id":"trk-1","status":"pending"}]`)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	resetDEKCache()
+	data, err := sealMempool(samplePayload)
+	if err != nil {
+		t.Fatalf("sealMempool: %v", err)
+	}
+	if !isSealedFile(data) || data[len(fileMagic)] != fileVersion {
+		t.Fatalf("sealed file has no DTMP v%d header", fileVersion)
+	}
+	if bytes.Contains(data, samplePayload) {
+		t.Fatal("sealed file contains the plaintext payload")
+	}
+
+	for _, cached := range []bool{true, false} {
+		if !cached {
+			resetDEKCache()
+		}
+		got, err := openMempool(data)
+		if err != nil {
+			t.Fatalf("openMempool (cached DEK %v): %v", cached, err)
+		}
+		if !bytes.Equal(got, samplePayload) {
+			t.Fatalf("openMempool = %q, want %q", got, samplePayload)
+		}
+	}
+}
+
+func TestOpenMempoolRejectsTampering(t *testing.T) {
+	resetDEKCache()
+	data, err := sealMempool(samplePayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseSealedFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappedAt := len(header()) + 2
+
+	cases := []struct {
+		name    string
+		tamper  func(b []byte) []byte
+		wantErr error
+	}{
+		{"payload byte flipped", func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }, nil},
+		{"nonce byte flipped", func(b []byte) []byte { b[wrappedAt+len(f.wrapped)] ^= 0xff; return b }, nil},
+		{"wrapped DEK byte flipped", func(b []byte) []byte { b[wrappedAt+len(f.wrapped)-1] ^= 0xff; return b }, nil},
+		{"version changed", func(b []byte) []byte { b[len(fileMagic)] = 1; return b }, ErrInvalidFile},
+		{"magic changed", func(b []byte) []byte { b[0] = 'X'; return b }, ErrInvalidFile},
+		{"truncated", func(b []byte) []byte { return b[:wrappedAt+len(f.wrapped)+4] }, ErrInvalidFile},
+		{"wrapped DEK length too long", func(b []byte) []byte { b[wrappedAt-2] = 0xff; return b }, ErrInvalidFile},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetDEKCache()
+			_, err := openMempool(tc.tamper(append([]byte{}, data...)))
+			if err == nil {
+				t.Fatal("openMempool accepted a tampered file")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("openMempool err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrappedDEKBoundToMempool(t *testing.T) {
+	resetDEKCache()
+	data, err := sealMempool(samplePayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseSealedFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// DEK dengan AD lain (mis. blob keymanager dari konteks lain) tidak boleh diterima
+	foreign, err := keymanager.Encrypt(bytes.Repeat([]byte{1}, 32), []byte("doctracker-block-body:1:x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.wrapped = foreign
+	resetDEKCache()
+	if _, err := openMempool(f.bytes()); err == nil {
+		t.Fatal("openMempool accepted a DEK wrapped with another associated data")
+	}
+}
+
+func TestDEKRewrapAfterRotation(t *testing.T) {
+	resetDEKCache()
+	old, err := sealMempool(samplePayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldFile, err := parseSealedFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, err := keymanager.KeyIDOf(oldFile.wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keymanager.Rotate(keymanager.PurposeStorage); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// DEK di cache dibungkus ulang sebelum file berikutnya ditulis
+	saved, err := sealMempool(samplePayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedFile, err := parseSealedFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keymanager.IsCurrent(savedFile.wrapped) {
+		t.Fatal("sealMempool wrote the DEK with the rotated-out key")
+	}
+
+	resetDEKCache()
+	rewrapped, changed, err := rewrapDEK(old)
+	if err != nil {
+		t.Fatalf("rewrapDEK: %v", err)
+	}
+	if !changed {
+		t.Fatal("rewrapDEK reported no change for a DEK under the old key")
+	}
+	newFile, err := parseSealedFile(rewrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keymanager.IsCurrent(newFile.wrapped) {
+		t.Fatal("rewrapDEK did not use the current storage key")
+	}
+	if !bytes.Equal(newFile.payload, oldFile.payload) || !bytes.Equal(newFile.nonce, oldFile.nonce) {
+		t.Fatal("rewrapDEK changed the payload")
+	}
+	if again, changed, err := rewrapDEK(rewrapped); err != nil || changed || !bytes.Equal(again, rewrapped) {
+		t.Fatalf("rewrapDEK on a current file = changed %v, err %v; want unchanged", changed, err)
+	}
+
+	if err := keymanager.Retire(oldID); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	resetDEKCache()
+	got, err := openMempool(rewrapped)
+	if err != nil {
+		t.Fatalf("openMempool after retire: %v", err)
+	}
+	if !bytes.Equal(got, samplePayload) {
+		t.Fatalf("openMempool = %q, want %q", got, samplePayload)
+	}
+	resetDEKCache()
+	if _, err := openMempool(old); err == nil {
+		t.Fatal("file wrapped with a retired key still opens")
+	}
+}
@@ -2,6 +2,7 @@
 package mempool
 
 import (
+	"doc-tracker/keymanager"
 	"doc-tracker/models"
 	"doc-tracker/utils"
 	"encoding/json"
@@ -21,37 +22,10 @@ var (
 	mu           sync.RWMutex
 	jsonFilePath = "data/mempool.json"
 	binFilePath  = "data/mempool.bin"
-	pubKeyPath   = "data/public.key"
-	privKeyPath  = "data/private.key"
 )
 
-// InitKeys inisialisasi atau load kunci
-func InitKeys() (*utils.ECDHKeyPair, error) {
-	// Jika kunci sudah ada, load dari file
-	if _, err := os.Stat(pubKeyPath); err == nil {
-		return utils.LoadKeys()
-	}
-
-	// Generate new key pair
-	keyPair, err := utils.GenerateECDHKeyPair()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate keys: %v", err)
-	}
-
-	// Simpan public key
-	pubKeyBytes := utils.SerializePublicKey(keyPair.PublicKey)
-	if err := os.WriteFile(pubKeyPath, pubKeyBytes, 0600); err != nil {
-		return nil, fmt.Errorf("failed to save public key: %v", err)
-	}
-
-	// Simpan private key
-	privKeyBytes := keyPair.PrivateKey.Bytes()
-	if err := os.WriteFile(privKeyPath, privKeyBytes, 0600); err != nil {
-		return nil, fmt.Errorf("failed to save private key: %v", err)
-	}
-
-	return keyPair, nil
-}
+// mempoolAD mengikat ciphertext ke file mempool
+var mempoolAD = []byte("doctracker-mempool")
 
 // InitEncryptMempool migrasi ke mempool terenkripsi
 func InitEncryptMempool() error {
@@ -72,34 +46,21 @@ func InitEncryptMempool() error {
 
 	log.Println("🔄 Encrypting mempool...")
 
-	// Load kunci
-	keyPair, err := utils.LoadKeys()
-	if err != nil {
-		return fmt.Errorf("failed to load keys: %v", err)
-	}
-
 	// Baca data plaintext
 	plaintext, err := os.ReadFile(jsonFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to read mempool: %v", err)
 	}
 
-	// Enkripsi data
-	// Untuk demo, kita gunakan kunci public sendiri sebagai peer
-	sharedSecret, err := utils.DeriveSharedSecret(keyPair.PrivateKey, keyPair.PublicKey)
-	if err != nil {
-		return fmt.Errorf("failed to derive secret: %v", err)
-	}
-
-	encKey := utils.GenerateEncryptionKey(sharedSecret)
-	ciphertext, err := utils.EncryptData(encKey, plaintext)
+	// Enkripsi dengan DEK yang dibungkus key storage node
+	ciphertext, err := sealMempool(plaintext)
 	if err != nil {
 		return fmt.Errorf("encryption failed: %v", err)
 	}
 
 	// Simpan versi terenkripsi
-	if err := os.WriteFile(binFilePath, ciphertext, 0600); err != nil {
-		return fmt.Errorf("failed to save encrypted mempool: %v", err)
+	if err := writeMempoolFile(ciphertext); err != nil {
+		return err
 	}
 
 	// Hapus file plaintext (opsional)
@@ -118,28 +79,15 @@ func LoadFromFile() error {
 
 	// Coba load dari file terenkripsi dulu
 	if _, err := os.Stat(binFilePath); err == nil {
-		// Load kunci
-		keyPair, err := utils.LoadKeys()
-		if err != nil {
-			return fmt.Errorf("failed to load keys: %v", err)
-		}
-
 		// Baca data terenkripsi
 		ciphertext, err := os.ReadFile(binFilePath)
 		if err != nil {
 			return fmt.Errorf("failed to read encrypted mempool: %v", err)
 		}
 
-		// Dekripsi data
-		sharedSecret, err := utils.DeriveSharedSecret(keyPair.PrivateKey, keyPair.PublicKey)
+		plaintext, err := decryptMempool(ciphertext)
 		if err != nil {
-			return fmt.Errorf("failed to derive secret: %v", err)
-		}
-
-		encKey := utils.GenerateEncryptionKey(sharedSecret)
-		plaintext, err := utils.DecryptData(encKey, ciphertext)
-		if err != nil {
-			return fmt.Errorf("decryption failed: %v", err)
+			return err
 		}
 
 		// Parse JSON
@@ -189,30 +137,86 @@ func SaveToFile() error {
 		return fmt.Errorf("failed to marshal mempool: %v", err)
 	}
 
-	// Load kunci
-	keyPair, err := utils.LoadKeys()
+	// Enkripsi dengan DEK (cache), tanpa memuat key dari disk di setiap save
+	ciphertext, err := sealMempool(data)
 	if err != nil {
-		return fmt.Errorf("failed to load keys: %v", err)
+		return fmt.Errorf("encryption failed: %v", err)
 	}
 
-	// Enkripsi data
-	sharedSecret, err := utils.DeriveSharedSecret(keyPair.PrivateKey, keyPair.PublicKey)
+	return writeMempoolFile(ciphertext)
+}
+
+// Reencrypt membungkus ulang DEK mempool.bin dengan key storage aktif tanpa
+// menulis ulang payload. File format lama dienkripsi ulang ke format DEK.
+// Mengembalikan false jika file sudah memakai key aktif atau belum ada.
+func Reencrypt() (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	data, err := os.ReadFile(binFilePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to derive secret: %v", err)
+		return false, err
 	}
 
-	encKey := utils.GenerateEncryptionKey(sharedSecret)
-	ciphertext, err := utils.EncryptData(encKey, data)
+	if isSealedFile(data) {
+		rewrapped, changed, err := rewrapDEK(data)
+		if err != nil || !changed {
+			return false, err
+		}
+		return true, writeMempoolFile(rewrapped)
+	}
+
+	plaintext, err := decryptMempool(data)
 	if err != nil {
-		return fmt.Errorf("encryption failed: %v", err)
+		return false, err
 	}
+	if data, err = sealMempool(plaintext); err != nil {
+		return false, err
+	}
+	return true, writeMempoolFile(data)
+}
 
-	// Simpan ke file
-	if err := os.WriteFile(binFilePath, ciphertext, 0600); err != nil {
-		return fmt.Errorf("failed to save encrypted mempool: %v", err)
+// decryptMempool membuka mempool.bin. Selain format DEK, format lama tetap
+// didukung agar node lama bisa bermigrasi: blob keymanager langsung dan ECDH
+// key node dengan dirinya sendiri (data/private.key).
+func decryptMempool(ciphertext []byte) ([]byte, error) {
+	if isSealedFile(ciphertext) {
+		return openMempool(ciphertext)
 	}
 
-	return nil
+	log.Println("🟡 Mempool uses a legacy encryption format, it will be re-encrypted on next save")
+	if keymanager.IsManaged(ciphertext) {
+		plaintext, err := keymanager.Decrypt(ciphertext, mempoolAD)
+		if err != nil {
+			return nil, fmt.Errorf("decryption failed: %v", err)
+		}
+		return plaintext, nil
+	}
+
+	keyPair, err := utils.LoadKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load legacy keys: %v", err)
+	}
+	sharedSecret, err := utils.DeriveSharedSecret(keyPair.PrivateKey, keyPair.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive secret: %v", err)
+	}
+	plaintext, err := utils.DecryptData(utils.GenerateEncryptionKey(sharedSecret), ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %v", err)
+	}
+	return plaintext, nil
+}
+
+func writeMempoolFile(ciphertext []byte) error {
+	tmp := binFilePath + ".tmp"
+	if err := os.WriteFile(tmp, ciphertext, 0600); err != nil {
+		return fmt.Errorf("failed to save encrypted mempool: %v", err)
+	}
+	return os.Rename(tmp, binFilePath)
 }
 
 // [Fungsi-fungsi manajemen mempool yang sama seperti sebelumnya...]
@@ -331,10 +335,10 @@ func Iterate(fn func(tx *models.Tracker) error, email_login string) error {
 }
 
 func UpdateTracker(tracker *models.Tracker) {
+	// SaveToFile mengambil lock sendiri, jangan dipanggil saat mu dipegang
 	mu.Lock()
-	defer mu.Unlock()
-
 	mempool[tracker.ID] = tracker
+	mu.Unlock()
 
 	if err := SaveToFile(); err != nil {
 		fmt.Printf("❌ Gagal simpan mempool: %v\n", err)
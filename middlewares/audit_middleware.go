@@ -0,0 +1,211 @@
+package middlewares
+
+import (
+	"doc-tracker/services"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditActions nama aksi untuk route yang dikenal; route lain dicatat sebagai
+// "METHOD /route/:param" atau "METHOD /path"
+var auditActions = map[string]string{
+	// auth
+	"POST /api/auth/login":               "auth.login.mnemonic",
+	"POST /api/auth/challenge":           "auth.challenge",
+	"POST /api/auth/verify-signature":    "auth.login.signature",
+	"POST /api/auth/logout":              "auth.logout",
+	"POST /api/auth/request-otp":         "auth.otp.request",
+	"POST /api/auth/verify-otp":          "auth.login.otp",
+	"POST /api/auth/me":                  "auth.me",
+	"GET /api/auth/qr/:address":          "auth.qr",
+	"GET /api/auth/totp/status":          "auth.totp.status",
+	"POST /api/auth/totp/enroll":         "auth.totp.enroll",
+	"POST /api/auth/totp/activate":       "auth.totp.activate",
+	"POST /api/auth/totp/recovery-codes": "auth.totp.recovery_codes",
+	"POST /api/auth/totp/disable":        "auth.totp.disable",
+	// wallet
+	"GET /api/wallet/":          "wallet.view",
+	"POST /api/wallet/export":   "wallet.export",
+	"POST /api/wallet/import":   "wallet.import",
+	"POST /api/wallet/register": "wallet.register",
+	// tracker, checkpoint dan note
+	"GET /api/trackers/":                     "tracker.list",
+	"GET /api/tracker/:id":                   "tracker.view",
+	"GET /api/tracker/address/:address":      "tracker.list_by_address",
+	"POST /api/tracker/create":               "tracker.create",
+	"GET /api/tracker/summary/:email":        "tracker.summary",
+	"GET /api/tracker/:id/content":           "tracker.content.decrypt",
+	"GET /api/tracker/:id/attachments/:name": "tracker.attachment.download",
+	"POST /api/tracker/:id/viewers":          "tracker.viewer.grant",
+	"DELETE /api/tracker/:id/viewers/:email": "tracker.viewer.revoke",
+	"GET /api/tracker/:id/thumbnails":        "tracker.thumbnails",
+	"GET /api/tracker/:id/label":             "tracker.label",
+	"GET /api/tracker/:id/certificate.pdf":   "tracker.certificate",
+	"GET /api/verify/:id":                    "tracker.verify",
+	"POST /api/checkpoint/complete":          "checkpoint.complete",
+	"POST /api/decrypt-note":                 "note.decrypt",
+	// template tracker
+	"GET /api/templates/":              "template.list",
+	"POST /api/templates/":             "template.create",
+	"GET /api/templates/:id":           "template.view",
+	"PUT /api/templates/:id":           "template.update",
+	"DELETE /api/templates/:id":        "template.delete",
+	"POST /api/templates/:id/preview":  "template.preview",
+	"POST /api/templates/:id/trackers": "tracker.create_from_template",
+	"GET /api/org/managers":            "org.managers.view",
+	"PUT /api/org/managers":            "org.managers.update",
+	// evidence
+	"POST /api/upload":                        "evidence.upload",
+	"GET /api/evidence/verify/:id":            "evidence.verify",
+	"GET /api/evidence/audit":                 "evidence.audit.view",
+	"POST /api/evidence/audit":                "evidence.audit.run",
+	"POST /api/evidence/uploads":              "evidence.upload.create",
+	"GET /api/evidence/uploads/:id":           "evidence.upload.status",
+	"PATCH /api/evidence/uploads/:id":         "evidence.upload.chunk",
+	"POST /api/evidence/uploads/:id/complete": "evidence.upload.complete",
+	"DELETE /api/evidence/uploads/:id":        "evidence.upload.abort",
+	"GET /api/evidence/url":                   "evidence.url",
+	"GET /api/evidence/metadata":              "evidence.metadata",
+	"POST /api/evidence/tickets":              "evidence.ticket.create",
+	"POST /api/evidence/tickets/:id/complete": "evidence.ticket.complete",
+	"DELETE /api/evidence/tickets/:id":        "evidence.ticket.abort",
+	"GET /api/evidence/files/:hash":           "evidence.download.signed",
+	"PUT /api/evidence/files/staging/:id":     "evidence.upload.signed",
+	"GET /evidence/view":                      "evidence.download",
+	"GET /evidence/thumbnail":                 "evidence.thumbnail",
+	// dokumen dan audit log
+	"POST /api/documents/fingerprint": "document.fingerprint",
+	"GET /api/documents/verify":       "document.verify",
+	"POST /api/documents/verify":      "document.verify",
+	"GET /api/audit/logs":             "audit.query",
+	"GET /api/audit/logs/export":      "audit.export",
+	"GET /api/audit/verify":           "audit.verify",
+	"GET /api/audit/head":             "audit.head",
+	"POST /api/audit/anchor":          "audit.anchor",
+	// sync antar node
+	"POST /api/sync/block":    "sync.block.receive",
+	"GET /api/blocks":         "block.list",
+	"GET /api/blocks/headers": "block.headers",
+	"POST /p2p/block":         "sync.block.push",
+	"POST /p2p/mempool":       "sync.mempool.push",
+	"GET /p2p/mempool":        "sync.mempool",
+	"GET /p2p/snapshot":       "sync.snapshot",
+	"GET /p2p/blocks":         "sync.blocks",
+	"GET /sync/manual":        "sync.manual",
+	"POST /sync/chain":        "sync.chain",
+	"POST /mine":              "miner.mine",
+	"GET /chain":              "block.chain",
+}
+
+// auditQueryResources query yang menunjuk resource (mis. hash evidence)
+var auditQueryResources = []string{"tracker_id", "checkpoint_address", "hash"}
+
+// Audit mencatat setiap request ke audit log berantai hash setelah handler
+// selesai. Path dengan prefix di AUDIT_SKIP_PREFIXES (dipisah koma, default
+// swagger dan polling p2p/latest-block) tidak dicatat.
+func Audit() fiber.Handler {
+	skip := []string{"/swagger", "/p2p/latest-block"}
+	if v, ok := os.LookupEnv("AUDIT_SKIP_PREFIXES"); ok {
+		skip = nil
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				skip = append(skip, p)
+			}
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		for _, p := range skip {
+			if strings.HasPrefix(c.Path(), p) {
+				return c.Next()
+			}
+		}
+		if c.Method() == fiber.MethodOptions {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		entry := services.AuditLogEntry{
+			Method:     c.Method(),
+			Path:       c.Path(),
+			IP:         c.IP(),
+			UserAgent:  c.Get(fiber.HeaderUserAgent),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				status = fe.Code
+			}
+			entry.Error = err.Error()
+		}
+		entry.Status = status
+		switch {
+		case status == fiber.StatusUnauthorized || status == fiber.StatusForbidden:
+			entry.Result = services.AuditDenied
+		case status >= 400:
+			entry.Result = services.AuditFailure
+		default:
+			entry.Result = services.AuditSuccess
+		}
+
+		route := c.Method() + " " + c.Route().Path
+		entry.Action = auditActions[route]
+		if entry.Action == "" && len(c.Route().Params) > 0 {
+			entry.Action = route
+		} else if entry.Action == "" {
+			// Ditolak middleware (mis. JWT) atau 404: route belum diketahui
+			entry.Action = c.Method() + " " + c.Path()
+		}
+
+		actor, resource := services.AuditLocals(c)
+		if email, _ := services.GetLoginEmail(c); email != "" {
+			actor = email
+		}
+		entry.Actor = actor
+		if entry.Actor == "" {
+			entry.Actor = "anonymous"
+		}
+		entry.Resource = auditResource(c, resource)
+
+		services.RecordAudit(entry)
+		return err
+	}
+}
+
+// auditResource menggabungkan param route, query resource dan resource dari
+// controller menjadi "key=value" dipisah spasi
+func auditResource(c *fiber.Ctx, fromBody string) string {
+	var parts []string
+	params := c.AllParams()
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "*" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if params[k] != "" {
+			parts = append(parts, k+"="+params[k])
+		}
+	}
+	for _, k := range auditQueryResources {
+		if v := c.Query(k); v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	if fromBody != "" {
+		parts = append(parts, fromBody)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit menolak body lebih besar dari limit. Dengan StreamRequestBody body
+// besar tidak lagi ditolak fasthttp, jadi batas dipasang di sini; path upload
+// streaming (streamingPrefixes) membatasi ukurannya sendiri.
+func BodyLimit(limit int, streamingPrefixes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, prefix := range streamingPrefixes {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		length := c.Request().Header.ContentLength()
+		if length > limit || (length < 0 && c.Request().IsBodyStream()) {
+			c.Context().SetConnectionClose()
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, "Request body too large")
+		}
+		return c.Next()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
@@ -38,5 +39,12 @@ func JWTMiddleware(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).SendString("Invalid or expired token")
 	}
 
+	// Token enrollment TOTP hanya boleh dipakai untuk menyelesaikan enrollment
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if enroll, _ := claims["mfa_enroll"].(bool); enroll && !strings.HasPrefix(c.Path(), "/api/auth/totp") {
+			return c.Status(fiber.StatusForbidden).SendString("TOTP enrollment required")
+		}
+	}
+
 	return c.Next()
 }
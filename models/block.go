@@ -5,7 +5,22 @@ type Block struct {
 	Timestamp    int64     `json:"timestamp"`
 	PrevHash     string    `json:"prev_hash"`
 	Hash         string    `json:"hash"`
+	MerkleRoot   string    `json:"merkle_root,omitempty"` // kosong untuk block format lama
 	Nonce        int       `json:"nonce"`
 	Transactions []Tracker `json:"transactions"`
 	Encrypted    bool      `json:"encrypted"` // Menandakan apakah block terenkripsi
 }
+
+// BlockHeader adalah bagian block yang disimpan plaintext dan bisa diverifikasi
+// tanpa key node. Legacy=true berarti hash block dihitung dari transaksi
+// (format lama) sehingga hanya link prev hash yang bisa dicek dari header.
+type BlockHeader struct {
+	Index      int    `json:"index"`
+	Timestamp  int64  `json:"timestamp"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"`
+	MerkleRoot string `json:"merkle_root"`
+	Nonce      int    `json:"nonce"`
+	TxCount    int    `json:"tx_count"`
+	Legacy     bool   `json:"legacy,omitempty"`
+}
@@ -5,6 +5,12 @@ type OtpRequest struct {
 }
 
 type VerifyOtpRequest struct {
-	Email string `json:"email"`
-	Otp   string `json:"otp"`
+	Email        string `json:"email"`
+	Otp          string `json:"otp"`
+	Totp         string `json:"totp,omitempty"`          // wajib jika user sudah aktifkan TOTP
+	RecoveryCode string `json:"recovery_code,omitempty"` // alternatif jika authenticator hilang
+}
+
+type TotpCodeRequest struct {
+	Code string `json:"code"`
 }
@@ -8,9 +8,37 @@ type Tracker struct {
 	CreatorAddr    string            `json:"creator_address"`
 	CreatedAt      int64             `json:"created_at"`
 	Checkpoints    []Checkpoint      `json:"checkpoints"`
-	TargetEnd      string            `json:"target_end"` // self / email / address
-	Status         string            `json:"status"`     // pending, progress, complete
-	EncryptedNotes map[string]string `json:"encrypted_notes,omitempty"`
+	TargetEnd      string            `json:"target_end"`                // self / email / address
+	Status         string            `json:"status"`                    // pending, progress, complete
+	EncryptedNotes map[string]string `json:"encrypted_notes,omitempty"` // address -> content key terbungkus (ECIES)
+
+	Note             string       `json:"note,omitempty"`              // input, dikosongkan setelah dienkripsi
+	EncryptedContent string       `json:"encrypted_content,omitempty"` // note terenkripsi dengan content key
+	Attachments      []Attachment `json:"attachments,omitempty"`
+
+	Document *DocumentFingerprint `json:"document,omitempty"` // dokumen yang dilacak, didaftarkan saat tracker dibuat
+	Template string               `json:"template,omitempty"` // ID template jika dibuat dari template
+}
+
+// DocumentFingerprint sidik jari dokumen yang dilacak tracker. Hanya hash yang
+// dicatat on-chain, isi dokumen tidak pernah disimpan node.
+type DocumentFingerprint struct {
+	Hash         string   `json:"hash"` // sha256 file dokumen
+	Name         string   `json:"name,omitempty"`
+	ContentType  string   `json:"content_type,omitempty"`
+	Size         int64    `json:"size,omitempty"`
+	PageHashes   []string `json:"page_hashes,omitempty"` // sha256 per halaman, urut dari halaman 1
+	RegisteredAt int64    `json:"registered_at,omitempty"`
+}
+
+// Attachment dienkripsi dengan content key tracker yang sama dengan note
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash"`           // sha256 plaintext
+	Data        string `json:"data,omitempty"` // input base64, dikosongkan setelah dienkripsi
+	Ciphertext  string `json:"ciphertext,omitempty"`
 }
 
 type Checkpoint struct {
@@ -23,11 +51,50 @@ type Checkpoint struct {
 	EncryptedNote string `json:"encrypted_note"`
 	Address       string `json:"address"` // auto-generated
 
-	EvidenceHash string `json:"evidence_hash,omitempty"`
-	EvidencePath string `json:"evidence_path,omitempty"`
+	// Format lama (satu evidence per checkpoint), hanya dibaca dari block dan
+	// mempool lama; evidence baru dicatat di Evidence. Pakai EvidenceItems().
+	EvidenceHash string            `json:"evidence_hash,omitempty"` // sha256 plaintext
+	EvidencePath string            `json:"evidence_path,omitempty"`
+	EvidenceKeys map[string]string `json:"evidence_keys,omitempty"` // address -> key evidence terbungkus (ECIES), kosong jika evidence tidak dienkripsi
+	EvidenceType string            `json:"evidence_type,omitempty"` // MIME hasil sniff isi file
+	EvidenceSize int64             `json:"evidence_size,omitempty"` // ukuran plaintext (byte)
+
+	Evidence []EvidenceItem `json:"evidence,omitempty"`
 
 	IsCompleted bool  `json:"is_completed"`
 	CompletedAt int64 `json:"completed_at,omitempty"`
+	DueAt       int64 `json:"due_at,omitempty"` // batas waktu (unix), 0 = tanpa deadline
+}
+
+// EvidenceItem satu file evidence checkpoint, mis. halaman bertanda tangan,
+// foto serah terima atau kwitansi
+type EvidenceItem struct {
+	Hash        string            `json:"hash"`                   // sha256 plaintext
+	Path        string            `json:"path"`                   // evidence.Ref object di store
+	ContentType string            `json:"content_type,omitempty"` // MIME hasil sniff isi file
+	Size        int64             `json:"size,omitempty"`         // ukuran plaintext (byte)
+	Label       string            `json:"label,omitempty"`
+	Uploader    string            `json:"uploader,omitempty"` // email pengunggah
+	UploadedAt  int64             `json:"uploaded_at,omitempty"`
+	Keys        map[string]string `json:"keys,omitempty"` // address -> key evidence terbungkus (ECIES), kosong jika tidak dienkripsi
+}
+
+// EvidenceItems mengembalikan daftar evidence checkpoint, termasuk evidence
+// format lama yang tersimpan di field tunggal
+func (cp Checkpoint) EvidenceItems() []EvidenceItem {
+	if cp.EvidenceHash == "" {
+		return cp.Evidence
+	}
+	legacy := EvidenceItem{
+		Hash:        cp.EvidenceHash,
+		Path:        cp.EvidencePath,
+		ContentType: cp.EvidenceType,
+		Size:        cp.EvidenceSize,
+		Uploader:    cp.Email,
+		UploadedAt:  cp.CompletedAt,
+		Keys:        cp.EvidenceKeys,
+	}
+	return append([]EvidenceItem{legacy}, cp.Evidence...)
 }
 
 type CheckpointStatusInput struct {
@@ -35,6 +102,15 @@ type CheckpointStatusInput struct {
 	Email     string  `json:"email"`
 	Note      *string `json:"note"`
 	Evidence  *string `json:"evidence"` // base64 encoded image
+
+	// Evidence tambahan (mis. foto serah terima, kwitansi), boleh tanpa Evidence
+	EvidenceItems []EvidenceUpload `json:"evidence_items,omitempty"`
+}
+
+// EvidenceUpload satu evidence base64 (boleh data URL) dengan label opsional
+type EvidenceUpload struct {
+	Data  string `json:"data"`
+	Label string `json:"label,omitempty"`
 }
 
 type CheckpointCompleteRequest struct {
@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Header request antar node yang ditandatangani key signing node pengirim
+const (
+	HeaderNodeKey       = "X-Node-Key"
+	HeaderNodeTimestamp = "X-Node-Timestamp"
+	HeaderNodeSignature = "X-Node-Signature"
+)
+
+// peerRequestMaxSkew batas selisih jam antara node pengirim dan penerima
+const peerRequestMaxSkew = 5 * time.Minute
+
+// peerRequestMessage adalah data yang ditandatangani: method, path+query dan waktu
+func peerRequestMessage(method, uri, timestamp string) []byte {
+	return []byte(fmt.Sprintf("doctracker-p2p:v1\n%s\n%s\n%s", method, uri, timestamp))
+}
+
+// signPeerRequest menambahkan public key, timestamp dan signature node ke request
+func signPeerRequest(req *http.Request) error {
+	pub, err := keymanager.PublicKey()
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig, err := keymanager.Sign(peerRequestMessage(req.Method, req.URL.RequestURI(), ts))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderNodeKey, hex.EncodeToString(utils.SerializePublicKey(pub)))
+	req.Header.Set(HeaderNodeTimestamp, ts)
+	req.Header.Set(HeaderNodeSignature, hex.EncodeToString(sig))
+	return nil
+}
+
+// RequirePeer hanya meneruskan request yang ditandatangani node di
+// SNAPSHOT_TRUSTED_KEYS. Dipakai untuk endpoint yang melayani isi block
+// (snapshot dan replay block) agar body terenkripsi tidak terbuka ke publik.
+func RequirePeer(c *fiber.Ctx) error {
+	pub, err := utils.ParsePublicKeyHex(c.Get(HeaderNodeKey))
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Peer authentication required")
+	}
+	ts := c.Get(HeaderNodeTimestamp)
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid peer timestamp")
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > peerRequestMaxSkew || skew < -peerRequestMaxSkew {
+		return fiber.NewError(fiber.StatusUnauthorized, "Peer request expired")
+	}
+	sig, err := hex.DecodeString(c.Get(HeaderNodeSignature))
+	if err != nil || !keymanager.Verify(pub, peerRequestMessage(c.Method(), c.OriginalURL(), ts), sig) {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid peer signature")
+	}
+	if fp := keymanager.Fingerprint(pub); !blockchain.IsTrustedSigner(fp) {
+		return fiber.NewError(fiber.StatusForbidden, "Node "+fp+" is not trusted")
+	}
+	return c.Next()
+}
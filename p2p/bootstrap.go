@@ -0,0 +1,55 @@
+package p2p
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var bootstrapClient = &http.Client{Timeout: 60 * time.Second}
+
+// peerURL menerima host:port atau URL lengkap
+func peerURL(peer, path string) string {
+	if !strings.HasPrefix(peer, "http://") && !strings.HasPrefix(peer, "https://") {
+		peer = "http://" + peer
+	}
+	return strings.TrimRight(peer, "/") + path
+}
+
+// FetchSnapshotFrom mengambil snapshot bertanda tangan terbaru dari peer
+func FetchSnapshotFrom(peer string) (blockchain.SignedSnapshot, error) {
+	var signed blockchain.SignedSnapshot
+	err := getJSON(peerURL(peer, "/p2p/snapshot"), &signed)
+	return signed, err
+}
+
+// FetchBlocksSince mengambil block dengan index >= from dari peer
+func FetchBlocksSince(peer string, from int) ([]models.Block, error) {
+	var blocks []models.Block
+	err := getJSON(peerURL(peer, fmt.Sprintf("/p2p/blocks?from=%d", from)), &blocks)
+	return blocks, err
+}
+
+// getJSON mengambil JSON dari peer dengan request bertanda tangan node (RequirePeer)
+func getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if err := signPeerRequest(req); err != nil {
+		return err
+	}
+	resp, err := bootstrapClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
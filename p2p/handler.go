@@ -37,3 +37,24 @@ func GetLatestBlock(c *fiber.Ctx) error {
 func GetMempool(c *fiber.Ctx) error {
 	return c.JSON(mempool.GetAll())
 }
+
+// GetSnapshot melayani snapshot bertanda tangan terbaru untuk fast bootstrap
+func GetSnapshot(c *fiber.Ctx) error {
+	signed, err := blockchain.LatestSignedSnapshot()
+	if err != nil {
+		return c.Status(404).SendString("No snapshot available")
+	}
+	return c.JSON(signed)
+}
+
+// GetBlocksFrom mengembalikan block dengan index >= from untuk replay setelah snapshot
+func GetBlocksFrom(c *fiber.Ctx) error {
+	from := c.QueryInt("from", 0)
+	var blocks []blockchain.Block
+	for _, b := range blockchain.GetAllBlocks() {
+		if b.Index >= from {
+			blocks = append(blocks, b)
+		}
+	}
+	return c.JSON(blocks)
+}
@@ -35,6 +35,11 @@ type Checkpoint struct {
 	EvidencePath  string                 `protobuf:"bytes,10,opt,name=evidence_path,json=evidencePath,proto3" json:"evidence_path,omitempty"`
 	IsCompleted   bool                   `protobuf:"varint,11,opt,name=is_completed,json=isCompleted,proto3" json:"is_completed,omitempty"`
 	CompletedAt   int64                  `protobuf:"varint,12,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	EvidenceKeys  map[string]string      `protobuf:"bytes,13,rep,name=evidence_keys,json=evidenceKeys,proto3" json:"evidence_keys,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	EvidenceType  string                 `protobuf:"bytes,14,opt,name=evidence_type,json=evidenceType,proto3" json:"evidence_type,omitempty"`
+	EvidenceSize  int64                  `protobuf:"varint,15,opt,name=evidence_size,json=evidenceSize,proto3" json:"evidence_size,omitempty"`
+	Evidence      []*EvidenceItem        `protobuf:"bytes,16,rep,name=evidence,proto3" json:"evidence,omitempty"`
+	DueAt         int64                  `protobuf:"varint,17,opt,name=due_at,json=dueAt,proto3" json:"due_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -153,25 +158,164 @@ func (x *Checkpoint) GetCompletedAt() int64 {
 	return 0
 }
 
+func (x *Checkpoint) GetEvidenceKeys() map[string]string {
+	if x != nil {
+		return x.EvidenceKeys
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetEvidenceType() string {
+	if x != nil {
+		return x.EvidenceType
+	}
+	return ""
+}
+
+func (x *Checkpoint) GetEvidenceSize() int64 {
+	if x != nil {
+		return x.EvidenceSize
+	}
+	return 0
+}
+
+func (x *Checkpoint) GetEvidence() []*EvidenceItem {
+	if x != nil {
+		return x.Evidence
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetDueAt() int64 {
+	if x != nil {
+		return x.DueAt
+	}
+	return 0
+}
+
+type EvidenceItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Label         string                 `protobuf:"bytes,5,opt,name=label,proto3" json:"label,omitempty"`
+	Uploader      string                 `protobuf:"bytes,6,opt,name=uploader,proto3" json:"uploader,omitempty"`
+	UploadedAt    int64                  `protobuf:"varint,7,opt,name=uploaded_at,json=uploadedAt,proto3" json:"uploaded_at,omitempty"`
+	Keys          map[string]string      `protobuf:"bytes,8,rep,name=keys,proto3" json:"keys,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvidenceItem) Reset() {
+	*x = EvidenceItem{}
+	mi := &file_proto_p2p_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvidenceItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvidenceItem) ProtoMessage() {}
+
+func (x *EvidenceItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvidenceItem.ProtoReflect.Descriptor instead.
+func (*EvidenceItem) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EvidenceItem) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *EvidenceItem) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *EvidenceItem) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *EvidenceItem) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *EvidenceItem) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *EvidenceItem) GetUploader() string {
+	if x != nil {
+		return x.Uploader
+	}
+	return ""
+}
+
+func (x *EvidenceItem) GetUploadedAt() int64 {
+	if x != nil {
+		return x.UploadedAt
+	}
+	return 0
+}
+
+func (x *EvidenceItem) GetKeys() map[string]string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
 type Tracker struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Type           string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
-	Privacy        string                 `protobuf:"bytes,3,opt,name=privacy,proto3" json:"privacy,omitempty"`
-	Creator        string                 `protobuf:"bytes,4,opt,name=creator,proto3" json:"creator,omitempty"`
-	CreatorAddr    string                 `protobuf:"bytes,5,opt,name=creator_addr,json=creatorAddr,proto3" json:"creator_addr,omitempty"`
-	CreatedAt      int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	Checkpoints    []*Checkpoint          `protobuf:"bytes,7,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
-	TargetEnd      string                 `protobuf:"bytes,8,opt,name=target_end,json=targetEnd,proto3" json:"target_end,omitempty"`
-	Status         string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
-	EncryptedNotes map[string]string      `protobuf:"bytes,10,rep,name=encrypted_notes,json=encryptedNotes,proto3" json:"encrypted_notes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type             string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Privacy          string                 `protobuf:"bytes,3,opt,name=privacy,proto3" json:"privacy,omitempty"`
+	Creator          string                 `protobuf:"bytes,4,opt,name=creator,proto3" json:"creator,omitempty"`
+	CreatorAddr      string                 `protobuf:"bytes,5,opt,name=creator_addr,json=creatorAddr,proto3" json:"creator_addr,omitempty"`
+	CreatedAt        int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Checkpoints      []*Checkpoint          `protobuf:"bytes,7,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	TargetEnd        string                 `protobuf:"bytes,8,opt,name=target_end,json=targetEnd,proto3" json:"target_end,omitempty"`
+	Status           string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
+	EncryptedNotes   map[string]string      `protobuf:"bytes,10,rep,name=encrypted_notes,json=encryptedNotes,proto3" json:"encrypted_notes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	EncryptedContent string                 `protobuf:"bytes,11,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
+	Attachments      []*Attachment          `protobuf:"bytes,12,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	Document         *DocumentFingerprint   `protobuf:"bytes,13,opt,name=document,proto3" json:"document,omitempty"`
+	Template         string                 `protobuf:"bytes,14,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *Tracker) Reset() {
 	*x = Tracker{}
-	mi := &file_proto_p2p_proto_msgTypes[1]
+	mi := &file_proto_p2p_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -183,7 +327,7 @@ func (x *Tracker) String() string {
 func (*Tracker) ProtoMessage() {}
 
 func (x *Tracker) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_p2p_proto_msgTypes[1]
+	mi := &file_proto_p2p_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -196,7 +340,7 @@ func (x *Tracker) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Tracker.ProtoReflect.Descriptor instead.
 func (*Tracker) Descriptor() ([]byte, []int) {
-	return file_proto_p2p_proto_rawDescGZIP(), []int{1}
+	return file_proto_p2p_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Tracker) GetId() string {
@@ -269,6 +413,194 @@ func (x *Tracker) GetEncryptedNotes() map[string]string {
 	return nil
 }
 
+func (x *Tracker) GetEncryptedContent() string {
+	if x != nil {
+		return x.EncryptedContent
+	}
+	return ""
+}
+
+func (x *Tracker) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *Tracker) GetDocument() *DocumentFingerprint {
+	if x != nil {
+		return x.Document
+	}
+	return nil
+}
+
+func (x *Tracker) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+type DocumentFingerprint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	PageHashes    []string               `protobuf:"bytes,5,rep,name=page_hashes,json=pageHashes,proto3" json:"page_hashes,omitempty"`
+	RegisteredAt  int64                  `protobuf:"varint,6,opt,name=registered_at,json=registeredAt,proto3" json:"registered_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DocumentFingerprint) Reset() {
+	*x = DocumentFingerprint{}
+	mi := &file_proto_p2p_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DocumentFingerprint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DocumentFingerprint) ProtoMessage() {}
+
+func (x *DocumentFingerprint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DocumentFingerprint.ProtoReflect.Descriptor instead.
+func (*DocumentFingerprint) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DocumentFingerprint) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *DocumentFingerprint) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DocumentFingerprint) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DocumentFingerprint) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *DocumentFingerprint) GetPageHashes() []string {
+	if x != nil {
+		return x.PageHashes
+	}
+	return nil
+}
+
+func (x *DocumentFingerprint) GetRegisteredAt() int64 {
+	if x != nil {
+		return x.RegisteredAt
+	}
+	return 0
+}
+
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Hash          string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Ciphertext    string                 `protobuf:"bytes,5,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_proto_p2p_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Attachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Attachment) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Attachment) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Attachment) GetCiphertext() string {
+	if x != nil {
+		return x.Ciphertext
+	}
+	return ""
+}
+
 type Block struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
@@ -278,13 +610,14 @@ type Block struct {
 	Nonce         int32                  `protobuf:"varint,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	Transactions  []*Tracker             `protobuf:"bytes,6,rep,name=transactions,proto3" json:"transactions,omitempty"`
 	Encrypted     bool                   `protobuf:"varint,7,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	MerkleRoot    string                 `protobuf:"bytes,8,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Block) Reset() {
 	*x = Block{}
-	mi := &file_proto_p2p_proto_msgTypes[2]
+	mi := &file_proto_p2p_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -296,7 +629,7 @@ func (x *Block) String() string {
 func (*Block) ProtoMessage() {}
 
 func (x *Block) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_p2p_proto_msgTypes[2]
+	mi := &file_proto_p2p_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -309,7 +642,7 @@ func (x *Block) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Block.ProtoReflect.Descriptor instead.
 func (*Block) Descriptor() ([]byte, []int) {
-	return file_proto_p2p_proto_rawDescGZIP(), []int{2}
+	return file_proto_p2p_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Block) GetIndex() int32 {
@@ -361,6 +694,209 @@ func (x *Block) GetEncrypted() bool {
 	return false
 }
 
+func (x *Block) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+type BlockHeader struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	PrevHash      string                 `protobuf:"bytes,3,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Hash          string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	MerkleRoot    string                 `protobuf:"bytes,5,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	Nonce         int32                  `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	TxCount       int32                  `protobuf:"varint,7,opt,name=tx_count,json=txCount,proto3" json:"tx_count,omitempty"`
+	Legacy        bool                   `protobuf:"varint,8,opt,name=legacy,proto3" json:"legacy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockHeader) Reset() {
+	*x = BlockHeader{}
+	mi := &file_proto_p2p_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockHeader) ProtoMessage() {}
+
+func (x *BlockHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockHeader.ProtoReflect.Descriptor instead.
+func (*BlockHeader) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BlockHeader) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BlockHeader) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *BlockHeader) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *BlockHeader) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *BlockHeader) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *BlockHeader) GetNonce() int32 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *BlockHeader) GetTxCount() int32 {
+	if x != nil {
+		return x.TxCount
+	}
+	return 0
+}
+
+func (x *BlockHeader) GetLegacy() bool {
+	if x != nil {
+		return x.Legacy
+	}
+	return false
+}
+
+type HeaderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromIndex     int32                  `protobuf:"varint,1,opt,name=from_index,json=fromIndex,proto3" json:"from_index,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderRequest) Reset() {
+	*x = HeaderRequest{}
+	mi := &file_proto_p2p_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderRequest) ProtoMessage() {}
+
+func (x *HeaderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderRequest.ProtoReflect.Descriptor instead.
+func (*HeaderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeaderRequest) GetFromIndex() int32 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+func (x *HeaderRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type HeaderList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Headers       []*BlockHeader         `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderList) Reset() {
+	*x = HeaderList{}
+	mi := &file_proto_p2p_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderList) ProtoMessage() {}
+
+func (x *HeaderList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_p2p_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderList.ProtoReflect.Descriptor instead.
+func (*HeaderList) Descriptor() ([]byte, []int) {
+	return file_proto_p2p_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeaderList) GetHeaders() []*BlockHeader {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
 type BlockList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Blocks        []*Block               `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
@@ -370,7 +906,7 @@ type BlockList struct {
 
 func (x *BlockList) Reset() {
 	*x = BlockList{}
-	mi := &file_proto_p2p_proto_msgTypes[3]
+	mi := &file_proto_p2p_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -382,7 +918,7 @@ func (x *BlockList) String() string {
 func (*BlockList) ProtoMessage() {}
 
 func (x *BlockList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_p2p_proto_msgTypes[3]
+	mi := &file_proto_p2p_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -395,7 +931,7 @@ func (x *BlockList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockList.ProtoReflect.Descriptor instead.
 func (*BlockList) Descriptor() ([]byte, []int) {
-	return file_proto_p2p_proto_rawDescGZIP(), []int{3}
+	return file_proto_p2p_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *BlockList) GetBlocks() []*Block {
@@ -413,7 +949,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_proto_p2p_proto_msgTypes[4]
+	mi := &file_proto_p2p_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -425,7 +961,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_p2p_proto_msgTypes[4]
+	mi := &file_proto_p2p_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -438,14 +974,14 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_proto_p2p_proto_rawDescGZIP(), []int{4}
+	return file_proto_p2p_proto_rawDescGZIP(), []int{10}
 }
 
 var File_proto_p2p_proto protoreflect.FileDescriptor
 
 const file_proto_p2p_proto_rawDesc = "" +
 	"\n" +
-	"\x0fproto/p2p.proto\x12\x05proto\"\xea\x02\n" +
+	"\x0fproto/p2p.proto\x12\x05proto\"\x87\x05\n" +
 	"\n" +
 	"Checkpoint\x12\x14\n" +
 	"\x05email\x18\x01 \x01(\tR\x05email\x12\x12\n" +
@@ -461,7 +997,28 @@ const file_proto_p2p_proto_rawDesc = "" +
 	"\revidence_path\x18\n" +
 	" \x01(\tR\fevidencePath\x12!\n" +
 	"\fis_completed\x18\v \x01(\bR\visCompleted\x12!\n" +
-	"\fcompleted_at\x18\f \x01(\x03R\vcompletedAt\"\x9f\x03\n" +
+	"\fcompleted_at\x18\f \x01(\x03R\vcompletedAt\x12H\n" +
+	"\revidence_keys\x18\r \x03(\v2#.proto.Checkpoint.EvidenceKeysEntryR\fevidenceKeys\x12#\n" +
+	"\revidence_type\x18\x0e \x01(\tR\fevidenceType\x12#\n" +
+	"\revidence_size\x18\x0f \x01(\x03R\fevidenceSize\x12/\n" +
+	"\bevidence\x18\x10 \x03(\v2\x13.proto.EvidenceItemR\bevidence\x12\x15\n" +
+	"\x06due_at\x18\x11 \x01(\x03R\x05dueAt\x1a?\n" +
+	"\x11EvidenceKeysEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xac\x02\n" +
+	"\fEvidenceItem\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05label\x18\x05 \x01(\tR\x05label\x12\x1a\n" +
+	"\buploader\x18\x06 \x01(\tR\buploader\x12\x1f\n" +
+	"\vuploaded_at\x18\a \x01(\x03R\n" +
+	"uploadedAt\x121\n" +
+	"\x04keys\x18\b \x03(\v2\x1d.proto.EvidenceItem.KeysEntryR\x04keys\x1a7\n" +
+	"\tKeysEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd5\x04\n" +
 	"\aTracker\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
@@ -475,10 +1032,31 @@ const file_proto_p2p_proto_rawDesc = "" +
 	"target_end\x18\b \x01(\tR\ttargetEnd\x12\x16\n" +
 	"\x06status\x18\t \x01(\tR\x06status\x12K\n" +
 	"\x0fencrypted_notes\x18\n" +
-	" \x03(\v2\".proto.Tracker.EncryptedNotesEntryR\x0eencryptedNotes\x1aA\n" +
+	" \x03(\v2\".proto.Tracker.EncryptedNotesEntryR\x0eencryptedNotes\x12+\n" +
+	"\x11encrypted_content\x18\v \x01(\tR\x10encryptedContent\x123\n" +
+	"\vattachments\x18\f \x03(\v2\x11.proto.AttachmentR\vattachments\x126\n" +
+	"\bdocument\x18\r \x01(\v2\x1a.proto.DocumentFingerprintR\bdocument\x12\x1a\n" +
+	"\btemplate\x18\x0e \x01(\tR\btemplate\x1aA\n" +
 	"\x13EncryptedNotesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd4\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xba\x01\n" +
+	"\x13DocumentFingerprint\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x1f\n" +
+	"\vpage_hashes\x18\x05 \x03(\tR\n" +
+	"pageHashes\x12#\n" +
+	"\rregistered_at\x18\x06 \x01(\x03R\fregisteredAt\"\x8b\x01\n" +
+	"\n" +
+	"Attachment\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x05 \x01(\tR\n" +
+	"ciphertext\"\xf5\x01\n" +
 	"\x05Block\x12\x14\n" +
 	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1b\n" +
@@ -486,14 +1064,35 @@ const file_proto_p2p_proto_rawDesc = "" +
 	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x14\n" +
 	"\x05nonce\x18\x05 \x01(\x05R\x05nonce\x122\n" +
 	"\ftransactions\x18\x06 \x03(\v2\x0e.proto.TrackerR\ftransactions\x12\x1c\n" +
-	"\tencrypted\x18\a \x01(\bR\tencrypted\"1\n" +
+	"\tencrypted\x18\a \x01(\bR\tencrypted\x12\x1f\n" +
+	"\vmerkle_root\x18\b \x01(\tR\n" +
+	"merkleRoot\"\xdc\x01\n" +
+	"\vBlockHeader\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\tprev_hash\x18\x03 \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x1f\n" +
+	"\vmerkle_root\x18\x05 \x01(\tR\n" +
+	"merkleRoot\x12\x14\n" +
+	"\x05nonce\x18\x06 \x01(\x05R\x05nonce\x12\x19\n" +
+	"\btx_count\x18\a \x01(\x05R\atxCount\x12\x16\n" +
+	"\x06legacy\x18\b \x01(\bR\x06legacy\"D\n" +
+	"\rHeaderRequest\x12\x1d\n" +
+	"\n" +
+	"from_index\x18\x01 \x01(\x05R\tfromIndex\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\":\n" +
+	"\n" +
+	"HeaderList\x12,\n" +
+	"\aheaders\x18\x01 \x03(\v2\x12.proto.BlockHeaderR\aheaders\"1\n" +
 	"\tBlockList\x12$\n" +
 	"\x06blocks\x18\x01 \x03(\v2\f.proto.BlockR\x06blocks\"\a\n" +
-	"\x05Empty2k\n" +
+	"\x05Empty2\xa2\x01\n" +
 	"\n" +
 	"P2PService\x12/\n" +
 	"\rGetBlockchain\x12\f.proto.Empty\x1a\x10.proto.BlockList\x12,\n" +
-	"\x0eBroadcastBlock\x12\f.proto.Block\x1a\f.proto.EmptyB\x0eZ\f/proto;protob\x06proto3"
+	"\x0eBroadcastBlock\x12\f.proto.Block\x1a\f.proto.Empty\x125\n" +
+	"\n" +
+	"GetHeaders\x12\x14.proto.HeaderRequest\x1a\x11.proto.HeaderListB\x0eZ\f/proto;protob\x06proto3"
 
 var (
 	file_proto_p2p_proto_rawDescOnce sync.Once
@@ -507,29 +1106,45 @@ func file_proto_p2p_proto_rawDescGZIP() []byte {
 	return file_proto_p2p_proto_rawDescData
 }
 
-var file_proto_p2p_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_p2p_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_proto_p2p_proto_goTypes = []any{
-	(*Checkpoint)(nil), // 0: proto.Checkpoint
-	(*Tracker)(nil),    // 1: proto.Tracker
-	(*Block)(nil),      // 2: proto.Block
-	(*BlockList)(nil),  // 3: proto.BlockList
-	(*Empty)(nil),      // 4: proto.Empty
-	nil,                // 5: proto.Tracker.EncryptedNotesEntry
+	(*Checkpoint)(nil),          // 0: proto.Checkpoint
+	(*EvidenceItem)(nil),        // 1: proto.EvidenceItem
+	(*Tracker)(nil),             // 2: proto.Tracker
+	(*DocumentFingerprint)(nil), // 3: proto.DocumentFingerprint
+	(*Attachment)(nil),          // 4: proto.Attachment
+	(*Block)(nil),               // 5: proto.Block
+	(*BlockHeader)(nil),         // 6: proto.BlockHeader
+	(*HeaderRequest)(nil),       // 7: proto.HeaderRequest
+	(*HeaderList)(nil),          // 8: proto.HeaderList
+	(*BlockList)(nil),           // 9: proto.BlockList
+	(*Empty)(nil),               // 10: proto.Empty
+	nil,                         // 11: proto.Checkpoint.EvidenceKeysEntry
+	nil,                         // 12: proto.EvidenceItem.KeysEntry
+	nil,                         // 13: proto.Tracker.EncryptedNotesEntry
 }
 var file_proto_p2p_proto_depIdxs = []int32{
-	0, // 0: proto.Tracker.checkpoints:type_name -> proto.Checkpoint
-	5, // 1: proto.Tracker.encrypted_notes:type_name -> proto.Tracker.EncryptedNotesEntry
-	1, // 2: proto.Block.transactions:type_name -> proto.Tracker
-	2, // 3: proto.BlockList.blocks:type_name -> proto.Block
-	4, // 4: proto.P2PService.GetBlockchain:input_type -> proto.Empty
-	2, // 5: proto.P2PService.BroadcastBlock:input_type -> proto.Block
-	3, // 6: proto.P2PService.GetBlockchain:output_type -> proto.BlockList
-	4, // 7: proto.P2PService.BroadcastBlock:output_type -> proto.Empty
-	6, // [6:8] is the sub-list for method output_type
-	4, // [4:6] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	11, // 0: proto.Checkpoint.evidence_keys:type_name -> proto.Checkpoint.EvidenceKeysEntry
+	1,  // 1: proto.Checkpoint.evidence:type_name -> proto.EvidenceItem
+	12, // 2: proto.EvidenceItem.keys:type_name -> proto.EvidenceItem.KeysEntry
+	0,  // 3: proto.Tracker.checkpoints:type_name -> proto.Checkpoint
+	13, // 4: proto.Tracker.encrypted_notes:type_name -> proto.Tracker.EncryptedNotesEntry
+	4,  // 5: proto.Tracker.attachments:type_name -> proto.Attachment
+	3,  // 6: proto.Tracker.document:type_name -> proto.DocumentFingerprint
+	2,  // 7: proto.Block.transactions:type_name -> proto.Tracker
+	6,  // 8: proto.HeaderList.headers:type_name -> proto.BlockHeader
+	5,  // 9: proto.BlockList.blocks:type_name -> proto.Block
+	10, // 10: proto.P2PService.GetBlockchain:input_type -> proto.Empty
+	5,  // 11: proto.P2PService.BroadcastBlock:input_type -> proto.Block
+	7,  // 12: proto.P2PService.GetHeaders:input_type -> proto.HeaderRequest
+	9,  // 13: proto.P2PService.GetBlockchain:output_type -> proto.BlockList
+	10, // 14: proto.P2PService.BroadcastBlock:output_type -> proto.Empty
+	8,  // 15: proto.P2PService.GetHeaders:output_type -> proto.HeaderList
+	13, // [13:16] is the sub-list for method output_type
+	10, // [10:13] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_p2p_proto_init() }
@@ -543,7 +1158,7 @@ func file_proto_p2p_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_p2p_proto_rawDesc), len(file_proto_p2p_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,6 +21,7 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	P2PService_GetBlockchain_FullMethodName  = "/proto.P2PService/GetBlockchain"
 	P2PService_BroadcastBlock_FullMethodName = "/proto.P2PService/BroadcastBlock"
+	P2PService_GetHeaders_FullMethodName     = "/proto.P2PService/GetHeaders"
 )
 
 // P2PServiceClient is the client API for P2PService service.
@@ -29,6 +30,7 @@ const (
 type P2PServiceClient interface {
 	GetBlockchain(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BlockList, error)
 	BroadcastBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Empty, error)
+	GetHeaders(ctx context.Context, in *HeaderRequest, opts ...grpc.CallOption) (*HeaderList, error)
 }
 
 type p2PServiceClient struct {
@@ -59,12 +61,23 @@ func (c *p2PServiceClient) BroadcastBlock(ctx context.Context, in *Block, opts .
 	return out, nil
 }
 
+func (c *p2PServiceClient) GetHeaders(ctx context.Context, in *HeaderRequest, opts ...grpc.CallOption) (*HeaderList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HeaderList)
+	err := c.cc.Invoke(ctx, P2PService_GetHeaders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // P2PServiceServer is the server API for P2PService service.
 // All implementations must embed UnimplementedP2PServiceServer
 // for forward compatibility.
 type P2PServiceServer interface {
 	GetBlockchain(context.Context, *Empty) (*BlockList, error)
 	BroadcastBlock(context.Context, *Block) (*Empty, error)
+	GetHeaders(context.Context, *HeaderRequest) (*HeaderList, error)
 	mustEmbedUnimplementedP2PServiceServer()
 }
 
@@ -81,6 +94,9 @@ func (UnimplementedP2PServiceServer) GetBlockchain(context.Context, *Empty) (*Bl
 func (UnimplementedP2PServiceServer) BroadcastBlock(context.Context, *Block) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BroadcastBlock not implemented")
 }
+func (UnimplementedP2PServiceServer) GetHeaders(context.Context, *HeaderRequest) (*HeaderList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHeaders not implemented")
+}
 func (UnimplementedP2PServiceServer) mustEmbedUnimplementedP2PServiceServer() {}
 func (UnimplementedP2PServiceServer) testEmbeddedByValue()                    {}
 
@@ -138,6 +154,24 @@ func _P2PService_BroadcastBlock_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _P2PService_GetHeaders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(P2PServiceServer).GetHeaders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: P2PService_GetHeaders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(P2PServiceServer).GetHeaders(ctx, req.(*HeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // P2PService_ServiceDesc is the grpc.ServiceDesc for P2PService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +187,10 @@ var P2PService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BroadcastBlock",
 			Handler:    _P2PService_BroadcastBlock_Handler,
 		},
+		{
+			MethodName: "GetHeaders",
+			Handler:    _P2PService_GetHeaders_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/p2p.proto",
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"doc-tracker/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterAuditRoutes query, export dan verifikasi audit log (admin/auditor)
+func RegisterAuditRoutes(router fiber.Router) {
+	audit := router.Group("/audit")
+	audit.Get("/logs", controllers.GetAuditLogs)
+	audit.Get("/logs/export", controllers.ExportAuditLogs)
+	audit.Get("/verify", controllers.VerifyAuditLog)
+	audit.Get("/head", controllers.GetAuditHead)
+	audit.Post("/anchor", controllers.AnchorAuditLog)
+}
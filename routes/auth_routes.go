@@ -9,6 +9,8 @@ import (
 func SetupAuthRoutes(router fiber.Router) {
 	auth := router.Group("/auth")
 	auth.Post("/login", controllers.Login)
+	auth.Post("/challenge", controllers.LoginChallenge)
+	auth.Post("/verify-signature", controllers.LoginWithSignature)
 	auth.Post("/logout", controllers.Logout)
 	auth.Post("/request-otp", controllers.SendOtp)
 	auth.Post("/verify-otp", controllers.VerifyOtp)
@@ -22,4 +24,11 @@ func SetupAuthProtectedRoutes(router fiber.Router) {
 	meauth := router.Group("/auth")
 	// meauth.Use(limiter.New(limiter.Config{Max: 10000, Expiration: time.Minute}))
 	meauth.Post("/me", controllers.AuthMe)
+
+	totp := router.Group("/auth/totp")
+	totp.Get("/status", controllers.TotpStatus)
+	totp.Post("/enroll", controllers.TotpEnroll)
+	totp.Post("/activate", controllers.TotpActivate)
+	totp.Post("/recovery-codes", controllers.TotpRegenerateRecovery)
+	totp.Post("/disable", controllers.TotpDisable)
 }
@@ -9,4 +9,5 @@ import (
 func BlockRoutes(app fiber.Router) {
 	app.Post("/sync/block", controllers.ReceiveBlock)
 	app.Get("/blocks", controllers.GetChain)
+	app.Get("/blocks/headers", controllers.GetBlockHeaders)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"doc-tracker/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterDocumentRoutes(router fiber.Router) {
+	router.Post("/documents/fingerprint", controllers.FingerprintDocument)
+}
+
+// RegisterDocumentPublicRoutes verifikasi dokumen terbuka untuk siapa saja
+// tanpa login; hanya ringkasan tracker tanpa email/isi yang dikembalikan
+func RegisterDocumentPublicRoutes(router fiber.Router) {
+	router.Get("/documents/verify", controllers.VerifyDocumentHash)
+	router.Post("/documents/verify", controllers.VerifyDocument)
+}
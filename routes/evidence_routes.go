@@ -8,9 +8,34 @@ import (
 
 func RegisterEvidenceRoutes(router fiber.Router) {
 	router.Post("/upload", controllers.UploadEvidence)
+	router.Get("/evidence/verify/:id", controllers.VerifyTrackerEvidence)
+	router.Get("/evidence/audit", controllers.GetEvidenceAudit)
+	router.Post("/evidence/audit", controllers.RunEvidenceAudit)
 
+	// Upload bertahap (resumable) untuk file besar
+	router.Post("/evidence/uploads", controllers.CreateEvidenceUpload)
+	router.Get("/evidence/uploads/:id", controllers.GetEvidenceUpload)
+	router.Patch("/evidence/uploads/:id", controllers.UploadEvidenceChunk)
+	router.Post("/evidence/uploads/:id/complete", controllers.CompleteEvidenceUpload)
+	router.Delete("/evidence/uploads/:id", controllers.AbortEvidenceUpload)
+
+	// Upload dan download langsung ke storage tanpa melewati node
+	router.Get("/evidence/url", controllers.GetEvidenceURL)
+	router.Get("/evidence/metadata", controllers.GetEvidenceMetadata)
+	router.Post("/evidence/tickets", controllers.CreateEvidenceTicket)
+	router.Post("/evidence/tickets/:id/complete", controllers.CompleteEvidenceTicket)
+	router.Delete("/evidence/tickets/:id", controllers.AbortEvidenceTicket)
+
+}
+
+// RegisterEvidenceSignedRoutes URL bertanda tangan backend local/memory; tanpa
+// JWT karena aksesnya dijamin tanda tangan HMAC di URL (evidence.SignedPath)
+func RegisterEvidenceSignedRoutes(router fiber.Router) {
+	router.Get("/evidence/files/:hash", controllers.ServeSignedEvidence)
+	router.Put("/evidence/files/staging/:id", controllers.PutSignedEvidence)
 }
 
 func RegisterEvidenceRoutesWeb(router fiber.Router) {
 	router.Get("/evidence/view", controllers.ViewEvidence)
+	router.Get("/evidence/thumbnail", controllers.ViewEvidenceThumbnail)
 }
@@ -11,4 +11,8 @@ func P2PRoutes(app *fiber.App) {
 	app.Get("/p2p/mempool", p2p.GetMempool)
 	app.Post("/p2p/block", p2p.ReceiveBlock)
 	app.Post("/p2p/mempool", p2p.ReceiveMempool)
+
+	// Isi block hanya untuk node di SNAPSHOT_TRUSTED_KEYS (request bertanda tangan)
+	app.Get("/p2p/snapshot", p2p.RequirePeer, p2p.GetSnapshot)
+	app.Get("/p2p/blocks", p2p.RequirePeer, p2p.GetBlocksFrom)
 }
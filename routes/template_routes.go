@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"doc-tracker/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TemplateRoutes(router fiber.Router) {
+	tpl := router.Group("/templates")
+	tpl.Get("/", controllers.GetTemplates)
+	tpl.Post("/", controllers.CreateTemplate)
+	tpl.Get("/:id", controllers.GetTemplate)
+	tpl.Put("/:id", controllers.UpdateTemplate)
+	tpl.Delete("/:id", controllers.DeleteTemplate)
+
+	// Membuat tracker dari template dengan variabel dan override per tracker
+	tpl.Post("/:id/preview", controllers.PreviewTemplateTracker)
+	tpl.Post("/:id/trackers", controllers.CreateTrackerFromTemplate)
+
+	// Direktori atasan untuk variabel {{creator.manager}}
+	org := router.Group("/org")
+	org.Get("/managers", controllers.GetManagers)
+	org.Put("/managers", controllers.UpdateManagers)
+}
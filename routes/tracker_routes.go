@@ -15,4 +15,18 @@ func TrackerRoutes(router fiber.Router) {
 	apiTracker.Get("/address/:address", controllers.GetTrackersByAddress)
 	apiTracker.Post("/create", controllers.CreateTracker)
 	apiTracker.Get("/summary/:email", controllers.GetTrackerSummary)
+
+	// Konten terenkripsi (envelope per viewer)
+	apiTracker.Get("/:id/content", controllers.GetTrackerContent)
+	apiTracker.Get("/:id/attachments/:name", controllers.DownloadAttachment)
+	apiTracker.Post("/:id/viewers", controllers.GrantViewer)
+	apiTracker.Delete("/:id/viewers/:email", controllers.RevokeViewer)
+	apiTracker.Get("/:id/thumbnails", controllers.GetTrackerThumbnails)
+	apiTracker.Get("/:id/label", controllers.GetTrackerLabel)
+	apiTracker.Get("/:id/certificate.pdf", controllers.GetTrackerCertificate)
+}
+
+// TrackerPublicRoutes halaman verifikasi tujuan QR label, tanpa login
+func TrackerPublicRoutes(router fiber.Router) {
+	router.Get("/verify/:id", controllers.VerifyTracker)
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"doc-tracker/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WalletRoutes(router fiber.Router) {
+	wallet := router.Group("/wallet")
+	wallet.Get("/", controllers.GetMyWallet)
+	wallet.Post("/export", controllers.ExportWallet)
+	wallet.Post("/import", controllers.ImportWallet)
+	wallet.Post("/register", controllers.RegisterWallet)
+}
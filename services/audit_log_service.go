@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"doc-tracker/keymanager"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+var ErrAuditExportFormat = errors.New("export format must be jsonl or csv")
+
+var (
+	auditLogDir    = "data/audit"
+	auditLogFile   = "data/audit/audit.log"
+	auditAnchorLog = "data/audit/anchor.json"
+)
+
+// auditGenesisHash prev_hash entry pertama
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// AuditLogEntry satu aksi API. Hash = sha256(prev_hash + JSON entry tanpa
+// hash), jadi mengubah atau menghapus satu baris memutus rantai setelahnya.
+type AuditLogEntry struct {
+	Seq        int64  `json:"seq"`
+	Time       int64  `json:"time"` // unix milli
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Resource   string `json:"resource,omitempty"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	Status     int    `json:"status"`
+	Result     string `json:"result"` // success / failure / denied
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"`
+}
+
+// AuditFilter filter query audit log; field kosong diabaikan
+type AuditFilter struct {
+	Actor    string
+	Action   string // prefix, mis. "tracker." untuk semua aksi tracker
+	Resource string // substring
+	Result   string
+	IP       string
+	From     int64 // unix milli, inklusif
+	To       int64 // unix milli, inklusif
+	Limit    int
+	Offset   int
+}
+
+// AuditHead posisi terakhir rantai audit log
+type AuditHead struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// AuditVerification hasil verifikasi ulang seluruh rantai audit log
+type AuditVerification struct {
+	Valid    bool      `json:"valid"`
+	Entries  int64     `json:"entries"`
+	Head     AuditHead `json:"head"`
+	BrokenAt int64     `json:"broken_at,omitempty"` // seq entry pertama yang tidak cocok
+	Error    string    `json:"error,omitempty"`
+}
+
+// AuditAnchor head audit log yang terakhir dicatat ke blockchain
+type AuditAnchor struct {
+	Seq       int64  `json:"seq"`
+	Hash      string `json:"hash"`
+	TrackerID string `json:"tracker_id"`
+	Time      int64  `json:"time"`
+}
+
+// Key Locals untuk data audit yang tidak terlihat dari URL (mis. isi body)
+const (
+	auditActorKey    = "audit_actor"
+	auditResourceKey = "audit_resource"
+)
+
+// SetAuditActor mencatat pelaku untuk request tanpa JWT, mis. email saat login
+func SetAuditActor(c *fiber.Ctx, actor string) {
+	c.Locals(auditActorKey, actor)
+}
+
+// SetAuditResource mencatat resource yang dikirim lewat body, mis. tracker_id
+func SetAuditResource(c *fiber.Ctx, resource string) {
+	c.Locals(auditResourceKey, resource)
+}
+
+// AuditLocals actor dan resource yang diset controller untuk request ini
+func AuditLocals(c *fiber.Ctx) (actor, resource string) {
+	actor, _ = c.Locals(auditActorKey).(string)
+	resource, _ = c.Locals(auditResourceKey).(string)
+	return actor, resource
+}
+
+var auditLog struct {
+	sync.Mutex
+	loaded bool
+	head   AuditHead
+}
+
+// auditEntryHash menghitung hash entry dari prev_hash dan isi entry
+func auditEntryHash(e AuditLogEntry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(append([]byte(e.PrevHash), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadAuditHead membaca entry terakhir dari file; dipanggil dengan lock
+func loadAuditHead() error {
+	if auditLog.loaded {
+		return nil
+	}
+	head := AuditHead{Hash: auditGenesisHash}
+	err := scanAuditLog(func(e AuditLogEntry) error {
+		head = AuditHead{Seq: e.Seq, Hash: e.Hash}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	auditLog.head, auditLog.loaded = head, true
+	return nil
+}
+
+// scanAuditLog membaca audit log dari awal, satu entry per baris
+func scanAuditLog(fn func(e AuditLogEntry) error) error {
+	f, err := os.Open(auditLogFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e AuditLogEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("corrupt audit entry: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// RecordAudit menambahkan entry ke audit log (append-only). Seq, waktu dan
+// hash diisi di sini; kegagalan menulis hanya di-log agar request tetap jalan.
+func RecordAudit(e AuditLogEntry) {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+
+	if err := loadAuditHead(); err != nil {
+		log.Printf("⚠️ Audit log: %v", err)
+		return
+	}
+	if e.Time == 0 {
+		e.Time = time.Now().UnixMilli()
+	}
+	e.Seq = auditLog.head.Seq + 1
+	e.PrevHash = auditLog.head.Hash
+	e.Hash = auditEntryHash(e)
+
+	line, _ := json.Marshal(e)
+	if err := utils.CreateDirIfNotExists(auditLogDir); err != nil {
+		log.Printf("⚠️ Audit log: %v", err)
+		return
+	}
+	f, err := os.OpenFile(auditLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("⚠️ Audit log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("⚠️ Audit log: %v", err)
+		return
+	}
+	auditLog.head = AuditHead{Seq: e.Seq, Hash: e.Hash}
+}
+
+// AuditLogHead seq dan hash entry terakhir
+func AuditLogHead() (AuditHead, error) {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+	if err := loadAuditHead(); err != nil {
+		return AuditHead{}, err
+	}
+	return auditLog.head, nil
+}
+
+func (f AuditFilter) match(e AuditLogEntry) bool {
+	switch {
+	case f.Actor != "" && !strings.EqualFold(e.Actor, f.Actor):
+		return false
+	case f.Action != "" && !strings.HasPrefix(e.Action, f.Action):
+		return false
+	case f.Resource != "" && !strings.Contains(e.Resource, f.Resource):
+		return false
+	case f.Result != "" && e.Result != f.Result:
+		return false
+	case f.IP != "" && e.IP != f.IP:
+		return false
+	case f.From > 0 && e.Time < f.From:
+		return false
+	case f.To > 0 && e.Time > f.To:
+		return false
+	}
+	return true
+}
+
+// QueryAudit entry yang cocok dengan filter, terbaru lebih dulu, beserta
+// jumlah total yang cocok sebelum limit/offset
+func QueryAudit(filter AuditFilter) ([]AuditLogEntry, int, error) {
+	var matched []AuditLogEntry
+	err := scanAuditLog(func(e AuditLogEntry) error {
+		if filter.match(e) {
+			matched = append(matched, e)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+
+	total := len(matched)
+	entries := make([]AuditLogEntry, 0, min(total, max(filter.Limit, 0)))
+	for i := total - 1 - filter.Offset; i >= 0; i-- {
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+		entries = append(entries, matched[i])
+	}
+	return entries, total, nil
+}
+
+// ExportAudit menulis entry yang cocok (urut seq, tanpa limit) sebagai JSONL
+// atau CSV. JSONL bisa diverifikasi ulang di luar node dengan rumus hash yang sama.
+func ExportAudit(w io.Writer, filter AuditFilter, format string) error {
+	var cw *csv.Writer
+	switch format {
+	case "", "jsonl":
+	case "csv":
+		cw = csv.NewWriter(w)
+		cw.Write([]string{"seq", "time", "actor", "action", "method", "path", "resource", "ip", "user_agent", "status", "result", "error", "duration_ms", "prev_hash", "hash"})
+	default:
+		return ErrAuditExportFormat
+	}
+
+	err := scanAuditLog(func(e AuditLogEntry) error {
+		if !filter.match(e) {
+			return nil
+		}
+		if cw == nil {
+			line, _ := json.Marshal(e)
+			_, err := w.Write(append(line, '\n'))
+			return err
+		}
+		return cw.Write([]string{
+			strconv.FormatInt(e.Seq, 10), time.UnixMilli(e.Time).UTC().Format(time.RFC3339Nano),
+			e.Actor, e.Action, e.Method, e.Path, e.Resource, e.IP, e.UserAgent,
+			strconv.Itoa(e.Status), e.Result, e.Error, strconv.FormatInt(e.DurationMs, 10),
+			e.PrevHash, e.Hash,
+		})
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if cw != nil {
+		cw.Flush()
+		return cw.Error()
+	}
+	return nil
+}
+
+// VerifyAuditLog menghitung ulang rantai hash dari genesis sampai head
+func VerifyAuditLog() (AuditVerification, error) {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+
+	result := AuditVerification{Valid: true, Head: AuditHead{Hash: auditGenesisHash}}
+	errBroken := errors.New("broken")
+	err := scanAuditLog(func(e AuditLogEntry) error {
+		switch {
+		case e.Seq != result.Head.Seq+1:
+			result.Error = fmt.Sprintf("expected seq %d, got %d", result.Head.Seq+1, e.Seq)
+		case e.PrevHash != result.Head.Hash:
+			result.Error = "prev_hash does not match previous entry"
+		case auditEntryHash(e) != e.Hash:
+			result.Error = "entry hash mismatch"
+		default:
+			result.Entries++
+			result.Head = AuditHead{Seq: e.Seq, Hash: e.Hash}
+			return nil
+		}
+		result.Valid, result.BrokenAt = false, e.Seq
+		return errBroken
+	})
+	if err != nil && err != errBroken && !os.IsNotExist(err) {
+		return AuditVerification{}, err
+	}
+	return result, nil
+}
+
+// LastAuditAnchor anchor terakhir, nil jika belum pernah
+func LastAuditAnchor() (*AuditAnchor, error) {
+	data, err := os.ReadFile(auditAnchorLog)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var anchor AuditAnchor
+	if err := json.Unmarshal(data, &anchor); err != nil {
+		return nil, err
+	}
+	return &anchor, nil
+}
+
+// AnchorAuditLog mencatat head audit log ke blockchain sebagai tracker
+// audit_anchor yang langsung complete sehingga di-mine oleh miner worker.
+// Tidak melakukan apa-apa jika head belum berubah sejak anchor terakhir.
+func AnchorAuditLog() (*AuditAnchor, error) {
+	head, err := AuditLogHead()
+	if err != nil || head.Seq == 0 {
+		return nil, err
+	}
+	last, err := LastAuditAnchor()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.Seq == head.Seq {
+		return last, nil
+	}
+
+	now := time.Now().Unix()
+	anchor := &AuditAnchor{
+		Seq:       head.Seq,
+		Hash:      head.Hash,
+		TrackerID: fmt.Sprintf("audit-anchor-%d-%s", head.Seq, head.Hash[:12]),
+		Time:      now,
+	}
+	nodeAddr := ""
+	if pub, err := keymanager.PublicKey(); err == nil {
+		nodeAddr = keymanager.Fingerprint(pub)
+	}
+	mempool.Add(&models.Tracker{
+		ID:          anchor.TrackerID,
+		Type:        "audit_anchor",
+		Privacy:     "public",
+		CreatorAddr: nodeAddr,
+		CreatedAt:   now,
+		Checkpoints: []models.Checkpoint{},
+		Status:      "complete",
+		Document: &models.DocumentFingerprint{
+			Hash:         head.Hash,
+			Name:         fmt.Sprintf("audit-log#%d", head.Seq),
+			RegisteredAt: now,
+		},
+	})
+
+	data, _ := json.MarshalIndent(anchor, "", "  ")
+	if err := os.WriteFile(auditAnchorLog, data, 0600); err != nil {
+		return nil, err
+	}
+	return anchor, nil
+}
+
+// StartAuditAnchorWorker meng-anchor head audit log setiap AUDIT_ANCHOR_INTERVAL
+// (durasi Go, mis. "1h"; kosong atau "0" = mati)
+func StartAuditAnchorWorker() {
+	interval := time.Duration(0)
+	if v := os.Getenv("AUDIT_ANCHOR_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("⚠️ Invalid AUDIT_ANCHOR_INTERVAL %q, anchoring disabled", v)
+		}
+		interval = d
+	}
+	if interval <= 0 {
+		fmt.Println("[AuditLog] Anchoring disabled")
+		return
+	}
+
+	fmt.Printf("[AuditLog] Anchoring head every %s\n", interval)
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			anchor, err := AnchorAuditLog()
+			if err != nil {
+				log.Printf("⚠️ [AuditLog] Anchor failed: %v", err)
+				continue
+			}
+			if anchor != nil {
+				fmt.Printf("[AuditLog] Head #%d anchored in tracker %s\n", anchor.Seq, anchor.TrackerID)
+			}
+		}
+	}()
+}
@@ -1,12 +1,38 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"doc-tracker/storage/redis"
 	"doc-tracker/utils"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var (
+	ErrChallengeNotFound  = errors.New("challenge not found or expired")
+	ErrChallengeMismatch  = errors.New("challenge was issued for another address")
+	ErrAddressMismatch    = errors.New("public key does not match address")
+	ErrInvalidSignature   = errors.New("invalid signature")
+	ErrInvalidWalletInput = errors.New("address is required")
+	ErrChallengeLimit     = errors.New("too many login challenges, try again later")
+)
+
+// LoginChallenge adalah nonce yang harus ditandatangani wallet untuk login
+type LoginChallenge struct {
+	Address   string `json:"address"`
+	Nonce     string `json:"nonce"`
+	Message   string `json:"message"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// LoginWithMnemonic Deprecated: mengirim mnemonic ke server. Gunakan
+// CreateLoginChallenge + VerifyLoginSignature agar mnemonic tetap di client.
 func LoginWithMnemonic(mnemonic string) (string, error) {
 	if !utils.IsValidMnemonic(mnemonic) {
 		return "", fmt.Errorf("invalid mnemonic phrase")
@@ -18,6 +44,15 @@ func LoginWithMnemonic(mnemonic string) (string, error) {
 }
 
 func GetLoginEmail(c *fiber.Ctx) (string, error) {
+	claims, err := GetLoginClaims(c)
+	if err != nil {
+		return "", err
+	}
+	return claims.Email, nil
+}
+
+// GetLoginClaims membaca claim JWT user login dari cookie atau header Authorization
+func GetLoginClaims(c *fiber.Ctx) (*JwtClaims, error) {
 	tokenStr := c.Cookies("authToken")
 
 	// Fallback: cari di Authorization header
@@ -29,13 +64,92 @@ func GetLoginEmail(c *fiber.Ctx) (string, error) {
 	}
 
 	if tokenStr == "" {
-		return "", fmt.Errorf("missing token")
+		return nil, fmt.Errorf("missing token")
+	}
+	return VerifyJwtToken(tokenStr)
+}
+
+// CreateLoginChallenge membuat nonce sekali pakai untuk address.
+// Masa berlaku diatur lewat LOGIN_CHALLENGE_TTL_SECONDS (default 5 menit).
+// Jumlah challenge dibatasi per address (LOGIN_CHALLENGE_LIMIT, default 10) dan
+// per IP (LOGIN_CHALLENGE_IP_LIMIT, default 30) dalam LOGIN_CHALLENGE_WINDOW_SECONDS
+// (default 15 menit) agar nonce tidak memenuhi redis.
+func CreateLoginChallenge(address, ip string) (LoginChallenge, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if address == "" {
+		return LoginChallenge{}, ErrInvalidWalletInput
+	}
+
+	window := utils.GetEnvSeconds("LOGIN_CHALLENGE_WINDOW_SECONDS", 15*time.Minute)
+	if n, err := redis.Incr("auth:challenge:count:addr:"+address, window); err == nil && int(n) > utils.GetEnvInt("LOGIN_CHALLENGE_LIMIT", 10) {
+		return LoginChallenge{}, ErrChallengeLimit
+	}
+	if ip != "" {
+		if n, err := redis.Incr("auth:challenge:count:ip:"+ip, window); err == nil && int(n) > utils.GetEnvInt("LOGIN_CHALLENGE_IP_LIMIT", 30) {
+			return LoginChallenge{}, ErrChallengeLimit
+		}
 	}
 
-	claims, err := VerifyJwtToken(tokenStr)
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return LoginChallenge{}, err
+	}
+	nonce := hex.EncodeToString(b)
+
+	ttl := utils.GetEnvSeconds("LOGIN_CHALLENGE_TTL_SECONDS", 5*time.Minute)
+	expiresAt := time.Now().Add(ttl).Unix()
+	if err := redis.Set("auth:challenge:"+nonce, fmt.Sprintf("%s|%d", address, expiresAt), ttl); err != nil {
+		return LoginChallenge{}, err
+	}
+
+	return LoginChallenge{
+		Address:   address,
+		Nonce:     nonce,
+		Message:   ChallengeMessage(address, nonce, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ChallengeMessage adalah teks yang di-hash (SHA-256) lalu ditandatangani client
+func ChallengeMessage(address, nonce string, expiresAt int64) string {
+	return fmt.Sprintf("DocTracker login\nAddress: %s\nNonce: %s\nExpires: %d", address, nonce, expiresAt)
+}
+
+// VerifyLoginSignature memverifikasi signature atas challenge dan mengembalikan
+// address wallet. Nonce langsung dihapus sehingga tidak bisa di-replay.
+func VerifyLoginSignature(address, publicKeyHex, nonce, signatureHex string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+
+	stored, found, err := redis.Get("auth:challenge:" + nonce)
 	if err != nil {
 		return "", err
 	}
+	if !found {
+		return "", ErrChallengeNotFound
+	}
+	_ = redis.Del("auth:challenge:" + nonce)
 
-	return claims.Email, nil
+	var expected string
+	var expiresAt int64
+	if _, err := fmt.Sscanf(strings.Replace(stored, "|", " ", 1), "%s %d", &expected, &expiresAt); err != nil {
+		return "", ErrChallengeNotFound
+	}
+	if expected != address {
+		return "", ErrChallengeMismatch
+	}
+
+	pub, err := utils.ParsePublicKeyHex(publicKeyHex)
+	if err != nil {
+		return "", err
+	}
+	if utils.PublicKeyToAddress(pub) != address {
+		return "", ErrAddressMismatch
+	}
+
+	hash := sha256.Sum256([]byte(ChallengeMessage(address, nonce, expiresAt)))
+	if !utils.VerifySignatureHex(pub, hash[:], signatureHex) {
+		return "", ErrInvalidSignature
+	}
+
+	return address, nil
 }
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/p2p"
+	"fmt"
+)
+
+// BootstrapResult ringkasan fast bootstrap dari snapshot peer
+type BootstrapResult struct {
+	Peer     string `json:"peer"`
+	Signer   string `json:"signer"` // fingerprint node penandatangan snapshot
+	Height   int    `json:"height"`
+	Replayed int    `json:"replayed"`
+}
+
+// TrustedSnapshotSigners membaca SNAPSHOT_TRUSTED_KEYS (fingerprint, dipisah koma)
+func TrustedSnapshotSigners() []string {
+	return blockchain.TrustedSigners()
+}
+
+// BootstrapFromPeer memasang snapshot peer lalu me-replay block setelah
+// height snapshot. Snapshot hanya diterima dari signer di trusted.
+func BootstrapFromPeer(peer string, trusted []string, force bool) (BootstrapResult, error) {
+	result := BootstrapResult{Peer: peer}
+	if len(trusted) == 0 {
+		return result, fmt.Errorf("%w: no trusted signers configured (SNAPSHOT_TRUSTED_KEYS)", blockchain.ErrUntrustedSigner)
+	}
+
+	signed, err := p2p.FetchSnapshotFrom(peer)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch snapshot: %v", err)
+	}
+	snap, err := blockchain.InstallSnapshot(signed, trusted, force)
+	if err != nil {
+		return result, err
+	}
+	result.Signer = signed.SignerFingerprint()
+	result.Height = snap.Height
+
+	blocks, err := p2p.FetchBlocksSince(peer, snap.Height+1)
+	if err != nil {
+		return result, fmt.Errorf("snapshot installed but block replay failed: %v", err)
+	}
+	for _, b := range blocks {
+		if !blockchain.TryAddBlock(b) {
+			return result, fmt.Errorf("block %d from %s is not valid", b.Index, peer)
+		}
+		result.Replayed++
+	}
+	return result, nil
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const certificateVersion = 1
+
+// CertificateContent isi sertifikat chain of custody yang ditandatangani node.
+// JSON ini (certificate.json) ikut disematkan di PDF bersama signature-nya.
+type CertificateContent struct {
+	Version     int                         `json:"version"`
+	TrackerID   string                      `json:"tracker_id"`
+	Type        string                      `json:"type"`
+	Privacy     string                      `json:"privacy"`
+	Status      string                      `json:"status"`
+	Creator     string                      `json:"creator"`
+	CreatorAddr string                      `json:"creator_address"`
+	CreatedAt   int64                       `json:"created_at"`
+	Document    *models.DocumentFingerprint `json:"document,omitempty"`
+	Checkpoints []CertificateCheckpoint     `json:"checkpoints"`
+	Inclusion   BlockInclusion              `json:"inclusion"`
+	GeneratedAt int64                       `json:"generated_at"`
+	Node        string                      `json:"node"` // fingerprint key signing node
+}
+
+// CertificateCheckpoint satu checkpoint di sertifikat
+type CertificateCheckpoint struct {
+	Step        int                   `json:"step"`
+	Actor       string                `json:"actor"`
+	Address     string                `json:"address"`
+	Role        string                `json:"role"`
+	Type        string                `json:"type"`
+	Company     string                `json:"company,omitempty"`
+	IsCompleted bool                  `json:"is_completed"`
+	CompletedAt int64                 `json:"completed_at,omitempty"`
+	Evidence    []CertificateEvidence `json:"evidence"`
+}
+
+// CertificateEvidence evidence checkpoint tanpa path dan key
+type CertificateEvidence struct {
+	Hash        string `json:"hash"`
+	Label       string `json:"label,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	UploadedAt  int64  `json:"uploaded_at,omitempty"`
+}
+
+// Certificate sertifikat PDF beserta detached signature atas Payload
+type Certificate struct {
+	Content   CertificateContent
+	Payload   []byte // certificate.json
+	Digest    string // sha256(Payload) hex
+	Signature string // ECDSA P-256 DER atas sha256(Payload), base64
+	PublicKey string // public key node, hex uncompressed
+	PDF       []byte
+}
+
+// TrackerCertificate membuat sertifikat chain of custody untuk peserta atau auditor tracker
+func TrackerCertificate(email, trackerID string) (Certificate, error) {
+	t, inclusion := locateTracker(trackerID)
+	if t == nil {
+		return Certificate{}, utils.ErrNotFound
+	}
+	if !CanViewTracker(email, *t) {
+		return Certificate{}, ErrTrackerAccessDenied
+	}
+	pub, err := keymanager.PublicKey()
+	if err != nil {
+		return Certificate{}, err
+	}
+
+	content := CertificateContent{
+		Version:     certificateVersion,
+		TrackerID:   t.ID,
+		Type:        t.Type,
+		Privacy:     t.Privacy,
+		Status:      t.Status,
+		Creator:     t.Creator,
+		CreatorAddr: t.CreatorAddr,
+		CreatedAt:   t.CreatedAt,
+		Document:    t.Document,
+		Checkpoints: make([]CertificateCheckpoint, len(t.Checkpoints)),
+		Inclusion:   inclusion,
+		GeneratedAt: time.Now().Unix(),
+		Node:        keymanager.Fingerprint(pub),
+	}
+	for i, cp := range t.Checkpoints {
+		c := CertificateCheckpoint{
+			Step:        i + 1,
+			Actor:       cp.Email,
+			Address:     cp.Address,
+			Role:        cp.Role,
+			Type:        cp.Type,
+			Company:     cp.Company,
+			IsCompleted: cp.IsCompleted,
+			CompletedAt: cp.CompletedAt,
+			Evidence:    []CertificateEvidence{},
+		}
+		for _, item := range cp.EvidenceItems() {
+			c.Evidence = append(c.Evidence, CertificateEvidence{
+				Hash:        item.Hash,
+				Label:       item.Label,
+				ContentType: item.ContentType,
+				Size:        item.Size,
+				UploadedAt:  item.UploadedAt,
+			})
+		}
+		content.Checkpoints[i] = c
+	}
+
+	payload, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return Certificate{}, err
+	}
+	sig, err := keymanager.Sign(payload)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("failed to sign certificate: %v", err)
+	}
+	digest := sha256.Sum256(payload)
+	cert := Certificate{
+		Content:   content,
+		Payload:   payload,
+		Digest:    hex.EncodeToString(digest[:]),
+		Signature: base64.StdEncoding.EncodeToString(sig),
+		PublicKey: hex.EncodeToString(utils.SerializePublicKey(pub)),
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return Certificate{}, err
+	}
+	doc := renderCertificate(cert)
+	doc.Attach("certificate.json", "Signed certificate content", payload)
+	doc.Attach("certificate.json.sig", "Node signature (ECDSA P-256, SHA-256, DER)", sig)
+	doc.Attach("node-public-key.pem", "Node signing public key", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	cert.PDF = doc.Bytes()
+	return cert, nil
+}
+
+// A4 dalam point
+const (
+	certPageWidth  = 595.28
+	certPageHeight = 841.89
+	certMargin     = 48.0
+	certLabelWidth = 120.0
+)
+
+// certLayout menulis baris demi baris dan membuat halaman baru saat penuh
+type certLayout struct {
+	doc   *utils.PDF
+	pages []*utils.PDFPage
+	page  *utils.PDFPage
+	y     float64
+}
+
+func (l *certLayout) newPage() {
+	l.page = l.doc.AddPage(certPageWidth, certPageHeight)
+	l.pages = append(l.pages, l.page)
+	l.y = certMargin
+}
+
+// ensure pindah halaman jika tinggi h tidak muat (sisakan ruang footer)
+func (l *certLayout) ensure(h float64) {
+	if l.y+h > certPageHeight-certMargin-20 {
+		l.newPage()
+	}
+}
+
+func (l *certLayout) heading(s string) {
+	l.ensure(40)
+	l.y += 18
+	l.page.Fill(0.1, 0.2, 0.4)
+	l.page.Text(certMargin, l.y, utils.FontBold, 12, s)
+	l.y += 5
+	l.page.Stroke(0.1, 0.2, 0.4, 0.8)
+	l.page.Line(certMargin, l.y, certPageWidth-certMargin, l.y)
+	l.y += 6
+}
+
+func (l *certLayout) subheading(s string) {
+	l.ensure(30)
+	l.y += 14
+	l.page.Fill(0, 0, 0)
+	l.page.Text(certMargin, l.y, utils.FontBold, 10, s)
+	l.y += 4
+}
+
+// field baris label: nilai; nilai panjang dibungkus ke baris berikutnya
+func (l *certLayout) field(label, value string, font utils.PDFFont) {
+	if value == "" {
+		value = "-"
+	}
+	size := 9.0
+	if font == utils.FontMono {
+		size = 8
+	}
+	lines := wrapText(value, font, size, certPageWidth-2*certMargin-certLabelWidth)
+	l.ensure(12 * float64(len(lines)))
+	for i, line := range lines {
+		l.y += 12
+		if i == 0 {
+			l.page.Fill(0.35, 0.35, 0.35)
+			l.page.Text(certMargin, l.y, utils.FontRegular, 9, label)
+		}
+		l.page.Fill(0, 0, 0)
+		l.page.Text(certMargin+certLabelWidth, l.y, font, size, line)
+	}
+}
+
+func (l *certLayout) paragraph(s string, size float64, r, g, b float64) {
+	for _, line := range wrapText(s, utils.FontRegular, size, certPageWidth-2*certMargin) {
+		l.ensure(size + 4)
+		l.y += size + 4
+		l.page.Fill(r, g, b)
+		l.page.Text(certMargin, l.y, utils.FontRegular, size, line)
+	}
+}
+
+// wrapText membungkus teks per kata; kata yang lebih panjang dari satu baris
+// (hash, signature) dipotong per karakter
+func wrapText(s string, font utils.PDFFont, size, width float64) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if utils.TextWidth(font, size, candidate) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		for utils.TextWidth(font, size, word) > width {
+			n := len(word)
+			for n > 1 && utils.TextWidth(font, size, word[:n]) > width {
+				n--
+			}
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		current = word
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func certTime(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+func renderCertificate(cert Certificate) *utils.PDF {
+	c := cert.Content
+	l := &certLayout{doc: utils.NewPDF()}
+	l.newPage()
+
+	l.y += 20
+	l.page.Fill(0.1, 0.2, 0.4)
+	l.page.Text(certMargin, l.y, utils.FontBold, 20, "Chain of Custody Certificate")
+	l.y += 4
+	l.paragraph("Issued by node "+c.Node+" on "+certTime(c.GeneratedAt)+". This certificate lists the recorded journey of the tracked document and is signed by the issuing node.", 9, 0.3, 0.3, 0.3)
+	if !c.Inclusion.Confirmed {
+		l.y += 4
+		l.paragraph("WARNING: this tracker is not yet included in a block. Its data may still change until it is mined.", 10, 0.75, 0.1, 0.1)
+	}
+
+	l.heading("Tracker")
+	l.field("Tracker ID", c.TrackerID, utils.FontMono)
+	l.field("Type", c.Type, utils.FontRegular)
+	l.field("Privacy", c.Privacy, utils.FontRegular)
+	l.field("Status", c.Status, utils.FontRegular)
+	l.field("Creator", c.Creator, utils.FontRegular)
+	l.field("Creator address", c.CreatorAddr, utils.FontMono)
+	l.field("Created", certTime(c.CreatedAt), utils.FontRegular)
+
+	if d := c.Document; d != nil {
+		l.heading("Document")
+		l.field("SHA-256", d.Hash, utils.FontMono)
+		if d.Name != "" {
+			l.field("Name", d.Name, utils.FontRegular)
+		}
+		if d.ContentType != "" {
+			l.field("Content type", d.ContentType, utils.FontRegular)
+		}
+		if d.Size > 0 {
+			l.field("Size", strconv.FormatInt(d.Size, 10)+" bytes", utils.FontRegular)
+		}
+		if len(d.PageHashes) > 0 {
+			l.field("Page hashes", strconv.Itoa(len(d.PageHashes))+" registered", utils.FontRegular)
+		}
+		l.field("Registered", certTime(d.RegisteredAt), utils.FontRegular)
+	}
+
+	l.heading("Checkpoints")
+	for _, cp := range c.Checkpoints {
+		l.subheading(fmt.Sprintf("%d. %s", cp.Step, cp.Role))
+		l.field("Actor", cp.Actor, utils.FontRegular)
+		l.field("Address", cp.Address, utils.FontMono)
+		l.field("Type", cp.Type, utils.FontRegular)
+		if cp.Company != "" {
+			l.field("Company", cp.Company, utils.FontRegular)
+		}
+		status := "Pending"
+		if cp.IsCompleted {
+			status = "Completed " + certTime(cp.CompletedAt)
+		}
+		l.field("Status", status, utils.FontRegular)
+		if len(cp.Evidence) == 0 {
+			l.field("Evidence", "none", utils.FontRegular)
+		}
+		for i, e := range cp.Evidence {
+			detail := e.ContentType
+			if e.Label != "" {
+				detail = e.Label + " (" + e.ContentType + ")"
+			}
+			if e.UploadedAt > 0 {
+				detail += ", uploaded " + certTime(e.UploadedAt)
+			}
+			l.field(fmt.Sprintf("Evidence %d", i+1), e.Hash, utils.FontMono)
+			l.field("", detail, utils.FontRegular)
+		}
+	}
+
+	l.heading("Block inclusion")
+	if in := c.Inclusion; in.Confirmed {
+		l.field("Block index", strconv.Itoa(in.BlockIndex), utils.FontRegular)
+		l.field("Block hash", in.BlockHash, utils.FontMono)
+		l.field("Block time", certTime(in.BlockTime), utils.FontRegular)
+		l.field("Confirmations", strconv.Itoa(in.Confirmations), utils.FontRegular)
+		l.field("Transaction hash", in.TxHash, utils.FontMono)
+		l.field("Merkle root", in.MerkleRoot, utils.FontMono)
+		for i, step := range in.MerkleProof {
+			side := "right"
+			if step.Left {
+				side = "left"
+			}
+			l.field(fmt.Sprintf("Proof step %d (%s)", i+1, side), step.Hash, utils.FontMono)
+		}
+		if in.Legacy {
+			l.paragraph("This block uses the legacy format: the block hash covers the transactions directly instead of the Merkle root.", 8, 0.3, 0.3, 0.3)
+		} else if !blockchain.VerifyMerkleProof(in.TxHash, in.MerkleProof, in.MerkleRoot) {
+			l.paragraph("WARNING: the Merkle proof does not match the block Merkle root.", 9, 0.75, 0.1, 0.1)
+		}
+	} else {
+		l.field("Block", "not yet mined (mempool)", utils.FontRegular)
+	}
+
+	l.heading("Node signature")
+	l.field("Algorithm", "ECDSA P-256 with SHA-256 over the embedded certificate.json", utils.FontRegular)
+	l.field("Signer", cert.Content.Node, utils.FontMono)
+	l.field("Public key", cert.PublicKey, utils.FontMono)
+	l.field("Content SHA-256", cert.Digest, utils.FontMono)
+	l.field("Signature", cert.Signature, utils.FontMono)
+	l.y += 6
+	l.paragraph("To verify, extract certificate.json, certificate.json.sig and node-public-key.pem from this PDF's attachments and run: openssl dgst -sha256 -verify node-public-key.pem -signature certificate.json.sig certificate.json", 8, 0.3, 0.3, 0.3)
+
+	for i, p := range l.pages {
+		p.Fill(0.45, 0.45, 0.45)
+		p.Stroke(0.75, 0.75, 0.75, 0.5)
+		p.Line(certMargin, certPageHeight-certMargin, certPageWidth-certMargin, certPageHeight-certMargin)
+		p.Text(certMargin, certPageHeight-certMargin+12, utils.FontRegular, 7, "Tracker "+c.TrackerID)
+		pageNo := fmt.Sprintf("Page %d of %d", i+1, len(l.pages))
+		p.Text(certPageWidth-certMargin-utils.TextWidth(utils.FontRegular, 7, pageNo), certPageHeight-certMargin+12, utils.FontRegular, 7, pageNo)
+	}
+	return l.doc
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"doc-tracker/blockchain"
+	"doc-tracker/evidence"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrDocumentInvalid  = errors.New("invalid document fingerprint")
+	ErrDocumentTooLarge = errors.New("document too large")
+)
+
+// Batas jumlah halaman yang boleh didaftarkan per dokumen
+const maxDocumentPages = 10000
+
+// DocumentVerification hasil pencocokan file terhadap dokumen yang terdaftar
+type DocumentVerification struct {
+	Hash    string          `json:"hash"`
+	Size    int64           `json:"size,omitempty"` // hanya jika file diunggah
+	Matched bool            `json:"matched"`
+	Matches []DocumentMatch `json:"matches"`
+}
+
+// DocumentMatch tracker yang dokumennya cocok dengan file
+type DocumentMatch struct {
+	Match      string        `json:"match"`          // document / page
+	Page       int           `json:"page,omitempty"` // nomor halaman (mulai 1) jika Match page
+	Confirmed  bool          `json:"confirmed"`      // sudah masuk block
+	BlockIndex int           `json:"block_index,omitempty"`
+	BlockHash  string        `json:"block_hash,omitempty"`
+	Tracker    PublicTracker `json:"tracker"`
+}
+
+// PublicTracker ringkasan tracker yang aman ditampilkan ke siapa saja yang
+// memegang dokumennya: tanpa email, note, attachment maupun key evidence
+type PublicTracker struct {
+	ID          string                      `json:"id"`
+	Type        string                      `json:"type"`
+	Privacy     string                      `json:"privacy"`
+	Status      string                      `json:"status"`
+	CreatorAddr string                      `json:"creator_address"`
+	CreatedAt   int64                       `json:"created_at"`
+	Document    *models.DocumentFingerprint `json:"document,omitempty"`
+	Checkpoints []PublicCheckpoint          `json:"checkpoints"`
+}
+
+// PublicCheckpoint riwayat satu checkpoint tanpa identitas pemiliknya
+type PublicCheckpoint struct {
+	Step          int    `json:"step"`
+	Address       string `json:"address"`
+	Type          string `json:"type"`
+	Role          string `json:"role"`
+	Company       string `json:"company,omitempty"` // hanya tracker public
+	IsCompleted   bool   `json:"is_completed"`
+	CompletedAt   int64  `json:"completed_at,omitempty"`
+	DueAt         int64  `json:"due_at,omitempty"`
+	EvidenceCount int    `json:"evidence_count"`
+}
+
+// DocumentMaxBytes batas ukuran file dokumen yang di-hash node
+func DocumentMaxBytes() int64 {
+	return int64(utils.GetEnvInt("DOCUMENT_MAX_BYTES", 200<<20))
+}
+
+// FingerprintDocument menghitung sidik jari dokumen dari stream tanpa menyimpan isinya
+func FingerprintDocument(r io.Reader) (models.DocumentFingerprint, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return models.DocumentFingerprint{}, fmt.Errorf("failed to read document: %w", err)
+	}
+	if len(head) == 0 {
+		return models.DocumentFingerprint{}, fmt.Errorf("%w: empty file", ErrDocumentInvalid)
+	}
+
+	max := DocumentMaxBytes()
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, &maxBytesReader{r: br, max: max})
+	if errors.Is(err, ErrEvidenceTooLarge) {
+		return models.DocumentFingerprint{}, fmt.Errorf("%w (max %d bytes)", ErrDocumentTooLarge, max)
+	}
+	if err != nil {
+		return models.DocumentFingerprint{}, fmt.Errorf("failed to read document: %w", err)
+	}
+	return models.DocumentFingerprint{
+		Hash:        hex.EncodeToString(hasher.Sum(nil)),
+		ContentType: http.DetectContentType(head),
+		Size:        n,
+	}, nil
+}
+
+// normalizeDocument memvalidasi dokumen yang dideklarasikan saat tracker dibuat
+func normalizeDocument(t *models.Tracker) error {
+	d := t.Document
+	if d == nil {
+		return nil
+	}
+	d.Hash = strings.ToLower(strings.TrimSpace(d.Hash))
+	if evidence.ValidHash(d.Hash) != nil {
+		return fmt.Errorf("%w: hash must be a hex sha256", ErrDocumentInvalid)
+	}
+	if d.Size < 0 || len(d.Name) > 255 || len(d.ContentType) > 255 {
+		return ErrDocumentInvalid
+	}
+	if len(d.PageHashes) > maxDocumentPages {
+		return fmt.Errorf("%w: too many pages (max %d)", ErrDocumentInvalid, maxDocumentPages)
+	}
+	for i, h := range d.PageHashes {
+		d.PageHashes[i] = strings.ToLower(strings.TrimSpace(h))
+		if evidence.ValidHash(d.PageHashes[i]) != nil {
+			return fmt.Errorf("%w: page %d hash must be a hex sha256", ErrDocumentInvalid, i+1)
+		}
+	}
+	d.Name = strings.TrimSpace(d.Name)
+	d.RegisteredAt = t.CreatedAt
+	return nil
+}
+
+// VerifyDocument mencari tracker yang dokumennya (atau salah satu halamannya)
+// memiliki hash yang sama. Tracker di block didahulukan dari mempool.
+func VerifyDocument(hash string) (DocumentVerification, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if evidence.ValidHash(hash) != nil {
+		return DocumentVerification{}, fmt.Errorf("%w: hash must be a hex sha256", ErrDocumentInvalid)
+	}
+
+	result := DocumentVerification{Hash: hash, Matches: []DocumentMatch{}}
+	seen := make(map[string]bool)
+	add := func(t *models.Tracker, block *models.Block) {
+		if t.Document == nil || seen[t.ID] {
+			return
+		}
+		match := DocumentMatch{Match: "document"}
+		if t.Document.Hash != hash {
+			page := documentPage(t.Document, hash)
+			if page == 0 {
+				return
+			}
+			match.Match, match.Page = "page", page
+		}
+		if block != nil {
+			match.Confirmed, match.BlockIndex, match.BlockHash = true, block.Index, block.Hash
+		}
+		match.Tracker = publicTracker(*t)
+		seen[t.ID] = true
+		result.Matches = append(result.Matches, match)
+	}
+
+	for _, block := range blockchain.GetAllBlocks() {
+		for i := range block.Transactions {
+			add(&block.Transactions[i], &block)
+		}
+	}
+	err := mempool.Iterate(func(tx *models.Tracker) error {
+		add(tx, nil)
+		return nil
+	}, "")
+	if err != nil {
+		return DocumentVerification{}, err
+	}
+	result.Matched = len(result.Matches) > 0
+	return result, nil
+}
+
+func documentPage(d *models.DocumentFingerprint, hash string) int {
+	for i, h := range d.PageHashes {
+		if h == hash {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func publicTracker(t models.Tracker) PublicTracker {
+	p := PublicTracker{
+		ID:          t.ID,
+		Type:        t.Type,
+		Privacy:     t.Privacy,
+		Status:      t.Status,
+		CreatorAddr: t.CreatorAddr,
+		CreatedAt:   t.CreatedAt,
+		Checkpoints: make([]PublicCheckpoint, len(t.Checkpoints)),
+	}
+	if t.Document != nil {
+		doc := *t.Document
+		if t.Privacy != "public" {
+			// Nama file bisa sensitif; cukup hash dan ukuran untuk tracker privat
+			doc.Name, doc.ContentType = "", ""
+		}
+		p.Document = &doc
+	}
+	for i, cp := range t.Checkpoints {
+		p.Checkpoints[i] = PublicCheckpoint{
+			Step:          i + 1,
+			Address:       cp.Address,
+			Type:          cp.Type,
+			Role:          cp.Role,
+			IsCompleted:   cp.IsCompleted,
+			CompletedAt:   cp.CompletedAt,
+			DueAt:         cp.DueAt,
+			EvidenceCount: len(cp.EvidenceItems()),
+		}
+		if t.Privacy == "public" {
+			p.Checkpoints[i].Company = cp.Company
+		}
+	}
+	return p
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"crypto/sha256"
+	"doc-tracker/blockchain"
+	"doc-tracker/keystore"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrTrackerMined        = errors.New("tracker is already mined and can no longer be changed")
+	ErrNotTrackerCreator   = errors.New("only the tracker creator can manage viewers")
+	ErrNoEnvelope          = errors.New("no content key for this address")
+	ErrCannotRevokeCreator = errors.New("the creator cannot be revoked")
+	ErrWrappedKeyRequired  = errors.New("creator wallet is non-custodial, wrap the content key on the client")
+	ErrAttachmentNotFound  = errors.New("attachment not found")
+	ErrAttachmentTooLarge  = errors.New("attachment is too large")
+	ErrAttachmentInvalid   = errors.New("attachment name and data are required and names must be unique")
+)
+
+// TrackerContent adalah note dan daftar attachment tracker yang sudah didekripsi
+type TrackerContent struct {
+	Note        string              `json:"note"`
+	Attachments []models.Attachment `json:"attachments"` // tanpa ciphertext
+}
+
+// sealTrackerContent mengenkripsi note dan attachment tracker dengan satu content
+// key, lalu membungkus key itu untuk setiap viewer (address -> wallet).
+func sealTrackerContent(t *models.Tracker, viewers map[string]WalletInfo) error {
+	if t.Note == "" && len(t.Attachments) == 0 {
+		return nil
+	}
+
+	key, err := utils.NewContentKey()
+	if err != nil {
+		return err
+	}
+
+	if t.Note != "" {
+		if t.EncryptedContent, err = utils.EncryptContent(key, []byte(t.Note), utils.ContentAssociatedData(t.ID, "note")); err != nil {
+			return err
+		}
+		t.Note = ""
+	}
+
+	maxSize := int64(utils.GetEnvInt("ATTACHMENT_MAX_BYTES", 1<<20))
+	seen := map[string]bool{}
+	for i, a := range t.Attachments {
+		if a.Name == "" || a.Data == "" || seen[a.Name] || strings.ContainsAny(a.Name, "/\\") {
+			return ErrAttachmentInvalid
+		}
+		seen[a.Name] = true
+
+		data, err := base64.StdEncoding.DecodeString(a.Data)
+		if err != nil {
+			return fmt.Errorf("attachment %s: invalid base64", a.Name)
+		}
+		if int64(len(data)) > maxSize {
+			return ErrAttachmentTooLarge
+		}
+
+		sum := sha256.Sum256(data)
+		ct, err := utils.EncryptContent(key, data, utils.ContentAssociatedData(t.ID, "attachment:"+a.Name))
+		if err != nil {
+			return err
+		}
+		t.Attachments[i].Size = int64(len(data))
+		t.Attachments[i].Hash = hex.EncodeToString(sum[:])
+		t.Attachments[i].Ciphertext = ct
+		t.Attachments[i].Data = ""
+	}
+
+	return wrapForViewers(t, key, viewers)
+}
+
+func wrapForViewers(t *models.Tracker, key []byte, viewers map[string]WalletInfo) error {
+	t.EncryptedNotes = make(map[string]string, len(viewers))
+	for address, w := range viewers {
+		wrapped, err := utils.WrapContentKey(key, w.EncryptionKey, t.ID, address)
+		if err != nil {
+			return fmt.Errorf("failed to wrap content key for %s: %v", address, err)
+		}
+		t.EncryptedNotes[address] = wrapped
+	}
+	return nil
+}
+
+// openContentKey membuka content key tracker dengan wallet custodial email
+func openContentKey(email string, t *models.Tracker, purpose string) ([]byte, error) {
+	w, err := GetWalletPublic(email)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, ok := t.EncryptedNotes[w.Address]
+	if !ok {
+		return nil, ErrNoEnvelope
+	}
+
+	unlocked, err := UnlockWallet(email, purpose)
+	if err != nil {
+		return nil, err
+	}
+	return utils.UnwrapContentKey(wrapped, unlocked.EncryptionPrivateKey, t.ID, w.Address)
+}
+
+// findTrackerForContent mencari tracker di mempool lalu di chain
+func findTrackerForContent(trackerID string) (*models.Tracker, error) {
+	if t := mempool.GetByID(trackerID); t != nil {
+		return t, nil
+	}
+	t, err := GetTrackerByID(trackerID)
+	if err != nil {
+		return nil, utils.ErrNotFound
+	}
+	return &t, nil
+}
+
+// DecryptTrackerContent membuka note dan metadata attachment untuk viewer yang terdaftar
+func DecryptTrackerContent(email, trackerID string) (TrackerContent, error) {
+	t, err := findTrackerForContent(trackerID)
+	if err != nil {
+		return TrackerContent{}, err
+	}
+	key, err := openContentKey(email, t, "decrypt-content")
+	if err != nil {
+		return TrackerContent{}, err
+	}
+
+	var content TrackerContent
+	if t.EncryptedContent != "" {
+		note, err := utils.DecryptContent(key, t.EncryptedContent, utils.ContentAssociatedData(t.ID, "note"))
+		if err != nil {
+			return TrackerContent{}, err
+		}
+		content.Note = string(note)
+	}
+	for _, a := range t.Attachments {
+		a.Ciphertext = ""
+		content.Attachments = append(content.Attachments, a)
+	}
+	return content, nil
+}
+
+// DecryptAttachment membuka satu attachment dan memverifikasi hash-nya
+func DecryptAttachment(email, trackerID, name string) (models.Attachment, []byte, error) {
+	t, err := findTrackerForContent(trackerID)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+
+	var att *models.Attachment
+	for i := range t.Attachments {
+		if t.Attachments[i].Name == name {
+			att = &t.Attachments[i]
+			break
+		}
+	}
+	if att == nil {
+		return models.Attachment{}, nil, ErrAttachmentNotFound
+	}
+
+	key, err := openContentKey(email, t, "decrypt-attachment")
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	data, err := utils.DecryptContent(key, att.Ciphertext, utils.ContentAssociatedData(t.ID, "attachment:"+name))
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != att.Hash {
+		return models.Attachment{}, nil, fmt.Errorf("attachment %s hash mismatch", name)
+	}
+
+	meta := *att
+	meta.Ciphertext = ""
+	return meta, data, nil
+}
+
+// editableTracker mengembalikan salinan tracker di mempool yang boleh diubah creator
+func editableTracker(creatorEmail, trackerID string) (models.Tracker, error) {
+	t := mempool.GetByID(trackerID)
+	if blockchain.IsTrackerInBlockchain(trackerID) {
+		return models.Tracker{}, ErrTrackerMined
+	}
+	if t == nil {
+		return models.Tracker{}, utils.ErrNotFound
+	}
+	if t.Creator != creatorEmail {
+		return models.Tracker{}, ErrNotTrackerCreator
+	}
+	if len(t.EncryptedNotes) == 0 {
+		return models.Tracker{}, ErrNoEnvelope
+	}
+
+	copied := *t
+	copied.EncryptedNotes = make(map[string]string, len(t.EncryptedNotes))
+	for k, v := range t.EncryptedNotes {
+		copied.EncryptedNotes[k] = v
+	}
+	copied.Checkpoints = append([]models.Checkpoint(nil), t.Checkpoints...)
+	copied.Attachments = append([]models.Attachment(nil), t.Attachments...)
+	return copied, nil
+}
+
+// GrantViewer menambahkan envelope content key untuk viewerEmail. Creator
+// non-custodial harus mengirim wrappedKey hasil WrapContentKey di client; key
+// evidence yang sudah ada hanya dibungkus ulang untuk creator custodial.
+func GrantViewer(creatorEmail, trackerID, viewerEmail, wrappedKey string) (string, error) {
+	t, err := editableTracker(creatorEmail, trackerID)
+	if err != nil {
+		return "", err
+	}
+
+	viewer := GetOrCreateWallet(viewerEmail)
+	if viewer.EncryptionKey == nil {
+		return "", fmt.Errorf("wallet for %s is not available", viewerEmail)
+	}
+
+	if wrappedKey == "" {
+		key, err := openContentKey(creatorEmail, &t, "grant-viewer")
+		if errors.Is(err, keystore.ErrNonCustodial) {
+			return viewer.Address, ErrWrappedKeyRequired
+		}
+		if err != nil {
+			return "", err
+		}
+		if wrappedKey, err = utils.WrapContentKey(key, viewer.EncryptionKey, t.ID, viewer.Address); err != nil {
+			return "", err
+		}
+		if err := grantEvidenceKeys(creatorEmail, &t, viewer); err != nil {
+			return "", err
+		}
+	} else if !strings.HasPrefix(wrappedKey, utils.NotePrefix) {
+		return "", fmt.Errorf("wrapped_key must use the %s format", utils.NotePrefix)
+	}
+
+	t.EncryptedNotes[viewer.Address] = wrappedKey
+	mempool.UpdateTracker(&t)
+	return viewer.Address, nil
+}
+
+// RevokeViewer menghapus envelope viewerEmail. Jika wallet creator custodial,
+// content key dirotasi sehingga key lama yang mungkin tersimpan viewer tidak
+// berlaku lagi; rotated=false berarti hanya envelope yang dihapus. Key evidence
+// viewer ikut dihapus, ciphertext evidence tidak dienkripsi ulang.
+func RevokeViewer(creatorEmail, trackerID, viewerEmail string) (bool, error) {
+	t, err := editableTracker(creatorEmail, trackerID)
+	if err != nil {
+		return false, err
+	}
+
+	viewer, err := GetWalletPublic(viewerEmail)
+	if err != nil {
+		return false, err
+	}
+	if viewer.HasAddress(t.CreatorAddr) {
+		return false, ErrCannotRevokeCreator
+	}
+
+	// Envelope lama bisa tersimpan dengan address derivasi lama
+	found := false
+	for _, addr := range []string{viewer.Address, viewer.LegacyAddress} {
+		if _, ok := t.EncryptedNotes[addr]; ok && addr != "" {
+			delete(t.EncryptedNotes, addr)
+			revokeEvidenceKeys(&t, addr)
+			found = true
+		}
+	}
+	if !found {
+		return false, ErrNoEnvelope
+	}
+	for i, cp := range t.Checkpoints {
+		if viewer.HasAddress(cp.Address) {
+			t.Checkpoints[i].IsViewable = false
+			t.Checkpoints[i].EncryptedNote = ""
+		}
+	}
+
+	rotated := true
+	if err := rotateContentKey(creatorEmail, &t); err != nil {
+		if !errors.Is(err, keystore.ErrNonCustodial) {
+			return false, err
+		}
+		rotated = false
+	}
+
+	mempool.UpdateTracker(&t)
+	return rotated, nil
+}
+
+// rotateContentKey mengenkripsi ulang konten dengan key baru untuk viewer yang tersisa
+func rotateContentKey(creatorEmail string, t *models.Tracker) error {
+	oldKey, err := openContentKey(creatorEmail, t, "revoke-viewer")
+	if err != nil {
+		return err
+	}
+	newKey, err := utils.NewContentKey()
+	if err != nil {
+		return err
+	}
+
+	if t.EncryptedContent != "" {
+		ad := utils.ContentAssociatedData(t.ID, "note")
+		note, err := utils.DecryptContent(oldKey, t.EncryptedContent, ad)
+		if err != nil {
+			return err
+		}
+		if t.EncryptedContent, err = utils.EncryptContent(newKey, note, ad); err != nil {
+			return err
+		}
+	}
+	for i, a := range t.Attachments {
+		ad := utils.ContentAssociatedData(t.ID, "attachment:"+a.Name)
+		data, err := utils.DecryptContent(oldKey, a.Ciphertext, ad)
+		if err != nil {
+			return err
+		}
+		if t.Attachments[i].Ciphertext, err = utils.EncryptContent(newKey, data, ad); err != nil {
+			return err
+		}
+	}
+
+	viewers := make(map[string]WalletInfo, len(t.EncryptedNotes))
+	for address := range t.EncryptedNotes {
+		email, ok := GetEmailByAddress(address)
+		if !ok {
+			return fmt.Errorf("wallet for address %s not found", address)
+		}
+		w, err := GetWalletPublic(email)
+		if err != nil {
+			return err
+		}
+		viewers[address] = w
+	}
+	return wrapForViewers(t, newKey, viewers)
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"doc-tracker/evidence"
+	"doc-tracker/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EvidenceOK       = "ok"
+	EvidenceMissing  = "missing"
+	EvidenceTampered = "tampered"
+	EvidenceError    = "error"
+)
+
+var (
+	ErrAuditRunning        = errors.New("an evidence audit is already running")
+	ErrAuditNotFound       = errors.New("no evidence audit has run yet")
+	ErrTrackerAccessDenied = errors.New("you are not a participant of this tracker")
+)
+
+var evidenceAuditFile = "data/evidence_audit.json"
+
+// EvidenceCheck hasil hash ulang satu evidence checkpoint
+type EvidenceCheck struct {
+	TrackerID  string `json:"tracker_id"`
+	Checkpoint string `json:"checkpoint_address"`
+	Email      string `json:"email"`
+	Label      string `json:"label,omitempty"`
+	Path       string `json:"path"`
+	Expected   string `json:"expected_hash"`
+	Encrypted  bool   `json:"encrypted,omitempty"` // Expected = hash ciphertext di store
+	Actual     string `json:"actual_hash,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EvidenceAuditReport ringkasan audit seluruh evidence; Problems hanya berisi
+// evidence yang hilang, berubah atau gagal dibaca
+type EvidenceAuditReport struct {
+	StartedAt  int64           `json:"started_at"`
+	FinishedAt int64           `json:"finished_at"`
+	Trackers   int             `json:"trackers"`
+	Checked    int             `json:"checked"`
+	OK         int             `json:"ok"`
+	Missing    int             `json:"missing"`
+	Tampered   int             `json:"tampered"`
+	Errors     int             `json:"errors"`
+	Problems   []EvidenceCheck `json:"problems"`
+}
+
+var evidenceAudit struct {
+	sync.Mutex
+	running bool
+	last    *EvidenceAuditReport
+}
+
+// VerifyTrackerEvidence meng-hash ulang setiap evidence checkpoint tracker
+// dari storage dan membandingkannya dengan hash on-chain. Evidence terenkripsi
+// tidak bisa dibuka node, jadi yang dibandingkan adalah hash ciphertext-nya.
+func VerifyTrackerEvidence(t models.Tracker) []EvidenceCheck {
+	var checks []EvidenceCheck
+	for _, cp := range t.Checkpoints {
+		for _, item := range cp.EvidenceItems() {
+			if item.Hash == "" {
+				continue
+			}
+			check := EvidenceCheck{
+				TrackerID:  t.ID,
+				Checkpoint: cp.Address,
+				Email:      cp.Email,
+				Label:      item.Label,
+				Path:       item.Path,
+				Expected:   strings.ToLower(item.Hash),
+			}
+			if len(item.Keys) > 0 {
+				check.Expected = evidence.RefHash(item.Path)
+				check.Encrypted = true
+			}
+			hash, size, err := hashEvidence(check.Expected, item.Path)
+			switch {
+			case errors.Is(err, evidence.ErrNotFound), errors.Is(err, evidence.ErrInvalidHash):
+				check.Status = EvidenceMissing
+			case err != nil:
+				check.Status = EvidenceError
+				check.Error = err.Error()
+			case hash != check.Expected:
+				check.Status = EvidenceTampered
+				check.Actual, check.Size = hash, size
+			default:
+				check.Status = EvidenceOK
+				check.Actual, check.Size = hash, size
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// VerifyTrackerEvidenceByID memverifikasi evidence tracker untuk user yang berhak
+func VerifyTrackerEvidenceByID(email, trackerID string) ([]EvidenceCheck, error) {
+	t, err := findTrackerForContent(trackerID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanViewTracker(email, *t) {
+		return nil, ErrTrackerAccessDenied
+	}
+	return VerifyTrackerEvidence(*t), nil
+}
+
+// RunEvidenceAudit memverifikasi evidence semua tracker (mempool dan chain)
+func RunEvidenceAudit() (EvidenceAuditReport, error) {
+	evidenceAudit.Lock()
+	if evidenceAudit.running {
+		evidenceAudit.Unlock()
+		return EvidenceAuditReport{}, ErrAuditRunning
+	}
+	evidenceAudit.running = true
+	evidenceAudit.Unlock()
+
+	defer func() {
+		evidenceAudit.Lock()
+		evidenceAudit.running = false
+		evidenceAudit.Unlock()
+	}()
+
+	report := EvidenceAuditReport{StartedAt: time.Now().Unix(), Problems: []EvidenceCheck{}}
+	trackers, err := GetDataTracker()
+	if err != nil {
+		return report, err
+	}
+	for _, t := range trackers {
+		report.Trackers++
+		for _, check := range VerifyTrackerEvidence(t) {
+			report.Checked++
+			switch check.Status {
+			case EvidenceOK:
+				report.OK++
+				continue
+			case EvidenceMissing:
+				report.Missing++
+			case EvidenceTampered:
+				report.Tampered++
+			default:
+				report.Errors++
+			}
+			report.Problems = append(report.Problems, check)
+			log.Printf("🚨 [EvidenceAudit] %s tracker=%s checkpoint=%s path=%s", check.Status, check.TrackerID, check.Checkpoint, check.Path)
+		}
+	}
+	report.FinishedAt = time.Now().Unix()
+
+	evidenceAudit.Lock()
+	evidenceAudit.last = &report
+	evidenceAudit.Unlock()
+	if err := saveEvidenceAudit(report); err != nil {
+		log.Printf("⚠️ Failed to save evidence audit report: %v", err)
+	}
+	return report, nil
+}
+
+// LastEvidenceAudit mengembalikan laporan audit terakhir (juga setelah restart)
+func LastEvidenceAudit() (EvidenceAuditReport, error) {
+	evidenceAudit.Lock()
+	defer evidenceAudit.Unlock()
+
+	if evidenceAudit.last != nil {
+		return *evidenceAudit.last, nil
+	}
+	data, err := os.ReadFile(evidenceAuditFile)
+	if os.IsNotExist(err) {
+		return EvidenceAuditReport{}, ErrAuditNotFound
+	}
+	if err != nil {
+		return EvidenceAuditReport{}, err
+	}
+	var report EvidenceAuditReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return EvidenceAuditReport{}, err
+	}
+	evidenceAudit.last = &report
+	return report, nil
+}
+
+// StartEvidenceAuditWorker menjalankan audit berkala setiap EVIDENCE_AUDIT_INTERVAL
+// (durasi Go, default 24h; "0" mematikan audit terjadwal)
+func StartEvidenceAuditWorker() {
+	interval := 24 * time.Hour
+	if v := os.Getenv("EVIDENCE_AUDIT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("⚠️ Invalid EVIDENCE_AUDIT_INTERVAL %q, using %s", v, interval)
+		} else {
+			interval = d
+		}
+	}
+	if interval <= 0 {
+		fmt.Println("[EvidenceAudit] Scheduled audit disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			report, err := RunEvidenceAudit()
+			if err != nil {
+				log.Printf("⚠️ [EvidenceAudit] %v", err)
+				continue
+			}
+			fmt.Printf("[EvidenceAudit] %d checked, %d missing, %d tampered, %d errors\n", report.Checked, report.Missing, report.Tampered, report.Errors)
+		}
+	}()
+}
+
+// hashEvidence men-stream evidence dari evidence store (atau lokasi lama) dan menghitung SHA-256
+func hashEvidence(hash, path string) (string, int64, error) {
+	r, _, err := evidence.Open(context.Background(), hash, path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func saveEvidenceAudit(report EvidenceAuditReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(evidenceAuditFile, data, 0600)
+}
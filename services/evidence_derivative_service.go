@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"doc-tracker/evidence"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrDerivativeNotReady = errors.New("evidence derivative is not available")
+
+const (
+	DerivativePending     = "pending"
+	DerivativeReady       = "ready"
+	DerivativeFailed      = "failed"
+	DerivativeUnsupported = "unsupported"
+
+	VariantThumbnail = "thumbnail"
+	VariantPreview   = "preview"
+	variantMetadata  = "metadata"
+)
+
+var derivativeDir = "data/derivatives"
+
+// EvidenceDerivatives turunan evidence: thumbnail, preview (halaman pertama
+// untuk PDF) dan metadata EXIF/GPS yang dipisah dari gambar. Dicatat per hash
+// object asli di store; hash on-chain tetap hash file asli. Untuk evidence
+// terenkripsi turunan dienkripsi dengan key evidence yang sama.
+type EvidenceDerivatives struct {
+	Object     string      `json:"object"` // hash object asli di store
+	Hash       string      `json:"hash"`   // hash plaintext on-chain
+	TrackerID  string      `json:"tracker_id"`
+	Checkpoint string      `json:"checkpoint_address"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Encrypted  bool        `json:"encrypted"`
+	Thumbnail  *Derivative `json:"thumbnail,omitempty"`
+	Preview    *Derivative `json:"preview,omitempty"`
+	Metadata   string      `json:"metadata,omitempty"` // evidence.Ref object ExifData (JSON)
+	HasGPS     bool        `json:"has_gps,omitempty"`
+	UpdatedAt  int64       `json:"updated_at"`
+}
+
+// Derivative satu gambar turunan (JPEG tanpa EXIF)
+type Derivative struct {
+	Path   string `json:"path"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+}
+
+// EvidenceThumbnail ringkasan thumbnail evidence untuk tampilan daftar tracker
+type EvidenceThumbnail struct {
+	Checkpoint  string `json:"checkpoint_address"`
+	Hash        string `json:"hash"`
+	Label       string `json:"label,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Status      string `json:"status"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+type derivativeJob struct {
+	trackerID  string
+	checkpoint string
+	info       EvidenceInfo
+	key        []byte
+}
+
+var (
+	derivativeOnce sync.Once
+	derivativeSem  chan struct{}
+)
+
+// queueEvidenceDerivatives memproses evidence di background setelah upload.
+// Key evidence hanya dibawa di memori selama proses berjalan.
+func queueEvidenceDerivatives(trackerID, checkpointAddr string, info EvidenceInfo, key []byte) {
+	object := evidence.RefHash(info.Path)
+	if object == "" {
+		return
+	}
+	derivativeOnce.Do(func() {
+		derivativeSem = make(chan struct{}, max(1, utils.GetEnvInt("EVIDENCE_PROCESS_WORKERS", 2)))
+	})
+
+	job := derivativeJob{trackerID: trackerID, checkpoint: checkpointAddr, info: info, key: key}
+	d := job.derivatives(object)
+	d.Status = DerivativePending
+	if err := saveDerivatives(d); err != nil {
+		log.Printf("[Evidence] failed to queue derivatives for %s: %v", object, err)
+		return
+	}
+	go func() {
+		derivativeSem <- struct{}{}
+		defer func() { <-derivativeSem }()
+		processEvidenceDerivatives(job, d)
+	}()
+}
+
+func (job derivativeJob) derivatives(object string) EvidenceDerivatives {
+	return EvidenceDerivatives{
+		Object:     object,
+		Hash:       job.info.Hash,
+		TrackerID:  job.trackerID,
+		Checkpoint: job.checkpoint,
+		Encrypted:  job.key != nil,
+		UpdatedAt:  time.Now().Unix(),
+	}
+}
+
+func processEvidenceDerivatives(job derivativeJob, d EvidenceDerivatives) {
+	err := buildDerivatives(job, &d)
+	switch {
+	case errors.Is(err, evidence.ErrUnsupportedPreview):
+		d.Status = DerivativeUnsupported
+	case err != nil:
+		d.Status = DerivativeFailed
+		d.Error = err.Error()
+		log.Printf("[Evidence] derivatives for %s failed: %v", d.Object, err)
+	default:
+		d.Status = DerivativeReady
+	}
+	d.UpdatedAt = time.Now().Unix()
+	if err := saveDerivatives(d); err != nil {
+		log.Printf("[Evidence] failed to save derivatives for %s: %v", d.Object, err)
+	}
+}
+
+func buildDerivatives(job derivativeJob, d *EvidenceDerivatives) error {
+	contentType := job.info.ContentType
+	if contentType != "application/pdf" && !strings.HasPrefix(contentType, "image/") {
+		return evidence.ErrUnsupportedPreview
+	}
+
+	rc, _, err := evidence.Open(context.Background(), job.info.Hash, job.info.Path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	var r io.Reader = rc
+	if job.key != nil {
+		if r, err = evidence.DecryptReader(rc, job.key, evidence.AssociatedData(job.trackerID, job.checkpoint)); err != nil {
+			return err
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(r, EvidenceMaxBytes(contentType)))
+	if err != nil {
+		return err
+	}
+
+	maxPixels := utils.GetEnvInt("EVIDENCE_MAX_PIXELS", 50_000_000)
+	previewSize := utils.GetEnvInt("EVIDENCE_PREVIEW_SIZE", 1280)
+	var preview image.Image
+	if contentType == "application/pdf" {
+		page, err := evidence.PDFPreview(data, previewSize, maxPixels)
+		if err != nil {
+			return err
+		}
+		preview = evidence.Resize(page, previewSize)
+	} else {
+		// Metadata EXIF (termasuk GPS) disimpan terpisah; turunan di-encode
+		// ulang sehingga tidak membawa EXIF
+		exif, exifErr := evidence.ReadExif(data)
+		if exifErr == nil {
+			meta, err := json.Marshal(exif)
+			if err != nil {
+				return err
+			}
+			if d.Metadata, _, err = putDerivative(job, variantMetadata, meta, "application/json"); err != nil {
+				return err
+			}
+			d.HasGPS = exif.GPS != nil
+		}
+		img, err := evidence.DecodeImage(data, maxPixels)
+		if err != nil {
+			return err
+		}
+		preview = evidence.Orient(evidence.Resize(img, previewSize), exif.Orientation)
+	}
+
+	if d.Preview, err = putDerivativeImage(job, VariantPreview, preview); err != nil {
+		return err
+	}
+	thumb := evidence.Resize(preview, utils.GetEnvInt("EVIDENCE_THUMB_SIZE", 256))
+	d.Thumbnail, err = putDerivativeImage(job, VariantThumbnail, thumb)
+	return err
+}
+
+func putDerivativeImage(job derivativeJob, variant string, img image.Image) (*Derivative, error) {
+	data, err := evidence.EncodeJPEG(img, 82)
+	if err != nil {
+		return nil, err
+	}
+	path, size, err := putDerivative(job, variant, data, "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	return &Derivative{Path: path, Width: b.Dx(), Height: b.Dy(), Size: size}, nil
+}
+
+func putDerivative(job derivativeJob, variant string, data []byte, contentType string) (string, int64, error) {
+	store, err := evidence.Default()
+	if err != nil {
+		return "", 0, err
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if job.key != nil {
+		ad := evidence.DerivativeAssociatedData(job.trackerID, job.checkpoint, variant)
+		if r, err = evidence.EncryptReader(r, job.key, ad); err != nil {
+			return "", 0, err
+		}
+		contentType = "application/octet-stream"
+	}
+	obj, err := store.Put(context.Background(), r, contentType)
+	if err != nil {
+		return "", 0, err
+	}
+	return evidence.Ref(obj.Hash), int64(len(data)), nil
+}
+
+// OpenEvidenceDerivative membuka thumbnail atau preview evidence hash (JPEG).
+// Aksesnya sama dengan evidence aslinya.
+func OpenEvidenceDerivative(email, hash, variant string) (io.ReadCloser, evidence.Object, error) {
+	t, cp, item, err := findEvidenceItem(hash)
+	if err != nil {
+		return nil, evidence.Object{}, err
+	}
+	d, err := loadDerivatives(evidence.RefHash(item.Path))
+	if err != nil {
+		return nil, evidence.Object{}, err
+	}
+	derived := d.Thumbnail
+	if variant == VariantPreview {
+		derived = d.Preview
+	}
+	if derived == nil {
+		return nil, evidence.Object{}, ErrDerivativeNotReady
+	}
+
+	r, err := openDerivative(email, t, cp, item, variant, derived.Path)
+	if err != nil {
+		return nil, evidence.Object{}, err
+	}
+	return r, evidence.Object{Hash: hash, Size: derived.Size, ContentType: "image/jpeg"}, nil
+}
+
+// EvidenceMetadata mengembalikan metadata EXIF/GPS evidence. Evidence terenkripsi
+// hanya untuk pemegang key evidence; evidence public hanya untuk yang boleh
+// melihat tracker.
+func EvidenceMetadata(email, hash string) (evidence.ExifData, error) {
+	t, cp, item, err := findEvidenceItem(hash)
+	if err != nil {
+		return evidence.ExifData{}, err
+	}
+	if len(item.Keys) == 0 && !CanViewTracker(email, t) {
+		return evidence.ExifData{}, ErrTrackerAccessDenied
+	}
+	d, err := loadDerivatives(evidence.RefHash(item.Path))
+	if err != nil {
+		return evidence.ExifData{}, err
+	}
+	if d.Metadata == "" {
+		if d.Status == DerivativePending {
+			return evidence.ExifData{}, ErrDerivativeNotReady
+		}
+		return evidence.ExifData{}, evidence.ErrNoExif
+	}
+
+	r, err := openDerivative(email, t, cp, item, variantMetadata, d.Metadata)
+	if err != nil {
+		return evidence.ExifData{}, err
+	}
+	defer r.Close()
+	var exif evidence.ExifData
+	if err := json.NewDecoder(r).Decode(&exif); err != nil {
+		return evidence.ExifData{}, err
+	}
+	return exif, nil
+}
+
+// TrackerThumbnails daftar thumbnail evidence tracker untuk tampilan daftar
+func TrackerThumbnails(email, trackerID string) ([]EvidenceThumbnail, error) {
+	t, err := findTrackerForContent(trackerID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanViewTracker(email, *t) {
+		return nil, ErrTrackerAccessDenied
+	}
+
+	thumbs := []EvidenceThumbnail{}
+	for _, cp := range t.Checkpoints {
+		for _, item := range cp.EvidenceItems() {
+			thumb := EvidenceThumbnail{
+				Checkpoint:  cp.Address,
+				Hash:        item.Hash,
+				Label:       item.Label,
+				ContentType: item.ContentType,
+				Status:      DerivativeUnsupported,
+			}
+			if d, err := loadDerivatives(evidence.RefHash(item.Path)); err == nil {
+				thumb.Status = d.Status
+				if d.Thumbnail != nil {
+					thumb.Width, thumb.Height = d.Thumbnail.Width, d.Thumbnail.Height
+					thumb.URL = "/evidence/thumbnail?hash=" + item.Hash
+				}
+			}
+			thumbs = append(thumbs, thumb)
+		}
+	}
+	return thumbs, nil
+}
+
+func openDerivative(email string, t models.Tracker, cp models.Checkpoint, item models.EvidenceItem, variant, path string) (io.ReadCloser, error) {
+	rc, _, err := OpenEvidence("", path)
+	if err != nil {
+		return nil, err
+	}
+	if len(item.Keys) == 0 {
+		return rc, nil
+	}
+
+	key, err := unlockEvidenceKey(email, t, cp, item)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	pt, err := evidence.DecryptReader(rc, key, evidence.DerivativeAssociatedData(t.ID, cp.Address, variant))
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return readCloser{pt, rc}, nil
+}
+
+func derivativePath(object string) string {
+	return filepath.Join(derivativeDir, object+".json")
+}
+
+func loadDerivatives(object string) (EvidenceDerivatives, error) {
+	if evidence.ValidHash(object) != nil {
+		return EvidenceDerivatives{}, ErrDerivativeNotReady
+	}
+	data, err := os.ReadFile(derivativePath(object))
+	if os.IsNotExist(err) {
+		return EvidenceDerivatives{}, ErrDerivativeNotReady
+	}
+	if err != nil {
+		return EvidenceDerivatives{}, err
+	}
+	var d EvidenceDerivatives
+	if err := json.Unmarshal(data, &d); err != nil {
+		return EvidenceDerivatives{}, fmt.Errorf("invalid derivative record %s: %w", object, err)
+	}
+	return d, nil
+}
+
+func saveDerivatives(d EvidenceDerivatives) error {
+	if err := os.MkdirAll(derivativeDir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := derivativePath(d.Object) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, derivativePath(d.Object))
+}
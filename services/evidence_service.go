@@ -1,103 +1,492 @@
 package services
 
 import (
+	"bufio"
+	"context"
 	"crypto/sha256"
-	"doc-tracker/storage"
+	"doc-tracker/evidence"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"mime/multipart"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
+var (
+	ErrNoEvidenceKey    = errors.New("no evidence key for this address")
+	ErrEvidenceNotFound = errors.New("evidence not found")
+	ErrEvidenceTooLarge = errors.New("evidence exceeds the maximum size")
+	ErrEvidenceType     = errors.New("evidence type is not allowed")
+	ErrEvidenceEmpty    = errors.New("evidence is empty")
+)
+
+// Tipe evidence default: gambar dan PDF hasil scan
+const defaultEvidenceTypes = "image/jpeg,image/png,image/gif,image/webp,application/pdf"
+
+// EvidenceMaxBytes batas ukuran evidence per tipe: EVIDENCE_MAX_PDF_BYTES untuk
+// PDF (default 50 MiB), EVIDENCE_MAX_BYTES untuk tipe lain (default 10 MiB)
+func EvidenceMaxBytes(contentType string) int64 {
+	if contentType == "application/pdf" {
+		return int64(utils.GetEnvInt("EVIDENCE_MAX_PDF_BYTES", 50<<20))
+	}
+	return int64(utils.GetEnvInt("EVIDENCE_MAX_BYTES", 10<<20))
+}
+
+// EvidenceTypeAllowed memeriksa MIME hasil sniff terhadap EVIDENCE_ALLOWED_TYPES
+func EvidenceTypeAllowed(contentType string) bool {
+	allowed := os.Getenv("EVIDENCE_ALLOWED_TYPES")
+	if allowed == "" {
+		allowed = defaultEvidenceTypes
+	}
+	for _, t := range strings.Split(allowed, ",") {
+		if strings.TrimSpace(t) == contentType {
+			return true
+		}
+	}
+	return false
+}
+
 type EvidenceInfo struct {
-	FileName string `json:"file_name"`
-	Hash     string `json:"hash"`
-	Path     string `json:"path"`
+	FileName    string            `json:"file_name"`
+	Hash        string            `json:"hash"` // sha256 plaintext, dicatat on-chain
+	Path        string            `json:"path"` // evidence.Ref(hash object di store)
+	Size        int64             `json:"size"`
+	ContentType string            `json:"content_type"`
+	Keys        map[string]string `json:"-"` // address -> key evidence terbungkus, kosong jika tidak dienkripsi
+}
+
+// Encrypted true jika evidence disimpan terenkripsi
+func (i EvidenceInfo) Encrypted() bool {
+	return len(i.Keys) > 0
+}
+
+// Item membuat item evidence checkpoint dari hasil upload
+func (i EvidenceInfo) Item(label, uploader string) models.EvidenceItem {
+	return models.EvidenceItem{
+		Hash:        i.Hash,
+		Path:        i.Path,
+		ContentType: i.ContentType,
+		Size:        i.Size,
+		Label:       label,
+		Uploader:    uploader,
+		UploadedAt:  time.Now().Unix(),
+		Keys:        i.Keys,
+	}
+}
+
+// SaveEvidence men-stream evidence ke evidence store aktif (content-addressed) tanpa enkripsi
+func SaveEvidence(r io.Reader) (EvidenceInfo, error) {
+	return saveEvidence(r, nil, nil)
+}
+
+// SaveCheckpointEvidence menyimpan evidence checkpoint. Kecuali tracker
+// bertanda public, evidence dienkripsi dengan key acak yang dibungkus untuk
+// creator, checkpoint yang boleh melihat dan viewer yang diberi akses.
+// Hash on-chain tetap dihitung dari plaintext.
+func SaveCheckpointEvidence(trackerID, checkpointAddr string, r io.Reader) (EvidenceInfo, error) {
+	t := mempool.GetByID(trackerID)
+	if t == nil {
+		return EvidenceInfo{}, utils.ErrNotFound
+	}
+	if t.Privacy == "public" {
+		info, err := SaveEvidence(r)
+		if err == nil {
+			queueEvidenceDerivatives(trackerID, checkpointAddr, info, nil)
+		}
+		return info, err
+	}
+
+	recipients, err := evidenceRecipients(t)
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	key, err := evidence.NewKey()
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	info, err := saveEvidence(r, key, evidence.AssociatedData(trackerID, checkpointAddr))
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+
+	if info.Keys, err = wrapEvidenceKey(key, recipients, trackerID, checkpointAddr); err != nil {
+		return EvidenceInfo{}, err
+	}
+	queueEvidenceDerivatives(trackerID, checkpointAddr, info, key)
+	return info, nil
+}
+
+// wrapEvidenceKey membungkus key evidence untuk setiap penerima
+func wrapEvidenceKey(key []byte, recipients map[string]WalletInfo, trackerID, checkpointAddr string) (map[string]string, error) {
+	keys := make(map[string]string, len(recipients))
+	for address, w := range recipients {
+		wrapped, err := utils.WrapEvidenceKey(key, w.EncryptionKey, trackerID, checkpointAddr, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap evidence key for %s: %v", address, err)
+		}
+		keys[address] = wrapped
+	}
+	return keys, nil
 }
 
-// SaveEvidenceBase64ToS3 menyimpan evidence dari base64 ke Storj (S3)
-func SaveEvidenceBase64ToS3(trackerID, checkpointID string, base64Str *string) (EvidenceInfo, error) {
+// SaveEvidenceBase64 menyimpan evidence base64 (boleh berupa data URL) untuk
+// checkpoint. MIME di data URL diabaikan, tipe selalu dideteksi dari isi.
+func SaveEvidenceBase64(trackerID, checkpointAddr string, base64Str *string) (EvidenceInfo, error) {
 	if base64Str == nil || *base64Str == "" {
 		return EvidenceInfo{}, fmt.Errorf("base64 string is empty")
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(*base64Str)
-	if err != nil {
-		return EvidenceInfo{}, fmt.Errorf("invalid base64: %v", err)
+	data := *base64Str
+	if strings.HasPrefix(data, "data:") {
+		_, payload, ok := strings.Cut(data, ",")
+		if !ok {
+			return EvidenceInfo{}, fmt.Errorf("invalid data URL")
+		}
+		data = payload
 	}
 
-	hash := sha256.Sum256(decoded)
+	info, err := SaveCheckpointEvidence(trackerID, checkpointAddr, base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	var corrupt base64.CorruptInputError
+	if errors.As(err, &corrupt) {
+		return EvidenceInfo{}, fmt.Errorf("invalid base64: %v", corrupt)
+	}
+	return info, err
+}
 
-	fileName := fmt.Sprintf("%s_%s.jpg", trackerID, checkpointID)
-	s3Key := fmt.Sprintf("evidence/%s", fileName)
+// OpenEvidence membuka evidence dari store aktif atau lokasi lama (path checkpoint)
+func OpenEvidence(hash, path string) (io.ReadCloser, evidence.Object, error) {
+	return evidence.Open(context.Background(), hash, path)
+}
 
-	// ✅ FIXED: call global instance
-	err = storage.S3.UploadS3File(s3Key, decoded)
+// OpenCheckpointEvidence membuka evidence berdasarkan hash on-chain. Evidence
+// terenkripsi hanya dibuka untuk pemegang key evidence dengan wallet custodial;
+// wallet non-custodial mendapat keystore.ErrNonCustodial dan memakai
+// OpenEncryptedEvidence untuk mendekripsi di client.
+func OpenCheckpointEvidence(email, hash string) (io.ReadCloser, evidence.Object, error) {
+	t, cp, item, err := findEvidenceItem(hash)
 	if err != nil {
-		return EvidenceInfo{}, fmt.Errorf("upload failed: %v", err)
+		return nil, evidence.Object{}, err
+	}
+	if len(item.Keys) == 0 {
+		rc, obj, err := OpenEvidence(hash, item.Path)
+		if err == nil && item.ContentType != "" {
+			obj.ContentType = item.ContentType
+		}
+		return rc, obj, err
 	}
 
-	return EvidenceInfo{
-		FileName: fileName,
-		Hash:     fmt.Sprintf("%x", hash[:]),
-		Path:     s3Key,
-	}, nil
+	key, err := unlockEvidenceKey(email, t, cp, item)
+	if err != nil {
+		return nil, evidence.Object{}, err
+	}
+
+	rc, _, err := OpenEvidence(hash, item.Path)
+	if err != nil {
+		return nil, evidence.Object{}, err
+	}
+	pt, err := evidence.DecryptReader(rc, key, evidence.AssociatedData(t.ID, cp.Address))
+	if err != nil {
+		rc.Close()
+		return nil, evidence.Object{}, err
+	}
+
+	obj := evidence.Object{Hash: hash, Size: item.Size, ContentType: item.ContentType}
+	if obj.Size == 0 {
+		obj.Size = -1
+	}
+	if obj.ContentType == "" {
+		// Evidence sebelum tipe dicatat: deteksi ulang dari hasil dekripsi
+		br := bufio.NewReaderSize(pt, 512)
+		head, _ := br.Peek(512)
+		obj.ContentType = http.DetectContentType(head)
+		pt = br
+	}
+	return readCloser{pt, rc}, obj, nil
+}
+
+// EncryptedEvidence ciphertext evidence dan key terbungkus milik viewer
+type EncryptedEvidence struct {
+	TrackerID  string `json:"tracker_id"`
+	Checkpoint string `json:"checkpoint_address"`
+	Address    string `json:"address"`
+	WrappedKey string `json:"wrapped_key"`
 }
 
-func LoadEvidenceFromS3(fileName string) ([]byte, error) {
-	// ✅ FIXED: call global instance
-	data, err := storage.S3.DownloadS3File(fileName)
+// OpenEncryptedEvidence mengembalikan ciphertext evidence apa adanya untuk
+// pemegang key evidence, agar wallet non-custodial bisa mendekripsi di client
+func OpenEncryptedEvidence(email, hash string) (io.ReadCloser, evidence.Object, EncryptedEvidence, error) {
+	t, cp, item, err := findEvidenceItem(hash)
+	if err != nil {
+		return nil, evidence.Object{}, EncryptedEvidence{}, err
+	}
+	if len(item.Keys) == 0 {
+		return nil, evidence.Object{}, EncryptedEvidence{}, evidence.ErrNotEncrypted
+	}
+	address, wrapped, err := evidenceKeyFor(email, item)
+	if err != nil {
+		return nil, evidence.Object{}, EncryptedEvidence{}, err
+	}
+
+	rc, obj, err := OpenEvidence(hash, item.Path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download file from S3: %v", err)
+		return nil, evidence.Object{}, EncryptedEvidence{}, err
 	}
+	return rc, obj, EncryptedEvidence{TrackerID: t.ID, Checkpoint: cp.Address, Address: address, WrappedKey: wrapped}, nil
+}
 
-	return data, nil
+// findEvidenceItem mencari tracker, checkpoint dan item pemilik evidence hash
+func findEvidenceItem(hash string) (models.Tracker, models.Checkpoint, models.EvidenceItem, error) {
+	t, err := GetTrackerByHash(hash)
+	if err != nil {
+		return models.Tracker{}, models.Checkpoint{}, models.EvidenceItem{}, ErrEvidenceNotFound
+	}
+	for _, cp := range t.Checkpoints {
+		for _, item := range cp.EvidenceItems() {
+			if item.Hash == hash && item.Path != "" {
+				return t, cp, item, nil
+			}
+		}
+	}
+	return models.Tracker{}, models.Checkpoint{}, models.EvidenceItem{}, ErrEvidenceNotFound
 }
 
-func SaveBase64EvidenceLocal(trackerID string, checkpointID string, base64Str *string) (*multipart.FileHeader, error) {
-	// Simpan base64Str ke file sementara
-	tempFile, err := os.CreateTemp("storage/evidence", fmt.Sprintf("%s_%s_*.txt", trackerID, checkpointID))
+// unlockEvidenceKey membuka key evidence item dengan wallet custodial email
+func unlockEvidenceKey(email string, t models.Tracker, cp models.Checkpoint, item models.EvidenceItem) ([]byte, error) {
+	address, wrapped, err := evidenceKeyFor(email, item)
 	if err != nil {
 		return nil, err
 	}
-	defer tempFile.Close()
+	unlocked, err := UnlockWallet(email, "view-evidence")
+	if err != nil {
+		return nil, err
+	}
+	return utils.UnwrapEvidenceKey(wrapped, unlocked.EncryptionPrivateKey, t.ID, cp.Address, address)
+}
 
-	if _, err := tempFile.WriteString(*base64Str); err != nil {
+func evidenceKeyFor(email string, item models.EvidenceItem) (string, string, error) {
+	w, err := GetWalletPublic(email)
+	if err != nil {
+		return "", "", err
+	}
+	wrapped, ok := item.Keys[w.Address]
+	if !ok {
+		return "", "", ErrNoEvidenceKey
+	}
+	return w.Address, wrapped, nil
+}
+
+// evidenceRecipients: creator, checkpoint yang boleh melihat dan viewer tambahan (GrantViewer)
+func evidenceRecipients(t *models.Tracker) (map[string]WalletInfo, error) {
+	creator, err := GetWalletPublic(t.Creator)
+	if err != nil {
 		return nil, err
 	}
+	recipients := map[string]WalletInfo{creator.Address: creator}
 
-	return &multipart.FileHeader{
-		Filename: tempFile.Name(),
-		Size:     int64(len(*base64Str)),
-	}, nil
+	for _, cp := range t.Checkpoints {
+		if !cp.IsViewable {
+			continue
+		}
+		w, err := GetWalletPublic(cp.Email)
+		if err != nil {
+			return nil, err
+		}
+		recipients[w.Address] = w
+	}
+	for address := range t.EncryptedNotes {
+		if _, ok := recipients[address]; ok {
+			continue
+		}
+		email, ok := GetEmailByAddress(address)
+		if !ok {
+			continue
+		}
+		if w, err := GetWalletPublic(email); err == nil {
+			recipients[address] = w
+		}
+	}
+	return recipients, nil
 }
 
-func SaveEvidenceFileLocal(trackerID, checkpointID string, file *string) (EvidenceInfo, error) {
+// grantEvidenceKeys membungkus ulang key evidence tracker untuk viewer baru
+// dengan wallet custodial creator
+func grantEvidenceKeys(creatorEmail string, t *models.Tracker, viewer WalletInfo) error {
+	var creator WalletInfo
+	for i := range t.Checkpoints {
+		cp := &t.Checkpoints[i]
+		normalizeEvidence(cp)
+		for j, item := range cp.Evidence {
+			wrapped, ok := item.Keys[t.CreatorAddr]
+			if !ok {
+				continue
+			}
+			if creator.EncryptionPrivateKey == nil {
+				unlocked, err := UnlockWallet(creatorEmail, "grant-viewer")
+				if err != nil {
+					return err
+				}
+				creator = unlocked
+			}
 
-	// Simpan file
-	savePath := fmt.Sprintf("storage/evidence/%s_%s.jpg", trackerID, checkpointID)
-	dst, err := os.Create(savePath)
-	if err != nil {
-		return EvidenceInfo{}, err
+			key, err := utils.UnwrapEvidenceKey(wrapped, creator.EncryptionPrivateKey, t.ID, cp.Address, t.CreatorAddr)
+			if err != nil {
+				return err
+			}
+			rewrapped, err := utils.WrapEvidenceKey(key, viewer.EncryptionKey, t.ID, cp.Address, viewer.Address)
+			if err != nil {
+				return err
+			}
+			keys := copyKeys(item.Keys)
+			keys[viewer.Address] = rewrapped
+			cp.Evidence[j].Keys = keys
+		}
+	}
+	return nil
+}
+
+// revokeEvidenceKeys menghapus key evidence milik address dari semua checkpoint
+func revokeEvidenceKeys(t *models.Tracker, address string) {
+	for i := range t.Checkpoints {
+		cp := &t.Checkpoints[i]
+		normalizeEvidence(cp)
+		for j, item := range cp.Evidence {
+			if _, ok := item.Keys[address]; !ok {
+				continue
+			}
+			keys := copyKeys(item.Keys)
+			delete(keys, address)
+			cp.Evidence[j].Keys = keys
+		}
 	}
-	defer dst.Close()
+}
 
-	hash := sha256.New()
-	writer := io.MultiWriter(dst, hash)
+// normalizeEvidence memindahkan evidence format lama ke daftar Evidence (hanya
+// untuk tracker di mempool). Slice selalu disalin agar tracker asal tidak ikut berubah.
+func normalizeEvidence(cp *models.Checkpoint) {
+	cp.Evidence = append([]models.EvidenceItem(nil), cp.EvidenceItems()...)
+	cp.EvidenceHash, cp.EvidencePath, cp.EvidenceType = "", "", ""
+	cp.EvidenceKeys, cp.EvidenceSize = nil, 0
+}
+
+func copyKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
 
-	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, strings.NewReader(*file)))
+// saveEvidence men-stream r ke store sambil menghitung hash plaintext. Tipe
+// dideteksi dari byte awal dan ukuran dibatasi selama streaming; jika key
+// diisi, yang disimpan adalah ciphertext evidence.EncryptReader.
+func saveEvidence(r io.Reader, key, ad []byte) (EvidenceInfo, error) {
+	store, err := evidence.Default()
 	if err != nil {
 		return EvidenceInfo{}, err
 	}
-	if _, err := writer.Write(decoded); err != nil {
+	er, err := newEvidenceReader(r)
+	if err != nil {
 		return EvidenceInfo{}, err
 	}
 
+	var src io.Reader = er
+	storedType := er.contentType
+	if key != nil {
+		if src, err = evidence.EncryptReader(src, key, ad); err != nil {
+			return EvidenceInfo{}, err
+		}
+		storedType = "application/octet-stream"
+	}
+
+	obj, err := store.Put(context.Background(), src, storedType)
+	if errors.Is(err, ErrEvidenceTooLarge) {
+		return EvidenceInfo{}, er.tooLarge()
+	}
+	if err != nil {
+		return EvidenceInfo{}, fmt.Errorf("failed to store evidence: %w", err)
+	}
+	return er.info(obj.Hash), nil
+}
+
+// evidenceReader membatasi tipe (hasil sniff) dan ukuran evidence sambil
+// menghitung hash dan ukuran plaintext yang dibaca
+type evidenceReader struct {
+	io.Reader
+	contentType string
+	maxBytes    int64
+	hasher      hash.Hash
+	size        byteCounter
+}
+
+func newEvidenceReader(r io.Reader) (*evidenceReader, error) {
+	// Content type dari client tidak dipercaya, deteksi dari byte awal
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read evidence: %w", err)
+	}
+	if len(head) == 0 {
+		return nil, ErrEvidenceEmpty
+	}
+	contentType := http.DetectContentType(head)
+	if !EvidenceTypeAllowed(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrEvidenceType, contentType)
+	}
+
+	er := &evidenceReader{contentType: contentType, maxBytes: EvidenceMaxBytes(contentType), hasher: sha256.New()}
+	er.Reader = io.TeeReader(&maxBytesReader{r: br, max: er.maxBytes}, io.MultiWriter(er.hasher, &er.size))
+	return er, nil
+}
+
+func (er *evidenceReader) tooLarge() error {
+	return fmt.Errorf("%w (%s max %d bytes)", ErrEvidenceTooLarge, er.contentType, er.maxBytes)
+}
+
+// info hasil evidence setelah seluruh stream dibaca; objectHash key object di store
+func (er *evidenceReader) info(objectHash string) EvidenceInfo {
+	hash := hex.EncodeToString(er.hasher.Sum(nil))
 	return EvidenceInfo{
-		FileName: fmt.Sprintf("%s_%s", trackerID, checkpointID),
-		Hash:     fmt.Sprintf("%x", hash.Sum(nil)),
-		Path:     savePath,
-	}, nil
+		FileName:    hash,
+		Hash:        hash,
+		Path:        evidence.Ref(objectHash),
+		Size:        int64(er.size),
+		ContentType: er.contentType,
+	}
+}
+
+// maxBytesReader gagal dengan ErrEvidenceTooLarge setelah lebih dari max byte dibaca
+type maxBytesReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.read > m.max {
+		return n, ErrEvidenceTooLarge
+	}
+	return n, err
+}
+
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// readCloser menggabungkan reader hasil dekripsi dengan Close stream aslinya
+type readCloser struct {
+	io.Reader
+	io.Closer
 }
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"doc-tracker/evidence"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTicketNotFound = errors.New("upload ticket not found")
+	ErrUploadHash     = errors.New("uploaded evidence does not match the declared hash")
+)
+
+// UploadTicketRequest evidence yang akan diunggah client langsung ke storage
+type UploadTicketRequest struct {
+	TrackerID string `json:"tracker_id"`
+	Hash      string `json:"hash"` // sha256 plaintext
+	Size      int64  `json:"size"` // ukuran yang diunggah (ciphertext jika terenkripsi)
+	Label     string `json:"label"`
+	KeepOpen  bool   `json:"keep_open"` // jangan selesaikan checkpoint setelah upload
+}
+
+// UploadTicket izin upload evidence langsung ke storage (presigned S3 atau URL
+// bertanda tangan node). Setelah client selesai, isi object diverifikasi
+// terhadap Hash sebelum dicatat di checkpoint.
+//
+// Untuk tracker non-public client wajib mengenkripsi file dengan EvidenceKey
+// (format evidence.EncryptReader, associated data AssociatedData); node
+// mendekripsi saat verifikasi sehingga hash plaintext tetap terjamin.
+type UploadTicket struct {
+	ID             string `json:"id"`
+	Email          string `json:"email"`
+	TrackerID      string `json:"tracker_id"`
+	Checkpoint     string `json:"checkpoint_address"`
+	Hash           string `json:"hash"`
+	Size           int64  `json:"size"`
+	Label          string `json:"label,omitempty"`
+	KeepOpen       bool   `json:"keep_open,omitempty"`
+	UploadURL      string `json:"upload_url"`
+	URLExpiresAt   int64  `json:"url_expires_at"`
+	ExpiresAt      int64  `json:"expires_at"`
+	EvidenceKey    string `json:"evidence_key,omitempty"`    // base64
+	AssociatedData string `json:"associated_data,omitempty"` // base64
+
+	key  []byte
+	keys map[string]string
+}
+
+// EvidenceURL URL download evidence langsung dari storage
+type EvidenceURL struct {
+	URL         string             `json:"url"`
+	ExpiresAt   int64              `json:"expires_at"`
+	ContentType string             `json:"content_type,omitempty"`
+	Encrypted   bool               `json:"encrypted"`
+	Key         *EncryptedEvidence `json:"key,omitempty"` // key terbungkus untuk evidence terenkripsi
+}
+
+// Ticket hanya disimpan di memori agar key evidence tidak pernah ditulis ke disk;
+// setelah restart client cukup meminta ticket baru
+var uploadTickets sync.Map // id -> *UploadTicket
+
+func evidenceURLTTL() time.Duration {
+	return utils.GetEnvSeconds("EVIDENCE_URL_TTL", 15*time.Minute)
+}
+
+func directStore() (evidence.DirectStore, error) {
+	store, err := evidence.Default()
+	if err != nil {
+		return nil, err
+	}
+	ds, ok := store.(evidence.DirectStore)
+	if !ok {
+		return nil, evidence.ErrDirectUnsupported
+	}
+	return ds, nil
+}
+
+// CreateUploadTicket membuat URL upload langsung untuk checkpoint milik email
+func CreateUploadTicket(email string, req UploadTicketRequest) (UploadTicket, error) {
+	req.Hash = strings.ToLower(req.Hash)
+	if err := evidence.ValidHash(req.Hash); err != nil {
+		return UploadTicket{}, err
+	}
+	if req.Size <= 0 {
+		return UploadTicket{}, ErrUploadInvalid
+	}
+	if max := evidence.EncryptedSize(maxEvidenceBytes()); req.Size > max {
+		return UploadTicket{}, fmt.Errorf("%w (max %d bytes)", ErrEvidenceTooLarge, max)
+	}
+	t := mempool.GetByID(req.TrackerID)
+	if t == nil {
+		return UploadTicket{}, utils.ErrNotFound
+	}
+	checkpointAddr := GetCheckpointAddressByEmail(req.TrackerID, email)
+	if checkpointAddr == "" {
+		return UploadTicket{}, ErrNotCheckpointOwner
+	}
+	ds, err := directStore()
+	if err != nil {
+		return UploadTicket{}, err
+	}
+
+	cleanupExpiredTickets()
+
+	now := time.Now()
+	ticket := &UploadTicket{
+		ID:         uuid.New().String(),
+		Email:      email,
+		TrackerID:  req.TrackerID,
+		Checkpoint: checkpointAddr,
+		Hash:       req.Hash,
+		Size:       req.Size,
+		Label:      req.Label,
+		KeepOpen:   req.KeepOpen,
+		ExpiresAt:  now.Add(utils.GetEnvSeconds("EVIDENCE_UPLOAD_TTL", 24*time.Hour)).Unix(),
+	}
+	if t.Privacy != "public" {
+		recipients, err := evidenceRecipients(t)
+		if err != nil {
+			return UploadTicket{}, err
+		}
+		if ticket.key, err = evidence.NewKey(); err != nil {
+			return UploadTicket{}, err
+		}
+		if ticket.keys, err = wrapEvidenceKey(ticket.key, recipients, t.ID, checkpointAddr); err != nil {
+			return UploadTicket{}, err
+		}
+		ticket.EvidenceKey = base64.StdEncoding.EncodeToString(ticket.key)
+		ticket.AssociatedData = base64.StdEncoding.EncodeToString(evidence.AssociatedData(t.ID, checkpointAddr))
+	}
+
+	ttl := evidenceURLTTL()
+	ticket.UploadURL, err = ds.StagingURL(context.Background(), ticket.ID, ticket.Size, ttl)
+	if err != nil {
+		return UploadTicket{}, err
+	}
+	ticket.URLExpiresAt = now.Add(ttl).Unix()
+
+	uploadTickets.Store(ticket.ID, ticket)
+	return *ticket, nil
+}
+
+// CompleteUploadTicket memverifikasi object yang diunggah client (tipe, ukuran,
+// hash plaintext), memindahkannya ke store content-addressed lalu mencatatnya
+// di checkpoint. Object yang tidak cocok dibuang dan ticket tidak bisa dipakai lagi.
+func CompleteUploadTicket(email, id string) (EvidenceInfo, error) {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	ticket, err := loadUploadTicket(email, id)
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	ds, err := directStore()
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	ctx := context.Background()
+
+	rc, err := ds.OpenStaging(ctx, id)
+	if errors.Is(err, evidence.ErrNotFound) {
+		return EvidenceInfo{}, ErrUploadIncomplete
+	}
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	info, objectHash, err := verifyStagedEvidence(rc, ticket)
+	rc.Close()
+	if err == nil && info.Hash != ticket.Hash {
+		err = ErrUploadHash
+	}
+	if err != nil {
+		removeUploadTicket(id)
+		return EvidenceInfo{}, err
+	}
+
+	storedType := info.ContentType
+	if ticket.key != nil {
+		storedType = "application/octet-stream"
+	}
+	if err := ds.CommitStaging(ctx, id, objectHash, storedType); err != nil {
+		return EvidenceInfo{}, fmt.Errorf("failed to store evidence: %w", err)
+	}
+	info.Path = evidence.Ref(objectHash)
+	info.Keys = ticket.keys
+	queueEvidenceDerivatives(ticket.TrackerID, ticket.Checkpoint, info, ticket.key)
+
+	removeUploadTicket(id)
+	item := info.Item(ticket.Label, ticket.Email)
+	if err := UpdateCheckpointStatus(ticket.TrackerID, ticket.Checkpoint, []models.EvidenceItem{item}, !ticket.KeepOpen); err != nil {
+		return EvidenceInfo{}, err
+	}
+	return info, nil
+}
+
+// verifyStagedEvidence membaca object staging sampai habis; mengembalikan info
+// plaintext dan hash object (key content-addressed)
+func verifyStagedEvidence(r io.Reader, ticket *UploadTicket) (EvidenceInfo, string, error) {
+	objectHasher := sha256.New()
+	var size byteCounter
+	var src io.Reader = io.TeeReader(r, io.MultiWriter(objectHasher, &size))
+
+	if ticket.key != nil {
+		pt, err := evidence.DecryptReader(src, ticket.key, evidence.AssociatedData(ticket.TrackerID, ticket.Checkpoint))
+		if err != nil {
+			return EvidenceInfo{}, "", fmt.Errorf("%w: %v", ErrUploadHash, err)
+		}
+		src = pt
+	}
+	er, err := newEvidenceReader(src)
+	if err != nil {
+		return EvidenceInfo{}, "", stagedError(err)
+	}
+	if _, err := io.Copy(io.Discard, er); err != nil {
+		if errors.Is(err, ErrEvidenceTooLarge) {
+			return EvidenceInfo{}, "", er.tooLarge()
+		}
+		return EvidenceInfo{}, "", stagedError(err)
+	}
+	if int64(size) != ticket.Size {
+		return EvidenceInfo{}, "", fmt.Errorf("%w: size %d, declared %d", ErrUploadHash, size, ticket.Size)
+	}
+	objectHash := hex.EncodeToString(objectHasher.Sum(nil))
+	return er.info(objectHash), objectHash, nil
+}
+
+func stagedError(err error) error {
+	if errors.Is(err, evidence.ErrDecrypt) {
+		return fmt.Errorf("%w: %v", ErrUploadHash, err)
+	}
+	return err
+}
+
+// AbortUploadTicket membatalkan ticket dan membuang object yang sudah diunggah
+func AbortUploadTicket(email, id string) error {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	if _, err := loadUploadTicket(email, id); err != nil {
+		return err
+	}
+	removeUploadTicket(id)
+	return nil
+}
+
+// EvidenceDownloadURL membuat URL download langsung untuk evidence hash.
+// Evidence terenkripsi hanya untuk pemegang key; URL-nya mengarah ke ciphertext
+// dan key terbungkus ikut dikembalikan untuk didekripsi di client.
+func EvidenceDownloadURL(email, hash string) (EvidenceURL, error) {
+	t, cp, item, err := findEvidenceItem(hash)
+	if err != nil {
+		return EvidenceURL{}, err
+	}
+	objectHash := evidence.RefHash(item.Path)
+	if objectHash == "" {
+		// Evidence lama di luar store content-addressed tetap lewat ViewEvidence
+		return EvidenceURL{}, evidence.ErrDirectUnsupported
+	}
+	ds, err := directStore()
+	if err != nil {
+		return EvidenceURL{}, err
+	}
+
+	result := EvidenceURL{ContentType: item.ContentType}
+	if len(item.Keys) > 0 {
+		address, wrapped, err := evidenceKeyFor(email, item)
+		if err != nil {
+			return EvidenceURL{}, err
+		}
+		result.Encrypted = true
+		result.ContentType = "application/octet-stream"
+		result.Key = &EncryptedEvidence{TrackerID: t.ID, Checkpoint: cp.Address, Address: address, WrappedKey: wrapped}
+	}
+
+	ttl := evidenceURLTTL()
+	result.URL, err = ds.GetURL(context.Background(), objectHash, result.ContentType, ttl)
+	if err != nil {
+		return EvidenceURL{}, err
+	}
+	result.ExpiresAt = time.Now().Add(ttl).Unix()
+	return result, nil
+}
+
+func loadUploadTicket(email, id string) (*UploadTicket, error) {
+	v, ok := uploadTickets.Load(id)
+	if !ok {
+		return nil, ErrTicketNotFound
+	}
+	ticket := v.(*UploadTicket)
+	if ticket.Email != email || time.Now().Unix() > ticket.ExpiresAt {
+		return nil, ErrTicketNotFound
+	}
+	return ticket, nil
+}
+
+func removeUploadTicket(id string) {
+	if ds, err := directStore(); err == nil {
+		ds.DeleteStaging(context.Background(), id)
+	}
+	uploadTickets.Delete(id)
+	uploadLocks.Delete(id)
+}
+
+// cleanupExpiredTickets membuang ticket dan object staging yang kedaluwarsa
+func cleanupExpiredTickets() {
+	now := time.Now().Unix()
+	uploadTickets.Range(func(key, value any) bool {
+		if now > value.(*UploadTicket).ExpiresAt {
+			removeUploadTicket(key.(string))
+		}
+		return true
+	})
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload session not found")
+	ErrUploadOffset     = errors.New("upload offset does not match the received bytes")
+	ErrUploadIncomplete = errors.New("upload is not complete yet")
+	ErrUploadChunkSize  = errors.New("chunk exceeds the chunk size or the declared upload size")
+	ErrUploadInvalid    = errors.New("size must be greater than zero")
+)
+
+var uploadDir = "data/uploads"
+
+// UploadSession upload evidence bertahap (resumable) untuk PDF hasil scan yang
+// besar. Client mengirim potongan berurutan dari Offset, dan bisa melanjutkan
+// dari Offset terakhir setelah koneksi putus.
+type UploadSession struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	TrackerID  string `json:"tracker_id"`
+	Checkpoint string `json:"checkpoint_address"`
+	Size       int64  `json:"size"`   // total ukuran yang diumumkan client
+	Offset     int64  `json:"offset"` // byte yang sudah diterima
+	ChunkSize  int64  `json:"chunk_size"`
+	Label      string `json:"label,omitempty"`
+	KeepOpen   bool   `json:"keep_open,omitempty"` // checkpoint belum diselesaikan setelah upload
+	CreatedAt  int64  `json:"created_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+var uploadLocks sync.Map // id -> *sync.Mutex
+
+func lockUpload(id string) func() {
+	m, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// CreateUploadSession memulai upload bertahap untuk checkpoint milik email.
+// keepOpen membiarkan checkpoint terbuka untuk evidence berikutnya.
+func CreateUploadSession(email, trackerID string, size int64, label string, keepOpen bool) (UploadSession, error) {
+	if size <= 0 {
+		return UploadSession{}, ErrUploadInvalid
+	}
+	if max := maxEvidenceBytes(); size > max {
+		return UploadSession{}, fmt.Errorf("%w (max %d bytes)", ErrEvidenceTooLarge, max)
+	}
+	checkpointAddr := GetCheckpointAddressByEmail(trackerID, email)
+	if checkpointAddr == "" {
+		return UploadSession{}, ErrNotCheckpointOwner
+	}
+
+	cleanupExpiredUploads()
+
+	now := time.Now()
+	s := UploadSession{
+		ID:         uuid.New().String(),
+		Email:      email,
+		TrackerID:  trackerID,
+		Checkpoint: checkpointAddr,
+		Size:       size,
+		ChunkSize:  int64(utils.GetEnvInt("EVIDENCE_CHUNK_BYTES", 5<<20)),
+		Label:      label,
+		KeepOpen:   keepOpen,
+		CreatedAt:  now.Unix(),
+		ExpiresAt:  now.Add(utils.GetEnvSeconds("EVIDENCE_UPLOAD_TTL", 24*time.Hour)).Unix(),
+	}
+	if err := os.MkdirAll(uploadDir, 0700); err != nil {
+		return UploadSession{}, err
+	}
+	if err := os.WriteFile(uploadPartPath(s.ID), nil, 0600); err != nil {
+		return UploadSession{}, err
+	}
+	return s, saveUploadSession(s)
+}
+
+// GetUploadSession mengembalikan status upload (Offset untuk melanjutkan)
+func GetUploadSession(email, id string) (UploadSession, error) {
+	return loadUploadSession(email, id)
+}
+
+// AppendUploadChunk menambahkan potongan mulai dari offset. Byte yang sempat
+// diterima sebelum koneksi putus tetap dihitung sehingga client bisa melanjutkan.
+func AppendUploadChunk(email, id string, offset int64, r io.Reader) (UploadSession, error) {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	s, err := loadUploadSession(email, id)
+	if err != nil {
+		return UploadSession{}, err
+	}
+	if offset != s.Offset {
+		return s, ErrUploadOffset
+	}
+
+	f, err := os.OpenFile(uploadPartPath(id), os.O_WRONLY, 0600)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+	// Buang sisa tulisan yang tidak tercatat (mis. proses mati di tengah chunk)
+	if err := f.Truncate(s.Offset); err != nil {
+		return s, err
+	}
+	if _, err := f.Seek(s.Offset, io.SeekStart); err != nil {
+		return s, err
+	}
+
+	limit := s.ChunkSize
+	if remaining := s.Size - s.Offset; remaining < limit {
+		limit = remaining
+	}
+	n, copyErr := io.Copy(f, io.LimitReader(r, limit))
+	if copyErr == nil {
+		// Client mengirim lebih dari yang diizinkan: tolak seluruh chunk
+		var probe [1]byte
+		if m, _ := r.Read(probe[:]); m > 0 {
+			f.Truncate(s.Offset)
+			return s, ErrUploadChunkSize
+		}
+	}
+
+	s.Offset += n
+	if err := saveUploadSession(s); err != nil {
+		return s, err
+	}
+	return s, copyErr
+}
+
+// CompleteUpload memproses file yang sudah lengkap seperti upload biasa:
+// sniff tipe, batas ukuran, enkripsi, lalu item ditambahkan ke checkpoint dan
+// checkpoint ditandai selesai kecuali KeepOpen
+func CompleteUpload(email, id string) (EvidenceInfo, error) {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	s, err := loadUploadSession(email, id)
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	if s.Offset != s.Size {
+		return EvidenceInfo{}, ErrUploadIncomplete
+	}
+
+	f, err := os.Open(uploadPartPath(id))
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	info, err := SaveCheckpointEvidence(s.TrackerID, s.Checkpoint, f)
+	f.Close()
+	if err != nil {
+		return EvidenceInfo{}, err
+	}
+	if err := UpdateCheckpointStatus(s.TrackerID, s.Checkpoint, []models.EvidenceItem{info.Item(s.Label, s.Email)}, !s.KeepOpen); err != nil {
+		return EvidenceInfo{}, err
+	}
+
+	removeUpload(id)
+	return info, nil
+}
+
+// AbortUpload membatalkan upload dan menghapus potongan yang sudah diterima
+func AbortUpload(email, id string) error {
+	unlock := lockUpload(id)
+	defer unlock()
+
+	if _, err := loadUploadSession(email, id); err != nil {
+		return err
+	}
+	removeUpload(id)
+	return nil
+}
+
+// maxEvidenceBytes batas terbesar dari semua tipe; tipe sebenarnya baru
+// diketahui (dan dibatasi) saat upload diselesaikan
+func maxEvidenceBytes() int64 {
+	max := EvidenceMaxBytes("")
+	if pdf := EvidenceMaxBytes("application/pdf"); pdf > max {
+		max = pdf
+	}
+	return max
+}
+
+func uploadSessionPath(id string) string {
+	return filepath.Join(uploadDir, id+".json")
+}
+
+func uploadPartPath(id string) string {
+	return filepath.Join(uploadDir, id+".part")
+}
+
+func loadUploadSession(email, id string) (UploadSession, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	data, err := os.ReadFile(uploadSessionPath(id))
+	if os.IsNotExist(err) {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return UploadSession{}, err
+	}
+	var s UploadSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return UploadSession{}, err
+	}
+	// Sesi milik user lain atau kedaluwarsa diperlakukan tidak ada
+	if s.Email != email || time.Now().Unix() > s.ExpiresAt {
+		return UploadSession{}, ErrUploadNotFound
+	}
+	return s, nil
+}
+
+func saveUploadSession(s UploadSession) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(uploadSessionPath(s.ID), data, 0600)
+}
+
+func removeUpload(id string) {
+	os.Remove(uploadPartPath(id))
+	os.Remove(uploadSessionPath(id))
+	uploadLocks.Delete(id)
+}
+
+// cleanupExpiredUploads menghapus sesi yang melewati EVIDENCE_UPLOAD_TTL
+func cleanupExpiredUploads() {
+	entries, err := os.ReadDir(uploadDir)
+	if err != nil {
+		return
+	}
+	now := time.Now().Unix()
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(uploadSessionPath(id))
+		if err != nil {
+			continue
+		}
+		var s UploadSession
+		if json.Unmarshal(data, &s) == nil && now > s.ExpiresAt {
+			removeUpload(id)
+		}
+	}
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type JwtClaims struct {
-	Email   string `json:"email"`
-	Address string `json:"address"`
+	Email     string `json:"email"`
+	Address   string `json:"address"`
+	MfaEnroll bool   `json:"mfa_enroll,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var ErrLabelFormat = errors.New("label format must be png, svg or pdf")
+
+// labelPayload isi yang ditandatangani node untuk QR label tracker
+func labelPayload(trackerID string) []byte {
+	return []byte("doc-tracker/label/v1\n" + trackerID)
+}
+
+// TrackerVerification hasil verifikasi publik dari QR label dokumen fisik
+type TrackerVerification struct {
+	Tracker   PublicTracker  `json:"tracker"`
+	Inclusion BlockInclusion `json:"inclusion"`
+	Signature LabelSignature `json:"signature"`
+}
+
+// BlockInclusion posisi tracker di blockchain
+type BlockInclusion struct {
+	Confirmed     bool   `json:"confirmed"` // false jika masih di mempool
+	BlockIndex    int    `json:"block_index,omitempty"`
+	BlockHash     string `json:"block_hash,omitempty"`
+	BlockTime     int64  `json:"block_time,omitempty"`
+	MerkleRoot    string `json:"merkle_root,omitempty"`
+	TxHash        string `json:"tx_hash,omitempty"`
+	Confirmations int    `json:"confirmations,omitempty"` // jumlah block sejak block tracker (termasuk)
+
+	MerkleProof []blockchain.MerkleStep `json:"merkle_proof,omitempty"` // TxHash -> MerkleRoot
+	Legacy      bool                    `json:"legacy,omitempty"`       // block format lama: hash block tidak memakai merkle root
+}
+
+// LabelSignature status tanda tangan node di URL QR
+type LabelSignature struct {
+	Present bool   `json:"present"`
+	Valid   bool   `json:"valid"`
+	Trusted bool   `json:"trusted"` // ditandatangani node ini atau LABEL_TRUSTED_KEYS
+	Signer  string `json:"signer,omitempty"`
+}
+
+// verifyBaseURL URL halaman verifikasi yang dicetak di QR. VERIFY_PUBLIC_URL
+// sebaiknya diisi URL publik frontend; default endpoint API node ini.
+func verifyBaseURL() string {
+	if base := os.Getenv("VERIFY_PUBLIC_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return os.Getenv("EVIDENCE_PUBLIC_URL") + "/api/verify"
+}
+
+// TrackerVerificationURL URL verifikasi bertanda tangan node untuk tracker.
+// Public key ikut di URL agar label bisa diverifikasi node lain di jaringan.
+func TrackerVerificationURL(trackerID string) (string, error) {
+	sig, err := keymanager.Sign(labelPayload(trackerID))
+	if err != nil {
+		return "", err
+	}
+	pub, err := keymanager.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("sig", base64.RawURLEncoding.EncodeToString(sig))
+	q.Set("pk", base64.RawURLEncoding.EncodeToString(elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y)))
+	return verifyBaseURL() + "/" + url.PathEscape(trackerID) + "?" + q.Encode(), nil
+}
+
+// TrackerLabel merender label cetak berisi QR verifikasi untuk peserta tracker
+func TrackerLabel(email, trackerID, format string, size int) ([]byte, string, error) {
+	t, err := findTrackerForContent(trackerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !CanViewTracker(email, *t) {
+		return nil, "", ErrTrackerAccessDenied
+	}
+	link, err := TrackerVerificationURL(t.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	label := utils.QRLabel{
+		Content: link,
+		Title:   "Scan to verify document",
+		Lines:   []string{"Tracker " + t.ID},
+	}
+	// Tracker dari peer belum tentu lewat normalizeDocument, hash bisa pendek
+	if t.Document != nil && len(t.Document.Hash) >= 16 {
+		label.Lines = append(label.Lines, "SHA-256 "+t.Document.Hash[:16]+"...")
+	}
+	if pub, err := keymanager.PublicKey(); err == nil {
+		label.Lines = append(label.Lines, "Node "+keymanager.Fingerprint(pub))
+	}
+
+	switch format {
+	case "", "png":
+		if size <= 0 {
+			size = 512
+		}
+		data, err := label.PNG(min(size, 4096))
+		return data, "image/png", err
+	case "svg":
+		data, err := label.SVG()
+		return data, "image/svg+xml", err
+	case "pdf":
+		data, err := label.PDF(200) // ~70mm
+		return data, "application/pdf", err
+	}
+	return nil, "", ErrLabelFormat
+}
+
+// VerifyTrackerLabel verifikasi publik dari QR label: status tracker yang
+// sudah disamarkan, timeline checkpoint, posisi di block dan validitas tanda
+// tangan. sig dan pk kosong tetap mengembalikan status (signature.present false).
+func VerifyTrackerLabel(trackerID, sig, pk string) (TrackerVerification, error) {
+	t, inclusion := locateTracker(trackerID)
+	if t == nil {
+		return TrackerVerification{}, utils.ErrNotFound
+	}
+	return TrackerVerification{
+		Tracker:   publicTracker(*t),
+		Inclusion: inclusion,
+		Signature: verifyLabelSignature(trackerID, sig, pk),
+	}, nil
+}
+
+// locateTracker mencari tracker di blockchain lalu di mempool (termasuk yang
+// sudah complete tetapi belum di-mine)
+func locateTracker(trackerID string) (*models.Tracker, BlockInclusion) {
+	blocks := blockchain.GetAllBlocks()
+	for _, block := range blocks {
+		for i := range block.Transactions {
+			if block.Transactions[i].ID != trackerID {
+				continue
+			}
+			header := blockchain.HeaderOf(block)
+			return &block.Transactions[i], BlockInclusion{
+				Confirmed:     true,
+				BlockIndex:    block.Index,
+				BlockHash:     block.Hash,
+				BlockTime:     block.Timestamp,
+				MerkleRoot:    header.MerkleRoot,
+				TxHash:        blockchain.TxHash(block.Transactions[i]),
+				Confirmations: blocks[len(blocks)-1].Index - block.Index + 1,
+				MerkleProof:   blockchain.MerkleProof(block.Transactions, i),
+				Legacy:        header.Legacy,
+			}
+		}
+	}
+	if t := mempool.GetByID(trackerID); t != nil {
+		return t, BlockInclusion{}
+	}
+	return nil, BlockInclusion{}
+}
+
+func verifyLabelSignature(trackerID, sig, pk string) LabelSignature {
+	result := LabelSignature{Present: sig != "" && pk != ""}
+	if !result.Present {
+		return result
+	}
+	rawSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return result
+	}
+	rawPub, err := base64.RawURLEncoding.DecodeString(pk)
+	if err != nil {
+		return result
+	}
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), rawPub)
+	if x == nil {
+		return result
+	}
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+	result.Signer = keymanager.Fingerprint(pub)
+	result.Valid = keymanager.Verify(pub, labelPayload(trackerID), rawSig)
+	result.Trusted = result.Valid && labelSignerTrusted(result.Signer)
+	return result
+}
+
+// labelSignerTrusted signer dipercaya jika key node ini atau terdaftar di
+// LABEL_TRUSTED_KEYS (fingerprint, dipisah koma) untuk label dari node lain
+// atau key signing lama setelah rotasi
+func labelSignerTrusted(fingerprint string) bool {
+	if own, err := keymanager.PublicKey(); err == nil && keymanager.Fingerprint(own) == fingerprint {
+		return true
+	}
+	for _, f := range strings.Split(os.Getenv("LABEL_TRUSTED_KEYS"), ",") {
+		if strings.TrimSpace(f) == fingerprint {
+			return true
+		}
+	}
+	return false
+}
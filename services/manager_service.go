@@ -0,0 +1,110 @@
+package services
+
+import (
+	"doc-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrManagerInvalid = errors.New("invalid manager mapping")
+
+const managerFile = "data/managers.json"
+
+// Direktori atasan (email -> email manager) untuk variabel template
+// seperti {{creator.manager}}
+var (
+	managers       = map[string]string{}
+	managerMu      sync.Mutex
+	managersLoaded bool
+)
+
+// ManagerOf email manager dari email, kosong jika belum terdaftar
+func ManagerOf(email string) string {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	loadManagersLocked()
+	return managers[normalizeEmail(email)]
+}
+
+// ListManagers salinan direktori manager
+func ListManagers() map[string]string {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	loadManagersLocked()
+
+	out := make(map[string]string, len(managers))
+	for k, v := range managers {
+		out[k] = v
+	}
+	return out
+}
+
+// UpdateManagers menggabungkan mapping ke direktori; manager kosong menghapus
+// entry. replace=true mengganti seluruh direktori.
+func UpdateManagers(mapping map[string]string, replace bool) (map[string]string, error) {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	loadManagersLocked()
+
+	next := map[string]string{}
+	if !replace {
+		for k, v := range managers {
+			next[k] = v
+		}
+	}
+	for email, manager := range mapping {
+		email, manager = normalizeEmail(email), normalizeEmail(manager)
+		if !strings.Contains(email, "@") || (manager != "" && !strings.Contains(manager, "@")) {
+			return nil, fmt.Errorf("%w: %q -> %q", ErrManagerInvalid, email, manager)
+		}
+		if email == manager {
+			return nil, fmt.Errorf("%w: %s cannot be their own manager", ErrManagerInvalid, email)
+		}
+		if manager == "" {
+			delete(next, email)
+		} else {
+			next[email] = manager
+		}
+	}
+
+	previous := managers
+	managers = next
+	if err := saveManagersLocked(); err != nil {
+		managers = previous
+		return nil, err
+	}
+	out := make(map[string]string, len(managers))
+	for k, v := range managers {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func loadManagersLocked() {
+	if managersLoaded {
+		return
+	}
+	managersLoaded = true
+	data, err := os.ReadFile(managerFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &managers); err != nil {
+		fmt.Printf("❌ Failed to parse %s: %v\n", managerFile, err)
+	}
+}
+
+func saveManagersLocked() error {
+	if err := utils.CreateDirIfNotExists("data"); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(managers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(managerFile, data, 0600)
+}
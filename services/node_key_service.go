@@ -0,0 +1,61 @@
+package services
+
+import (
+	"doc-tracker/blockchain"
+	"doc-tracker/keymanager"
+	"doc-tracker/mempool"
+	"fmt"
+)
+
+// ReencryptResult ringkasan re-enkripsi data node dengan key storage aktif
+type ReencryptResult struct {
+	KeyID   string `json:"key_id"`
+	Mempool bool   `json:"mempool"` // true jika DEK mempool dibungkus ulang
+	Files   int    `json:"files"`   // jumlah body block yang ditulis ulang
+}
+
+// ReencryptNodeData menulis ulang mempool.bin dan body block yang
+// belum memakai key storage aktif (termasuk format lama sebelum keymanager)
+func ReencryptNodeData() (ReencryptResult, error) {
+	keyID, err := keymanager.CurrentKeyID(keymanager.PurposeStorage)
+	if err != nil {
+		return ReencryptResult{}, err
+	}
+	result := ReencryptResult{KeyID: keyID}
+
+	if result.Mempool, err = mempool.Reencrypt(); err != nil {
+		return result, fmt.Errorf("mempool: %v", err)
+	}
+	if result.Files, err = blockchain.ReencryptStorage(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// RotateNodeKey membuat key baru untuk purpose. Untuk key storage, data node
+// langsung dienkripsi ulang lalu key lama di-retire jika retire=true.
+func RotateNodeKey(purpose keymanager.Purpose, retire bool) (string, ReencryptResult, error) {
+	oldID, err := keymanager.CurrentKeyID(purpose)
+	if err != nil {
+		return "", ReencryptResult{}, err
+	}
+	newID, err := keymanager.Rotate(purpose)
+	if err != nil {
+		return "", ReencryptResult{}, err
+	}
+
+	var result ReencryptResult
+	if purpose == keymanager.PurposeStorage {
+		if result, err = ReencryptNodeData(); err != nil {
+			// Key lama tidak di-retire agar data yang belum terenkripsi ulang tetap terbaca
+			return newID, result, err
+		}
+	}
+
+	if retire {
+		if err := keymanager.Retire(oldID); err != nil {
+			return newID, result, fmt.Errorf("rotated but failed to retire %s: %v", oldID, err)
+		}
+	}
+	return newID, result, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"doc-tracker/blockchain"
+	"doc-tracker/keystore"
+	"doc-tracker/mempool"
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"errors"
+)
+
+var (
+	ErrNoteNotFound       = errors.New("note not found")
+	ErrNotCheckpointOwner = errors.New("you are not a checkpoint holder of this tracker")
+	ErrNoteNotViewable    = errors.New("checkpoint is not allowed to view the note")
+	ErrLegacyKeyNotFound  = errors.New("public key for legacy note not found")
+)
+
+// EncryptCheckpointNote mengenkripsi note untuk key enkripsi wallet penerima
+func EncryptCheckpointNote(trackerID, note string, receiver WalletInfo) (string, error) {
+	return utils.EncryptNote(note, receiver.EncryptionKey, utils.NoteAssociatedData(trackerID, receiver.Address))
+}
+
+// FindCheckpointNote mencari checkpoint milik email di tracker (mempool atau chain)
+func FindCheckpointNote(email, trackerID string) (models.Checkpoint, error) {
+	tracker := mempool.GetByID(trackerID)
+	if tracker == nil {
+		t, err := GetTrackerByID(trackerID)
+		if err != nil {
+			return models.Checkpoint{}, ErrNoteNotFound
+		}
+		tracker = &t
+	}
+
+	for _, cp := range tracker.Checkpoints {
+		if cp.Email != email {
+			continue
+		}
+		if !cp.IsViewable {
+			return models.Checkpoint{}, ErrNoteNotViewable
+		}
+		if cp.EncryptedNote == "" {
+			return models.Checkpoint{}, ErrNoteNotFound
+		}
+		return cp, nil
+	}
+	return models.Checkpoint{}, ErrNotCheckpointOwner
+}
+
+// DecryptCheckpointNote membuka note checkpoint untuk pemegangnya. Hanya untuk
+// wallet custodial; wallet non-custodial mendapat keystore.ErrNonCustodial dan
+// harus mendekripsi di client.
+func DecryptCheckpointNote(email, trackerID string) (string, error) {
+	cp, err := FindCheckpointNote(email, trackerID)
+	if err != nil {
+		return "", err
+	}
+
+	if utils.IsLegacyNote(cp.EncryptedNote) {
+		pub, err := legacyNotePublicKey(email, cp.Address)
+		if err != nil {
+			return "", err
+		}
+		return utils.DecryptLegacyNote(cp.EncryptedNote, pub)
+	}
+
+	wallet, err := UnlockWallet(email, "decrypt-note")
+	if err != nil {
+		return "", err
+	}
+	return utils.DecryptNote(cp.EncryptedNote, wallet.EncryptionPrivateKey, utils.NoteAssociatedData(trackerID, cp.Address))
+}
+
+// legacyNotePublicKey mencari public key lama yang dipakai EncryptWithPublicKey untuk address
+func legacyNotePublicKey(email, address string) (*ecdsa.PublicKey, error) {
+	kf, err := keystore.Load(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if !kf.HasAddress(address) {
+		return nil, ErrLegacyKeyNotFound
+	}
+
+	switch {
+	case kf.Legacy():
+		return utils.ParsePublicKeyHex(kf.PublicKey)
+	case address != kf.LegacyAddress:
+		// Address baru tidak pernah dipakai note skema lama
+		return nil, ErrLegacyKeyNotFound
+	case kf.LegacyPublicKey != "":
+		return utils.ParsePublicKeyHex(kf.LegacyPublicKey)
+	case kf.Custodial():
+		// Entry hasil upgrade sebelum legacy_public_key disimpan
+		keys, err := keystore.UnwrapKeys(email, "legacy-note")
+		if err != nil {
+			return nil, err
+		}
+		return &keys.Legacy.PublicKey, nil
+	}
+	return nil, ErrLegacyKeyNotFound
+}
+
+// NoteMigrateResult ringkasan re-enkripsi note lama
+type NoteMigrateResult struct {
+	Migrated int
+	Failed   map[string]error // key: trackerID/email
+	Mined    int              // note lama di block, tidak bisa ditulis ulang
+}
+
+// MigrateLegacyNotes mengenkripsi ulang note skema lama di mempool ke ECIES.
+// Note di block yang sudah di-mine hanya dihitung; isinya tetap bisa dibaca
+// lewat DecryptCheckpointNote (mode kompatibilitas).
+func MigrateLegacyNotes() (NoteMigrateResult, error) {
+	result := NoteMigrateResult{Failed: map[string]error{}}
+
+	for _, tracker := range mempool.GetAll() {
+		changed := false
+		for i, cp := range tracker.Checkpoints {
+			if !utils.IsLegacyNote(cp.EncryptedNote) {
+				continue
+			}
+			if err := migrateNote(tracker.ID, &tracker.Checkpoints[i]); err != nil {
+				result.Failed[tracker.ID+"/"+cp.Email] = err
+				continue
+			}
+			changed = true
+			result.Migrated++
+		}
+		if changed {
+			mempool.UpdateTracker(tracker)
+		}
+	}
+
+	err := blockchain.Iterate(func(tx *models.Tracker) error {
+		for _, cp := range tx.Checkpoints {
+			if utils.IsLegacyNote(cp.EncryptedNote) {
+				result.Mined++
+			}
+		}
+		return nil
+	}, "")
+	return result, err
+}
+
+func migrateNote(trackerID string, cp *models.Checkpoint) error {
+	pub, err := legacyNotePublicKey(cp.Email, cp.Address)
+	if err != nil {
+		return err
+	}
+	note, err := utils.DecryptLegacyNote(cp.EncryptedNote, pub)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := GetWalletPublic(cp.Email)
+	if err != nil {
+		return err
+	}
+	// AD tetap memakai address checkpoint agar riwayat tidak berubah
+	encrypted, err := utils.EncryptNote(note, wallet.EncryptionKey, utils.NoteAssociatedData(trackerID, cp.Address))
+	if err != nil {
+		return err
+	}
+	cp.EncryptedNote = encrypted
+	return nil
+}
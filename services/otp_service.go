@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"doc-tracker/storage/redis"
+	"doc-tracker/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOtpInvalid       = errors.New("invalid OTP")
+	ErrOtpExpired       = errors.New("OTP expired or not requested")
+	ErrOtpLocked        = errors.New("too many attempts, try again later")
+	ErrOtpResendLimit   = errors.New("too many OTP requests, try again later")
+	ErrOtpResendTooSoon = errors.New("OTP was just sent, please wait before requesting again")
+	ErrOtpSecret        = errors.New("OTP is unavailable: OTP_SECRET or JWT_SECRET is not configured")
+)
+
+// OtpConfig berisi pengaturan OTP, dibaca dari env
+type OtpConfig struct {
+	Length          int           // OTP_LENGTH, jumlah digit
+	TTL             time.Duration // OTP_TTL_SECONDS, masa berlaku OTP
+	MaxAttempts     int           // OTP_MAX_ATTEMPTS, salah tebak per email sebelum lockout
+	IPMaxAttempts   int           // OTP_IP_MAX_ATTEMPTS, salah tebak per IP sebelum lockout
+	ResendLimit     int           // OTP_RESEND_LIMIT, request OTP per email per window
+	IPResendLimit   int           // OTP_IP_RESEND_LIMIT, request OTP per IP per window
+	ResendWindow    time.Duration // OTP_RESEND_WINDOW_SECONDS
+	ResendCooldown  time.Duration // OTP_RESEND_COOLDOWN_SECONDS, jeda minimal antar request
+	LockoutDuration time.Duration // OTP_LOCKOUT_SECONDS
+}
+
+func LoadOtpConfig() OtpConfig {
+	return OtpConfig{
+		Length:          utils.GetEnvInt("OTP_LENGTH", 6),
+		TTL:             utils.GetEnvSeconds("OTP_TTL_SECONDS", 5*time.Minute),
+		MaxAttempts:     utils.GetEnvInt("OTP_MAX_ATTEMPTS", 5),
+		IPMaxAttempts:   utils.GetEnvInt("OTP_IP_MAX_ATTEMPTS", 20),
+		ResendLimit:     utils.GetEnvInt("OTP_RESEND_LIMIT", 3),
+		IPResendLimit:   utils.GetEnvInt("OTP_IP_RESEND_LIMIT", 10),
+		ResendWindow:    utils.GetEnvSeconds("OTP_RESEND_WINDOW_SECONDS", 15*time.Minute),
+		ResendCooldown:  utils.GetEnvSeconds("OTP_RESEND_COOLDOWN_SECONDS", time.Minute),
+		LockoutDuration: utils.GetEnvSeconds("OTP_LOCKOUT_SECONDS", 15*time.Minute),
+	}
+}
+
+// GenerateOtp membuat kode numerik dengan crypto/rand
+func GenerateOtp(length int) (string, error) {
+	if length < 4 || length > 10 {
+		length = 6
+	}
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
+
+// HashOtp menghitung HMAC-SHA256 dari email+otp, sehingga OTP tidak disimpan
+// plaintext. Gagal jika OTP_SECRET dan JWT_SECRET kosong (tidak memakai key kosong).
+func HashOtp(email, otp string) (string, error) {
+	secret := os.Getenv("OTP_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return "", ErrOtpSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(normalizeEmail(email) + ":" + otp))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// IssueOtp membuat OTP baru untuk email setelah mengecek lockout dan batas resend.
+// Kode plaintext dikembalikan hanya untuk dikirim via email.
+func IssueOtp(email, ip string) (string, error) {
+	cfg := LoadOtpConfig()
+	email = normalizeEmail(email)
+
+	// Tolak sebelum menghitung resend agar konfigurasi salah tidak memakan kuota
+	if _, err := HashOtp(email, ""); err != nil {
+		return "", err
+	}
+	if isOtpLocked(email, ip) {
+		return "", ErrOtpLocked
+	}
+
+	if _, found, _ := redis.Get("otp:cooldown:" + email); found {
+		return "", ErrOtpResendTooSoon
+	}
+
+	n, err := redis.Incr("otp:resend:email:"+email, cfg.ResendWindow)
+	if err != nil {
+		return "", err
+	}
+	if int(n) > cfg.ResendLimit {
+		return "", ErrOtpResendLimit
+	}
+	if ip != "" {
+		n, err := redis.Incr("otp:resend:ip:"+ip, cfg.ResendWindow)
+		if err != nil {
+			return "", err
+		}
+		if int(n) > cfg.IPResendLimit {
+			return "", ErrOtpResendLimit
+		}
+	}
+
+	otp, err := GenerateOtp(cfg.Length)
+	if err != nil {
+		return "", err
+	}
+
+	hashed, err := HashOtp(email, otp)
+	if err != nil {
+		return "", err
+	}
+	if err := redis.StoreOtpInMemoryOrRedis(email, hashed, cfg.TTL); err != nil {
+		return "", err
+	}
+	if cfg.ResendCooldown > 0 {
+		_ = redis.Set("otp:cooldown:"+email, "1", cfg.ResendCooldown)
+	}
+
+	return otp, nil
+}
+
+// VerifyOtp mencocokkan OTP. Setiap kegagalan dihitung per email dan per IP;
+// jika melewati batas, email/IP di-lock selama LockoutDuration.
+func VerifyOtp(email, ip, otp string) error {
+	cfg := LoadOtpConfig()
+	email = normalizeEmail(email)
+
+	if isOtpLocked(email, ip) {
+		return ErrOtpLocked
+	}
+
+	expected := redis.GetOtpFromMemoryOrRedis(email)
+	if expected == "" {
+		registerOtpFailure(cfg, email, ip)
+		return ErrOtpExpired
+	}
+
+	actual, err := HashOtp(email, strings.TrimSpace(otp))
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(actual)) {
+		if registerOtpFailure(cfg, email, ip) {
+			return ErrOtpLocked
+		}
+		return ErrOtpInvalid
+	}
+
+	// OTP sekali pakai
+	_ = redis.DeleteOtp(email)
+	_ = redis.Del("otp:attempts:email:"+email, "otp:cooldown:"+email)
+	return nil
+}
+
+// registerOtpFailure menaikkan counter gagal dan mengembalikan true jika lockout terjadi
+func registerOtpFailure(cfg OtpConfig, email, ip string) bool {
+	locked := false
+
+	n, err := redis.Incr("otp:attempts:email:"+email, cfg.LockoutDuration)
+	if err == nil && int(n) >= cfg.MaxAttempts {
+		_ = redis.Set("otp:lock:email:"+email, "1", cfg.LockoutDuration)
+		_ = redis.Del("otp:attempts:email:" + email)
+		// OTP yang sedang aktif tidak boleh dipakai lagi setelah lockout
+		_ = redis.DeleteOtp(email)
+		locked = true
+	}
+
+	if ip != "" {
+		n, err := redis.Incr("otp:attempts:ip:"+ip, cfg.LockoutDuration)
+		if err == nil && int(n) >= cfg.IPMaxAttempts {
+			_ = redis.Set("otp:lock:ip:"+ip, "1", cfg.LockoutDuration)
+			_ = redis.Del("otp:attempts:ip:" + ip)
+			locked = true
+		}
+	}
+
+	if locked {
+		fmt.Printf("🔒 OTP lockout for email=%s ip=%s\n", email, ip)
+	}
+	return locked
+}
+
+func isOtpLocked(email, ip string) bool {
+	if _, found, _ := redis.Get("otp:lock:email:" + email); found {
+		return true
+	}
+	if ip != "" {
+		if _, found, _ := redis.Get("otp:lock:ip:" + ip); found {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
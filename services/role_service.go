@@ -0,0 +1,57 @@
+package services
+
+import (
+	"doc-tracker/models"
+	"os"
+	"strings"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+	RoleUser    = "user"
+)
+
+// GetUserRole menentukan role user dari env ADMIN_EMAILS / AUDITOR_EMAILS (dipisah koma)
+func GetUserRole(email string) string {
+	email = normalizeEmail(email)
+	if containsEmail(os.Getenv("ADMIN_EMAILS"), email) {
+		return RoleAdmin
+	}
+	if containsEmail(os.Getenv("AUDITOR_EMAILS"), email) {
+		return RoleAuditor
+	}
+	return RoleUser
+}
+
+func containsEmail(list, email string) bool {
+	for _, e := range strings.Split(list, ",") {
+		if normalizeEmail(e) == email && email != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAuditorOrAdmin true untuk role yang boleh melihat laporan lintas tracker
+func IsAuditorOrAdmin(email string) bool {
+	role := GetUserRole(email)
+	return role == RoleAdmin || role == RoleAuditor
+}
+
+// CanViewTracker true jika email adalah creator, pemegang checkpoint, admin atau auditor
+func CanViewTracker(email string, t models.Tracker) bool {
+	email = normalizeEmail(email)
+	if email == "" {
+		return false
+	}
+	if normalizeEmail(t.Creator) == email || IsAuditorOrAdmin(email) {
+		return true
+	}
+	for _, cp := range t.Checkpoints {
+		if normalizeEmail(cp.Email) == email {
+			return true
+		}
+	}
+	return false
+}
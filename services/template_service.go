@@ -0,0 +1,409 @@
+package services
+
+import (
+	"doc-tracker/models"
+	"doc-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrTemplateInvalid   = errors.New("invalid template")
+	ErrTemplateForbidden = errors.New("only the template owner or an admin can change this template")
+	ErrTemplateVariable  = errors.New("template variable could not be resolved")
+)
+
+const templateFile = "data/templates.json"
+
+// TrackerTemplate rute checkpoint yang dipakai berulang (mis. PO, kontrak,
+// form HR). Template hanya disimpan di node, tidak masuk blockchain.
+type TrackerTemplate struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Type        string               `json:"type"`
+	Privacy     string               `json:"privacy,omitempty"`
+	TargetEnd   string               `json:"target_end,omitempty"`
+	Checkpoints []TemplateCheckpoint `json:"checkpoints"`
+	Variables   []string             `json:"variables,omitempty"` // variabel yang wajib diisi saat membuat tracker
+	CreatedBy   string               `json:"created_by"`
+	CreatedAt   int64                `json:"created_at"`
+	UpdatedAt   int64                `json:"updated_at,omitempty"`
+}
+
+// TemplateCheckpoint satu langkah template. Email dan Company boleh berupa
+// variabel: {{creator}}, {{creator.manager}}, {{nama}} atau {{nama.manager}}.
+type TemplateCheckpoint struct {
+	Email      string `json:"email"`
+	Type       string `json:"type"`
+	Company    string `json:"company,omitempty"`
+	Role       string `json:"role"`
+	IsViewable bool   `json:"is_view"`
+	DueIn      string `json:"due_in,omitempty"` // durasi Go sejak tracker dibuat, mis. "48h"
+}
+
+// TemplateInstance input membuat tracker dari template
+type TemplateInstance struct {
+	Variables   map[string]string           `json:"variables,omitempty"`
+	Privacy     string                      `json:"privacy,omitempty"`
+	TargetEnd   string                      `json:"target_end,omitempty"`
+	Note        string                      `json:"note,omitempty"`
+	Attachments []models.Attachment         `json:"attachments,omitempty"`
+	Document    *models.DocumentFingerprint `json:"document,omitempty"`
+	Overrides   []CheckpointOverride        `json:"overrides,omitempty"`
+}
+
+// CheckpointOverride mengubah satu langkah template untuk satu tracker saja;
+// field nil memakai nilai template
+type CheckpointOverride struct {
+	Step       int     `json:"step"` // mulai 1, sesuai urutan di template
+	Skip       bool    `json:"skip,omitempty"`
+	Email      *string `json:"email,omitempty"`
+	Company    *string `json:"company,omitempty"`
+	Role       *string `json:"role,omitempty"`
+	IsViewable *bool   `json:"is_view,omitempty"`
+	DueIn      *string `json:"due_in,omitempty"`
+	Note       string  `json:"note,omitempty"` // note untuk checkpoint ini
+}
+
+var (
+	templates       = map[string]*TrackerTemplate{}
+	templateMu      sync.Mutex
+	templatesLoaded bool
+
+	// {{nama}} atau {{nama.manager.manager}}
+	templateVarPattern = regexp.MustCompile(`^\{\{\s*([a-z][a-z0-9_]*)((?:\.manager)*)\s*\}\}$`)
+)
+
+// ListTemplates semua template, urut nama
+func ListTemplates() []TrackerTemplate {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	loadTemplatesLocked()
+
+	list := make([]TrackerTemplate, 0, len(templates))
+	for _, t := range templates {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	return list
+}
+
+// GetTemplate template berdasarkan ID
+func GetTemplate(id string) (TrackerTemplate, error) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	loadTemplatesLocked()
+
+	t, ok := templates[id]
+	if !ok {
+		return TrackerTemplate{}, ErrTemplateNotFound
+	}
+	return *t, nil
+}
+
+// CreateTemplate menyimpan template baru milik email
+func CreateTemplate(email string, input TrackerTemplate) (TrackerTemplate, error) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	loadTemplatesLocked()
+
+	input.ID = uuid.New().String()
+	input.CreatedBy = normalizeEmail(email)
+	input.CreatedAt = time.Now().Unix()
+	input.UpdatedAt = 0
+	if err := validateTemplateLocked(&input); err != nil {
+		return TrackerTemplate{}, err
+	}
+
+	templates[input.ID] = &input
+	if err := saveTemplatesLocked(); err != nil {
+		delete(templates, input.ID)
+		return TrackerTemplate{}, err
+	}
+	return input, nil
+}
+
+// UpdateTemplate mengganti isi template; hanya pemilik atau admin
+func UpdateTemplate(email, id string, input TrackerTemplate) (TrackerTemplate, error) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	loadTemplatesLocked()
+
+	current, ok := templates[id]
+	if !ok {
+		return TrackerTemplate{}, ErrTemplateNotFound
+	}
+	if !canManageTemplate(email, current) {
+		return TrackerTemplate{}, ErrTemplateForbidden
+	}
+
+	input.ID, input.CreatedBy, input.CreatedAt = current.ID, current.CreatedBy, current.CreatedAt
+	input.UpdatedAt = time.Now().Unix()
+	if err := validateTemplateLocked(&input); err != nil {
+		return TrackerTemplate{}, err
+	}
+
+	templates[id] = &input
+	if err := saveTemplatesLocked(); err != nil {
+		templates[id] = current
+		return TrackerTemplate{}, err
+	}
+	return input, nil
+}
+
+// DeleteTemplate menghapus template; tracker yang sudah dibuat tidak terpengaruh
+func DeleteTemplate(email, id string) error {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	loadTemplatesLocked()
+
+	current, ok := templates[id]
+	if !ok {
+		return ErrTemplateNotFound
+	}
+	if !canManageTemplate(email, current) {
+		return ErrTemplateForbidden
+	}
+	delete(templates, id)
+	if err := saveTemplatesLocked(); err != nil {
+		templates[id] = current
+		return err
+	}
+	return nil
+}
+
+// BuildTrackerFromTemplate menyusun input tracker dari template, variabel dan
+// override tanpa menyimpannya (dipakai juga untuk preview)
+func BuildTrackerFromTemplate(creator, id string, in TemplateInstance) (models.Tracker, error) {
+	tpl, err := GetTemplate(id)
+	if err != nil {
+		return models.Tracker{}, err
+	}
+	creator = normalizeEmail(creator)
+
+	overrides := make(map[int]CheckpointOverride, len(in.Overrides))
+	for _, o := range in.Overrides {
+		if o.Step < 1 || o.Step > len(tpl.Checkpoints) {
+			return models.Tracker{}, fmt.Errorf("%w: override step %d out of range (1-%d)", ErrTemplateInvalid, o.Step, len(tpl.Checkpoints))
+		}
+		if _, dup := overrides[o.Step]; dup {
+			return models.Tracker{}, fmt.Errorf("%w: duplicate override for step %d", ErrTemplateInvalid, o.Step)
+		}
+		overrides[o.Step] = o
+	}
+
+	now := time.Now()
+	tracker := models.Tracker{
+		Type:        tpl.Type,
+		Privacy:     firstNonEmpty(in.Privacy, tpl.Privacy),
+		Creator:     creator,
+		TargetEnd:   firstNonEmpty(in.TargetEnd, tpl.TargetEnd),
+		Note:        in.Note,
+		Attachments: in.Attachments,
+		Document:    in.Document,
+		Template:    tpl.ID,
+	}
+	for i, step := range tpl.Checkpoints {
+		o := overrides[i+1]
+		if o.Skip {
+			continue
+		}
+		if o.Email != nil {
+			step.Email = *o.Email
+		}
+		if o.Company != nil {
+			step.Company = *o.Company
+		}
+		if o.Role != nil {
+			step.Role = *o.Role
+		}
+		if o.IsViewable != nil {
+			step.IsViewable = *o.IsViewable
+		}
+		if o.DueIn != nil {
+			step.DueIn = *o.DueIn
+		}
+
+		email, err := resolveTemplateValue(step.Email, creator, in.Variables)
+		if err != nil {
+			return models.Tracker{}, fmt.Errorf("step %d email: %w", i+1, err)
+		}
+		company, err := resolveTemplateValue(step.Company, creator, in.Variables)
+		if err != nil {
+			return models.Tracker{}, fmt.Errorf("step %d company: %w", i+1, err)
+		}
+		if !strings.Contains(email, "@") {
+			return models.Tracker{}, fmt.Errorf("%w: step %d email %q is not an email address", ErrTemplateInvalid, i+1, email)
+		}
+
+		cp := models.Checkpoint{
+			Email:      normalizeEmail(email),
+			Type:       step.Type,
+			Company:    company,
+			Role:       step.Role,
+			IsViewable: step.IsViewable,
+			Note:       o.Note,
+		}
+		if step.DueIn != "" {
+			d, err := time.ParseDuration(step.DueIn)
+			if err != nil || d <= 0 {
+				return models.Tracker{}, fmt.Errorf("%w: step %d due_in must be a positive duration such as 48h", ErrTemplateInvalid, i+1)
+			}
+			cp.DueAt = now.Add(d).Unix()
+		}
+		tracker.Checkpoints = append(tracker.Checkpoints, cp)
+	}
+	if len(tracker.Checkpoints) == 0 {
+		return models.Tracker{}, fmt.Errorf("%w: at least one checkpoint is required", ErrTemplateInvalid)
+	}
+	return tracker, nil
+}
+
+// CreateTrackerFromTemplate membuat tracker dari template atas nama creator
+func CreateTrackerFromTemplate(creator, id string, in TemplateInstance) (models.Tracker, error) {
+	tracker, err := BuildTrackerFromTemplate(creator, id, in)
+	if err != nil {
+		return models.Tracker{}, err
+	}
+	return CreateTracker(tracker)
+}
+
+// resolveTemplateValue mengganti variabel {{...}} dengan email atau nilai
+// dari instance; teks biasa dikembalikan apa adanya
+func resolveTemplateValue(value, creator string, vars map[string]string) (string, error) {
+	value = strings.TrimSpace(value)
+	m := templateVarPattern.FindStringSubmatch(value)
+	if m == nil {
+		if strings.Contains(value, "{{") {
+			return "", fmt.Errorf("%w: unsupported variable %s", ErrTemplateVariable, value)
+		}
+		return value, nil
+	}
+
+	resolved := strings.TrimSpace(vars[m[1]])
+	if m[1] == "creator" {
+		resolved = creator
+	}
+	if resolved == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrTemplateVariable, m[1])
+	}
+	for range strings.Count(m[2], ".manager") {
+		manager := ManagerOf(resolved)
+		if manager == "" {
+			return "", fmt.Errorf("%w: no manager registered for %s", ErrTemplateVariable, resolved)
+		}
+		resolved = manager
+	}
+	return resolved, nil
+}
+
+// validateTemplateLocked menormalkan dan memeriksa template sebelum disimpan
+func validateTemplateLocked(t *TrackerTemplate) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Type = strings.TrimSpace(t.Type)
+	if t.Name == "" || len(t.Name) > 100 {
+		return fmt.Errorf("%w: name is required (max 100 characters)", ErrTemplateInvalid)
+	}
+	if t.Type == "" {
+		return fmt.Errorf("%w: type is required", ErrTemplateInvalid)
+	}
+	if len(t.Checkpoints) == 0 {
+		return fmt.Errorf("%w: at least one checkpoint is required", ErrTemplateInvalid)
+	}
+	for _, other := range templates {
+		if other.ID != t.ID && strings.EqualFold(other.Name, t.Name) {
+			return fmt.Errorf("%w: a template named %q already exists", ErrTemplateInvalid, t.Name)
+		}
+	}
+
+	vars := map[string]bool{}
+	for i := range t.Checkpoints {
+		cp := &t.Checkpoints[i]
+		cp.Email = strings.TrimSpace(cp.Email)
+		if cp.Email == "" || cp.Role == "" {
+			return fmt.Errorf("%w: step %d requires email and role", ErrTemplateInvalid, i+1)
+		}
+		if cp.Type != "" && cp.Type != "internal" && cp.Type != "external" {
+			return fmt.Errorf("%w: step %d type must be internal or external", ErrTemplateInvalid, i+1)
+		}
+		if cp.DueIn != "" {
+			if d, err := time.ParseDuration(cp.DueIn); err != nil || d <= 0 {
+				return fmt.Errorf("%w: step %d due_in must be a positive duration such as 48h", ErrTemplateInvalid, i+1)
+			}
+		}
+		for _, v := range []string{cp.Email, cp.Company} {
+			v = strings.TrimSpace(v)
+			if m := templateVarPattern.FindStringSubmatch(v); m != nil {
+				if m[1] != "creator" {
+					vars[m[1]] = true
+				}
+			} else if strings.Contains(v, "{{") {
+				return fmt.Errorf("%w: step %d has unsupported variable %s", ErrTemplateInvalid, i+1, v)
+			}
+		}
+		if templateVarPattern.MatchString(cp.Email) {
+			continue
+		}
+		if !strings.Contains(cp.Email, "@") {
+			return fmt.Errorf("%w: step %d email must be an email address or variable", ErrTemplateInvalid, i+1)
+		}
+		cp.Email = normalizeEmail(cp.Email)
+	}
+
+	t.Variables = t.Variables[:0]
+	for v := range vars {
+		t.Variables = append(t.Variables, v)
+	}
+	sort.Strings(t.Variables)
+	return nil
+}
+
+func canManageTemplate(email string, t *TrackerTemplate) bool {
+	email = normalizeEmail(email)
+	return email != "" && (email == t.CreatedBy || GetUserRole(email) == RoleAdmin)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func loadTemplatesLocked() {
+	if templatesLoaded {
+		return
+	}
+	templatesLoaded = true
+	data, err := os.ReadFile(templateFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &templates); err != nil {
+		fmt.Printf("❌ Failed to parse %s: %v\n", templateFile, err)
+	}
+}
+
+func saveTemplatesLocked() error {
+	if err := utils.CreateDirIfNotExists("data"); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(templateFile, data, 0600)
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"doc-tracker/storage/redis"
+	"doc-tracker/utils"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	totpFile          = "data/totp.json"
+	totpIssuer        = "DocTracker"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTotpRequired     = errors.New("TOTP code is required")
+	ErrTotpInvalid      = errors.New("invalid TOTP or recovery code")
+	ErrTotpNotEnrolled  = errors.New("TOTP is not enrolled")
+	ErrTotpAlreadyOn    = errors.New("TOTP is already active")
+	ErrTotpCannotRemove = errors.New("TOTP is mandatory for this account")
+	ErrTotpLocked       = errors.New("too many TOTP attempts, try again later")
+	ErrTotpSecret       = errors.New("TOTP is unavailable: TOTP_ENCRYPTION_KEY or JWT_SECRET is not configured")
+)
+
+// TotpRecord adalah data enrollment TOTP per email. Secret disimpan terenkripsi,
+// recovery code disimpan sebagai hash SHA-256.
+type TotpRecord struct {
+	Email           string   `json:"email"`
+	EncryptedSecret string   `json:"encrypted_secret"`
+	Enabled         bool     `json:"enabled"`
+	EnrolledAt      int64    `json:"enrolled_at,omitempty"`
+	LastStep        uint64   `json:"last_step,omitempty"` // mencegah replay kode yang sama
+	RecoveryCodes   []string `json:"recovery_codes,omitempty"`
+}
+
+// TotpEnrollment dikembalikan saat enroll agar user bisa scan QR
+type TotpEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QR     string `json:"qr"` // data URI PNG
+}
+
+var (
+	totpRecords = map[string]*TotpRecord{}
+	totpMu      sync.Mutex
+	totpLoaded  bool
+)
+
+// IsTotpRequired true jika role user atau email-nya diwajibkan memakai TOTP.
+// TOTP_REQUIRED_ROLES default "admin,auditor"; TOTP_REQUIRED_EMAILS untuk per-user.
+func IsTotpRequired(email string) bool {
+	roles := os.Getenv("TOTP_REQUIRED_ROLES")
+	if roles == "" {
+		roles = RoleAdmin + "," + RoleAuditor
+	}
+	role := GetUserRole(email)
+	for _, r := range strings.Split(roles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return containsEmail(os.Getenv("TOTP_REQUIRED_EMAILS"), normalizeEmail(email))
+}
+
+// IsTotpEnabled true jika user sudah menyelesaikan aktivasi TOTP
+func IsTotpEnabled(email string) bool {
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	rec, ok := totpRecords[normalizeEmail(email)]
+	return ok && rec.Enabled
+}
+
+// EnrollTotp membuat secret baru (belum aktif sampai ActivateTotp dipanggil)
+func EnrollTotp(email string) (TotpEnrollment, error) {
+	email = normalizeEmail(email)
+
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	if rec, ok := totpRecords[email]; ok && rec.Enabled {
+		return TotpEnrollment{}, ErrTotpAlreadyOn
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return TotpEnrollment{}, err
+	}
+	encrypted, err := encryptTotpSecret(secret)
+	if err != nil {
+		return TotpEnrollment{}, err
+	}
+
+	totpRecords[email] = &TotpRecord{
+		Email:           email,
+		EncryptedSecret: encrypted,
+	}
+	if err := saveTotpLocked(); err != nil {
+		return TotpEnrollment{}, err
+	}
+
+	uri := utils.TOTPProvisioningURI(totpIssuer, email, secret)
+	png, err := utils.GenerateQRCode(uri)
+	if err != nil {
+		return TotpEnrollment{}, err
+	}
+
+	return TotpEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QR:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ActivateTotp memverifikasi kode pertama dan mengembalikan recovery code (sekali tampil)
+func ActivateTotp(email, code string) ([]string, error) {
+	email = normalizeEmail(email)
+
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	rec, ok := totpRecords[email]
+	if !ok {
+		return nil, ErrTotpNotEnrolled
+	}
+	if rec.Enabled {
+		return nil, ErrTotpAlreadyOn
+	}
+	if err := checkTotpAttemptLocked(rec, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	rec.Enabled = true
+	rec.EnrolledAt = time.Now().Unix()
+	rec.RecoveryCodes = hashes
+
+	if err := saveTotpLocked(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor menerima kode TOTP atau recovery code (recovery code hangus setelah dipakai)
+func VerifySecondFactor(email, code, recoveryCode string) error {
+	email = normalizeEmail(email)
+
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	rec, ok := totpRecords[email]
+	if !ok || !rec.Enabled {
+		return ErrTotpNotEnrolled
+	}
+
+	if code != "" {
+		if err := checkTotpAttemptLocked(rec, code); err != nil {
+			return err
+		}
+		return saveTotpLocked()
+	}
+
+	if recoveryCode != "" {
+		if isTotpLocked(email) {
+			return ErrTotpLocked
+		}
+		hash := hashRecoveryCode(recoveryCode)
+		for i, h := range rec.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+				rec.RecoveryCodes = append(rec.RecoveryCodes[:i], rec.RecoveryCodes[i+1:]...)
+				fmt.Printf("🔑 Recovery code used by %s, %d remaining\n", email, len(rec.RecoveryCodes))
+				resetTotpFailures(email)
+				return saveTotpLocked()
+			}
+		}
+		registerTotpFailure(email)
+		return ErrTotpInvalid
+	}
+
+	return ErrTotpRequired
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code setelah verifikasi TOTP
+func RegenerateRecoveryCodes(email, code string) ([]string, error) {
+	email = normalizeEmail(email)
+
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	rec, ok := totpRecords[email]
+	if !ok || !rec.Enabled {
+		return nil, ErrTotpNotEnrolled
+	}
+	if err := checkTotpAttemptLocked(rec, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	rec.RecoveryCodes = hashes
+	if err := saveTotpLocked(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTotp menghapus enrollment, tidak diizinkan jika TOTP wajib untuk user
+func DisableTotp(email, code string) error {
+	email = normalizeEmail(email)
+	if IsTotpRequired(email) {
+		return ErrTotpCannotRemove
+	}
+
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	rec, ok := totpRecords[email]
+	if !ok || !rec.Enabled {
+		return ErrTotpNotEnrolled
+	}
+	if err := checkTotpAttemptLocked(rec, code); err != nil {
+		return err
+	}
+
+	delete(totpRecords, email)
+	return saveTotpLocked()
+}
+
+// RecoveryCodesLeft jumlah recovery code yang belum dipakai
+func RecoveryCodesLeft(email string) int {
+	totpMu.Lock()
+	defer totpMu.Unlock()
+	loadTotpLocked()
+
+	if rec, ok := totpRecords[normalizeEmail(email)]; ok {
+		return len(rec.RecoveryCodes)
+	}
+	return 0
+}
+
+// checkTotpAttemptLocked memeriksa kode TOTP dengan batas salah tebak per email
+// (TOTP_MAX_ATTEMPTS, default 5) dan lockout TOTP_LOCKOUT_SECONDS (default 15 menit)
+func checkTotpAttemptLocked(rec *TotpRecord, code string) error {
+	// Key tidak dikonfigurasi bukan salah tebak, jangan dihitung sebagai gagal
+	if _, err := totpKey(); err != nil {
+		return err
+	}
+	if isTotpLocked(rec.Email) {
+		return ErrTotpLocked
+	}
+	if !checkTotpCodeLocked(rec, code) {
+		registerTotpFailure(rec.Email)
+		return ErrTotpInvalid
+	}
+	resetTotpFailures(rec.Email)
+	return nil
+}
+
+// registerTotpFailure menaikkan counter gagal, lockout jika mencapai batas
+func registerTotpFailure(email string) {
+	maxAttempts := utils.GetEnvInt("TOTP_MAX_ATTEMPTS", 5)
+	lockout := utils.GetEnvSeconds("TOTP_LOCKOUT_SECONDS", 15*time.Minute)
+
+	n, err := redis.Incr("totp:attempts:"+email, lockout)
+	if err == nil && int(n) >= maxAttempts {
+		_ = redis.Set("totp:lock:"+email, "1", lockout)
+		_ = redis.Del("totp:attempts:" + email)
+		fmt.Printf("🔒 TOTP lockout for email=%s\n", email)
+	}
+}
+
+func resetTotpFailures(email string) {
+	_ = redis.Del("totp:attempts:" + email)
+}
+
+func isTotpLocked(email string) bool {
+	_, found, _ := redis.Get("totp:lock:" + email)
+	return found
+}
+
+func checkTotpCodeLocked(rec *TotpRecord, code string) bool {
+	secret, err := decryptTotpSecret(rec.EncryptedSecret)
+	if err != nil {
+		fmt.Printf("❌ Failed to decrypt TOTP secret for %s: %v\n", rec.Email, err)
+		return false
+	}
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), 1)
+	if !ok || step <= rec.LastStep {
+		return false
+	}
+	rec.LastStep = step
+	return true
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// totpKey diturunkan dari TOTP_ENCRYPTION_KEY, fallback ke JWT_SECRET. Gagal
+// jika keduanya kosong (tidak memakai key konstan).
+func totpKey() ([]byte, error) {
+	secret := os.Getenv("TOTP_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, ErrTotpSecret
+	}
+	key := sha256.Sum256([]byte("totp:" + secret))
+	return key[:], nil
+}
+
+func encryptTotpSecret(secret string) (string, error) {
+	key, err := totpKey()
+	if err != nil {
+		return "", err
+	}
+	ct, err := utils.EncryptData(key, []byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ct), nil
+}
+
+func decryptTotpSecret(encrypted string) (string, error) {
+	key, err := totpKey()
+	if err != nil {
+		return "", err
+	}
+	ct, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	pt, err := utils.DecryptData(key, ct)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+func loadTotpLocked() {
+	if totpLoaded {
+		return
+	}
+	totpLoaded = true
+	data, err := os.ReadFile(totpFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &totpRecords); err != nil {
+		fmt.Printf("❌ Failed to parse %s: %v\n", totpFile, err)
+	}
+}
+
+func saveTotpLocked() error {
+	if err := utils.CreateDirIfNotExists("data"); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(totpRecords, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(totpFile, data, 0600)
+}
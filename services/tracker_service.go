@@ -5,12 +5,9 @@ import (
 	"doc-tracker/mempool"
 	"doc-tracker/models"
 	"doc-tracker/utils"
-	"encoding/hex"
 	"fmt"
-	"os"
 	"time"
 
-	eciesgo "github.com/ecies/go/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -27,34 +24,7 @@ func init() {
 		return
 	}
 
-	secret := os.Getenv("TRACKER_SECRET")
-	if secret == "" {
-		panic("TRACKER_SECRET tidak di-set di environment. Pastikan .env dibaca.")
-	}
-	secretBytes, _ := hex.DecodeString(secret)
-	if len(secretBytes) != 32 {
-		panic(fmt.Sprintf("TRACKER_SECRET harus 32 byte (256 bit) untuk ECIES Private Key, tetapi mendapatkan %d byte", len(secretBytes)))
-	}
-	priv := eciesgo.NewPrivateKeyFromBytes(secretBytes) // generate new key
-	pub := priv.PublicKey
-	fmt.Println("Private:", priv.Hex())
-	fmt.Println("Public:", pub.Hex(true))
-
-	// Konversi ke *ecdsa.PrivateKey dan *ecdsa.PublicKey
-	// Konversi ECIES ke ECDSA
-	privECDSA := utils.ECIESPrivateKeyToECDSA(priv)
-	pubECDSA := &privECDSA.PublicKey
-
-	// Simpan ke file .pem
-	if err := utils.SavePEMKey("data/private.pem", privECDSA); err != nil {
-		panic(err)
-	}
-	if err := utils.SavePEMPub("data/public.pem", pubECDSA); err != nil {
-		panic(err)
-	}
-
-	fmt.Println("Kunci berhasil disimpan ke private.pem dan public.pem")
-
+	// Key node (storage/signing) dikelola package keymanager, tidak lagi dari TRACKER_SECRET
 	LoadTrackersFromDisk()
 }
 
@@ -62,21 +32,49 @@ func CreateTracker(input models.Tracker) (models.Tracker, error) {
 	input.ID = uuid.New().String()
 	input.CreatedAt = time.Now().Unix()
 	input.Status = "progress"
+	if err := normalizeDocument(&input); err != nil {
+		return models.Tracker{}, err
+	}
 
 	// Generate wallet/address untuk pengaju
 	senderWallet := GetOrCreateWallet(input.Creator)
+	if senderWallet.EncryptionKey == nil {
+		return models.Tracker{}, fmt.Errorf("wallet for %s is not available", input.Creator)
+	}
 	input.CreatorAddr = senderWallet.Address
 
-	// Enkripsi checkpoint jika perlu
+	// Penerima content key tracker: creator dan checkpoint yang boleh melihat
+	viewers := map[string]WalletInfo{senderWallet.Address: senderWallet}
+
+	// Enkripsi checkpoint jika perlu. Wallet non-custodial (public key terdaftar)
+	// diperlakukan sama, enkripsi hanya butuh public key penerima.
 	for i, cp := range input.Checkpoints {
+		// Evidence dan status selesai hanya boleh diisi lewat upload/complete,
+		// bukan dari input create (path evidence dibaca node saat download)
+		input.Checkpoints[i].Evidence = nil
+		input.Checkpoints[i].EvidenceHash = ""
+		input.Checkpoints[i].EvidencePath = ""
+		input.Checkpoints[i].EvidenceKeys = nil
+		input.Checkpoints[i].EvidenceType = ""
+		input.Checkpoints[i].EvidenceSize = 0
+		input.Checkpoints[i].IsCompleted = false
+		input.Checkpoints[i].CompletedAt = 0
+
 		receiverWallet := GetOrCreateWallet(cp.Email)
+		if receiverWallet.EncryptionKey == nil {
+			return models.Tracker{}, fmt.Errorf("wallet for %s is not available", cp.Email)
+		}
 		input.Checkpoints[i].Address = receiverWallet.Address
 
 		// Jika boleh melihat isi dokumen, enkripsi
 		if cp.IsViewable {
-			encrypted := utils.EncryptWithPublicKey(cp.Note, receiverWallet.PublicKey)
+			encrypted, err := EncryptCheckpointNote(input.ID, cp.Note, receiverWallet)
+			if err != nil {
+				return models.Tracker{}, fmt.Errorf("failed to encrypt note for %s: %v", cp.Email, err)
+			}
 			input.Checkpoints[i].EncryptedNote = encrypted
 			input.Checkpoints[i].Note = "" // kosongkan untuk keamanan
+			viewers[receiverWallet.Address] = receiverWallet
 		} else {
 			// Tidak boleh melihat, kosongkan isinya
 			input.Checkpoints[i].Note = ""
@@ -84,6 +82,10 @@ func CreateTracker(input models.Tracker) (models.Tracker, error) {
 		}
 	}
 
+	if err := sealTrackerContent(&input, viewers); err != nil {
+		return models.Tracker{}, err
+	}
+
 	// Simpan ke mempool dan broadcast
 	mempool.Add(&input)
 	return input, nil
@@ -161,8 +163,9 @@ func GetTrackersByAddress(address string) ([]models.Tracker, error) {
 	if err != nil {
 		return nil, err
 	}
+	owner := walletByAddress(address)
 	for i := len(trackers) - 1; i >= 0; i-- {
-		if !hasCheckpointForAddress(&trackers[i], address) {
+		if !hasCheckpointForAddress(&trackers[i], owner) {
 			// Hapus tracker jika tidak ada checkpoint untuk address ini
 			trackers = append(trackers[:i], trackers[i+1:]...)
 		}
@@ -184,8 +187,10 @@ func GetTrackerByHash(hash string) (models.Tracker, error) {
 
 	for _, t := range trackers {
 		for _, cp := range t.Checkpoints {
-			if cp.EvidenceHash == hash {
-				return t, nil
+			for _, item := range cp.EvidenceItems() {
+				if item.Hash == hash {
+					return t, nil
+				}
 			}
 		}
 	}
@@ -195,16 +200,18 @@ func GetTrackerByHash(hash string) (models.Tracker, error) {
 func GetEvidencePath(tracker models.Tracker, hash string) string {
 	// Implement logic to get the evidence path based on trackerID and hash
 	for _, cp := range tracker.Checkpoints {
-		if cp.EvidencePath != "" && cp.EvidenceHash == hash {
-			return cp.EvidencePath
+		for _, item := range cp.EvidenceItems() {
+			if item.Path != "" && item.Hash == hash {
+				return item.Path
+			}
 		}
 	}
 	return ""
 }
 
-func hasCheckpointForAddress(tx *models.Tracker, address string) bool {
+func hasCheckpointForAddress(tx *models.Tracker, owner WalletInfo) bool {
 	for _, cp := range tx.Checkpoints {
-		if cp.Address == address {
+		if owner.HasAddress(cp.Address) {
 			return true
 		}
 	}
@@ -2,103 +2,260 @@ package services
 
 import (
 	"crypto/ecdsa"
+	"doc-tracker/keystore"
 	"doc-tracker/utils"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 )
 
 type WalletInfo struct {
-	PrivateKey *ecdsa.PrivateKey
+	PrivateKey *ecdsa.PrivateKey // key signing, hanya terisi setelah UnlockWallet
 	Address    string
 	PublicKey  *ecdsa.PublicKey
+	Custodial  bool // false jika user hanya mendaftarkan public key
+
+	EncryptionKey        *ecdsa.PublicKey  // subkey enkripsi note
+	EncryptionPrivateKey *ecdsa.PrivateKey // hanya terisi setelah UnlockWallet
+	LegacyAddress        string            // address derivasi lama, kosong untuk wallet baru
+}
+
+// HasAddress true jika address adalah address wallet saat ini atau address
+// derivasi lama (checkpoint dan envelope lama masih memakai address lama)
+func (w WalletInfo) HasAddress(address string) bool {
+	return address != "" && (w.Address == address || w.LegacyAddress == address)
+}
+
+// walletByAddress mencari wallet pemilik address baru atau lama. Address yang
+// tidak terdaftar menghasilkan WalletInfo yang hanya berisi address tersebut.
+func walletByAddress(address string) WalletInfo {
+	if email, ok := GetEmailByAddress(address); ok {
+		if wallet, err := GetWalletPublic(email); err == nil {
+			return wallet
+		}
+	}
+	return WalletInfo{Address: address}
 }
 
+// walletMap hanya cache info publik (address + public key), private key tidak disimpan di memori
 var walletMap = make(map[string]WalletInfo)
 var mu sync.Mutex
 
 func GetAddressFromEmail(email string) string {
-
-	wallet := GetWalletFromPem(email)
-	if wallet.PrivateKey == nil || wallet.PublicKey == nil || wallet.Address == "" {
+	wallet, err := GetWalletPublic(email)
+	if err != nil || wallet.Address == "" {
 		fmt.Printf("Wallet for %s is not properly initialized\n", email)
 		return ""
 	}
 	return wallet.Address
 }
 
-func GetWalletFromPem(email string) WalletInfo {
+// GetWalletPublic mengambil address dan public key tanpa membuka private key
+func GetWalletPublic(email string) (WalletInfo, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return loadWalletPublicLocked(email)
+}
+
+// UnlockWallet membuka private key wallet custodial dari keystore.
+// Setiap pemanggilan tercatat di audit log keystore dengan purpose-nya.
+func UnlockWallet(email, purpose string) (WalletInfo, error) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	wallet, err := os.ReadFile("wallet/mnemonic/" + email + ".txt")
+	w, err := loadWalletPublicLocked(email)
 	if err != nil {
-		fmt.Printf("Error reading wallet file for %s: %v\n", email, err)
-		return WalletInfo{}
+		return WalletInfo{}, err
 	}
 
-	// Jika wallet tidak ada, buat baru
-	privateKey, publicKey, address := utils.PrivateKeyFromMnemonic(string(wallet))
-
-	return WalletInfo{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
-		Address:    address,
+	keys, err := keystore.UnwrapKeys(email, purpose)
+	if err != nil {
+		return WalletInfo{}, err
 	}
+
+	w.PrivateKey = keys.Signing
+	w.PublicKey = &keys.Signing.PublicKey
+	w.EncryptionPrivateKey = keys.Encryption
+	w.EncryptionKey = &keys.Encryption.PublicKey
+	return w, nil
 }
 
 func GetWalletByEmail(email string) (WalletInfo, bool) {
-	mu.Lock()
-
-	defer mu.Unlock()
-
-	wallet := GetOrCreateWallet(email) // Pastikan wallet sudah ada atau dibuat
-	if wallet.PrivateKey == nil || wallet.PublicKey == nil || wallet.Address == "" {
+	wallet, err := UnlockWallet(email, "get-wallet")
+	if err != nil || wallet.PrivateKey == nil || wallet.PublicKey == nil || wallet.Address == "" {
 		fmt.Printf("Wallet for %s is not properly initialized\n", email)
 		return WalletInfo{}, false
 	}
 	return wallet, true
 }
 
+// GetOrCreateWallet mengembalikan info publik wallet email, membuat wallet
+// custodial baru (tersimpan terenkripsi di keystore) jika belum ada.
 func GetOrCreateWallet(email string) WalletInfo {
 	mu.Lock()
 	defer mu.Unlock()
 
-	if w, exists := walletMap[email]; exists {
+	if w, err := loadWalletPublicLocked(email); err == nil {
 		return w
 	}
 
 	// Gunakan mnemonic unik per email
 	mnemonic := utils.GenerateMnemonic()
+	kf, err := keystore.StoreMnemonic(email, mnemonic)
+	if err != nil {
+		fmt.Printf("Error saving wallet for %s: %v\n", email, err)
+		return WalletInfo{}
+	}
+	fmt.Printf("Wallet for %s created with address %s\n", email, kf.Address)
+
+	w, err := walletFromKeyFile(kf)
+	if err != nil {
+		fmt.Printf("Error loading wallet for %s: %v\n", email, err)
+		return WalletInfo{}
+	}
+	walletMap[email] = w
+	return w
+}
+
+// loadWalletPublicLocked membaca wallet dari cache/keystore. Mnemonic plaintext
+// lama di wallet/mnemonic diimpor otomatis. Caller harus memegang mu.
+func loadWalletPublicLocked(email string) (WalletInfo, error) {
+	if w, exists := walletMap[email]; exists {
+		return w, nil
+	}
 
-	privKey, pubKey, addr := utils.PrivateKeyFromMnemonic(mnemonic)
+	kf, err := keystore.Load(email)
+	if err == keystore.ErrNotFound {
+		legacy := filepath.Join(keystore.LegacyDir, email+".txt")
+		if _, statErr := os.Stat(legacy); statErr != nil {
+			return WalletInfo{}, err
+		}
+		kf, err = keystore.ImportLegacyFile(legacy, false)
+		if err != nil {
+			return WalletInfo{}, err
+		}
+		fmt.Printf("⚠️ Imported plaintext mnemonic for %s into keystore, run `doctracker keystore migrate` to remove %s\n", email, legacy)
+	}
+	if err != nil {
+		return WalletInfo{}, err
+	}
 
-	w := WalletInfo{
-		PrivateKey: privKey,
-		PublicKey:  pubKey,
-		Address:    addr,
+	w, err := walletFromKeyFile(kf)
+	if err != nil {
+		return WalletInfo{}, err
 	}
 	walletMap[email] = w
+	return w, nil
+}
 
-	// Opsional: simpan mnemonic ke file atau DB jika diperlukan
-	fmt.Printf("Mnemonic for %s: %s\n", email, mnemonic)
-	saveMnemonicToFile(email, mnemonic)
+func walletFromKeyFile(kf *keystore.KeyFile) (WalletInfo, error) {
+	pub, err := utils.ParsePublicKeyHex(kf.PublicKey)
+	if err != nil {
+		return WalletInfo{}, err
+	}
 
-	return w
+	// Entry v1 belum punya subkey enkripsi, key yang sama dipakai
+	enc := pub
+	if kf.EncryptionKey != "" {
+		if enc, err = utils.ParsePublicKeyHex(kf.EncryptionKey); err != nil {
+			return WalletInfo{}, err
+		}
+	}
+
+	return WalletInfo{
+		PublicKey:     pub,
+		Address:       kf.Address,
+		Custodial:     kf.Custodial(),
+		EncryptionKey: enc,
+		LegacyAddress: kf.LegacyAddress,
+	}, nil
 }
 
-func saveMnemonicToFile(email, mnemonic string) {
-	// Implementasi penyimpanan mnemonic ke file atau database
-	// Misalnya, simpan ke file dengan nama email.txt
-	mkdir := utils.CreateDirIfNotExists("wallet/mnemonic")
-	if mkdir != nil {
-		fmt.Printf("Error creating directory: %v\n", mkdir)
+// GetEmailByAddress mencari email pemilik address dari wallet yang tersimpan
+func GetEmailByAddress(address string) (string, bool) {
+	if kf, ok := keystore.FindByAddress(address); ok {
+		return kf.Email, true
+	}
+	return "", false
+}
+
+// MigrateLegacyWallets mengimpor wallet lama yang masih plaintext ke keystore.
+// Dipanggil sekali saat startup agar lookup address tidak perlu migrasi.
+func MigrateLegacyWallets() {
+	result, err := keystore.MigrateLegacyDir(keystore.LegacyDir, false)
+	if err != nil {
+		log.Printf("⚠️ Legacy wallet migration failed: %v", err)
 		return
 	}
-	filename := fmt.Sprintf("wallet/mnemonic/%s.txt", email)
-	err := utils.WriteToFile(filename, mnemonic)
+	if len(result.Imported) > 0 {
+		fmt.Printf("[Keystore] Imported %d legacy wallet(s)\n", len(result.Imported))
+	}
+	for email, err := range result.Failed {
+		log.Printf("⚠️ Failed to import legacy wallet %s: %v", email, err)
+	}
+}
+
+// ExportWallet mengembalikan keystore JSON terproteksi password untuk wallet custodial
+func ExportWallet(email, password string) ([]byte, error) {
+	return keystore.ExportMnemonic(email, password)
+}
+
+// ImportWalletMnemonic menyimpan mnemonic milik user sebagai wallet custodial
+func ImportWalletMnemonic(email, mnemonic string) (WalletInfo, error) {
+	mnemonic = strings.TrimSpace(mnemonic)
+	if !utils.IsValidMnemonic(mnemonic) {
+		return WalletInfo{}, fmt.Errorf("invalid mnemonic phrase")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	kf, err := keystore.StoreMnemonic(email, mnemonic)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	return cacheKeyFileLocked(kf)
+}
+
+// ImportWalletExport mengimpor file hasil ExportWallet (bisa dari node lain)
+func ImportWalletExport(email string, data []byte, password string) (WalletInfo, error) {
+	mnemonic, err := keystore.DecryptExport(data, password)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	return ImportWalletMnemonic(email, mnemonic)
+}
+
+// RegisterPublicWallet mendaftarkan wallet non-custodial. Kepemilikan key dibuktikan
+// dengan signature atas challenge dari CreateLoginChallenge.
+func RegisterPublicWallet(email, publicKeyHex, nonce, signatureHex string) (WalletInfo, error) {
+	pub, err := utils.ParsePublicKeyHex(publicKeyHex)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	if _, err := VerifyLoginSignature(utils.PublicKeyToAddress(pub), publicKeyHex, nonce, signatureHex); err != nil {
+		return WalletInfo{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	kf, err := keystore.StorePublicKey(email, publicKeyHex)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	return cacheKeyFileLocked(kf)
+}
+
+func cacheKeyFileLocked(kf *keystore.KeyFile) (WalletInfo, error) {
+	w, err := walletFromKeyFile(kf)
 	if err != nil {
-		fmt.Printf("Error saving mnemonic for %s: %v\n", email, err)
+		return WalletInfo{}, err
 	}
-	fmt.Printf("Mnemonic for %s saved to %s\n", email, filename)
+	walletMap[kf.Email] = w
+	return w, nil
 }
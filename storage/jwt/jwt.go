@@ -15,11 +15,19 @@ func GetMapClaims(email string) jwt.MapClaims {
 }
 
 func GenerateJWT(email string) (string, int64, error) {
+	return GenerateJWTWithClaims(email, nil)
+}
+
+// GenerateJWTWithClaims sama seperti GenerateJWT dengan claim tambahan (mis. address, mfa_enroll)
+func GenerateJWTWithClaims(email string, extra map[string]interface{}) (string, int64, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := jwt.MapClaims{
 		"email": email,
 		"exp":   expirationTime.Unix(),
 	}
+	for k, v := range extra {
+		claims[k] = v
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 	return signedToken, expirationTime.Unix(), err
@@ -2,8 +2,11 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,6 +15,21 @@ import (
 var Ctx = context.Background()
 var Client *redis.Client
 
+// memoryEntry adalah satu nilai di store in-memory (fallback jika Redis tidak ada)
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // zero = tidak pernah expired
+}
+
+var memStore = struct {
+	sync.Mutex
+	items     map[string]memoryEntry
+	lastSweep time.Time
+}{items: make(map[string]memoryEntry)}
+
+// memSweepInterval jarak minimum antar sweep entry expired saat write
+const memSweepInterval = time.Minute
+
 func InitRedis() {
 	redisAddr := os.Getenv("REDIS_URL")
 	if redisAddr == "" {
@@ -20,23 +38,171 @@ func InitRedis() {
 	opt, err := redis.ParseURL(redisAddr)
 	if err != nil {
 		fmt.Println("❌ Failed to parse Redis URL:", err)
+		fmt.Println("🟡 Using in-memory store as Redis fallback")
+		return
+	}
+	client := redis.NewClient(opt)
+	if err := client.Ping(Ctx).Err(); err != nil {
+		fmt.Println("❌ Redis not reachable:", err)
+		fmt.Println("🟡 Using in-memory store as Redis fallback")
+		client.Close()
 		return
 	}
-	Client = redis.NewClient(opt)
+	Client = client
 }
 
-func GetOtpFromMemoryOrRedis(email string) string {
-	key := "otp:" + email
-	otp, err := Client.Get(Ctx, key).Result()
+// Set menyimpan value dengan TTL (0 = tanpa expiry) ke Redis atau memory
+func Set(key, value string, ttl time.Duration) error {
+	if Client != nil {
+		return Client.Set(Ctx, key, value, ttl).Err()
+	}
+
+	memStore.Lock()
+	defer memStore.Unlock()
+	sweepExpiredLocked()
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	memStore.items[key] = entry
+	return nil
+}
+
+// Get mengambil value; found=false jika key tidak ada atau sudah expired
+func Get(key string) (string, bool, error) {
+	if Client != nil {
+		val, err := Client.Get(Ctx, key).Result()
+		if err == redis.Nil {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return val, true, nil
+	}
+
+	memStore.Lock()
+	defer memStore.Unlock()
+	entry, ok := memGetLocked(key)
+	if !ok {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Del menghapus satu atau lebih key
+func Del(keys ...string) error {
+	if Client != nil {
+		return Client.Del(Ctx, keys...).Err()
+	}
+
+	memStore.Lock()
+	defer memStore.Unlock()
+	for _, key := range keys {
+		delete(memStore.items, key)
+	}
+	return nil
+}
+
+// Incr menaikkan counter. TTL hanya di-set saat counter pertama kali dibuat,
+// sehingga counter berlaku sebagai fixed window.
+func Incr(key string, ttl time.Duration) (int64, error) {
+	if Client != nil {
+		n, err := Client.Incr(Ctx, key).Result()
+		if err != nil {
+			return 0, err
+		}
+		if n == 1 && ttl > 0 {
+			if err := Client.Expire(Ctx, key, ttl).Err(); err != nil {
+				return n, err
+			}
+		}
+		return n, nil
+	}
+
+	memStore.Lock()
+	defer memStore.Unlock()
+	sweepExpiredLocked()
+	entry, ok := memGetLocked(key)
+	if !ok {
+		entry = memoryEntry{value: "0"}
+		if ttl > 0 {
+			entry.expiresAt = time.Now().Add(ttl)
+		}
+	}
+	n, err := strconv.ParseInt(entry.value, 10, 64)
 	if err != nil {
+		return 0, errors.New("value is not an integer")
+	}
+	n++
+	entry.value = strconv.FormatInt(n, 10)
+	memStore.items[key] = entry
+	return n, nil
+}
+
+// TTL mengembalikan sisa waktu key; 0 jika tidak ada atau tanpa expiry
+func TTL(key string) (time.Duration, error) {
+	if Client != nil {
+		ttl, err := Client.TTL(Ctx, key).Result()
+		if err != nil {
+			return 0, err
+		}
+		if ttl < 0 {
+			return 0, nil
+		}
+		return ttl, nil
+	}
+
+	memStore.Lock()
+	defer memStore.Unlock()
+	entry, ok := memGetLocked(key)
+	if !ok || entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return time.Until(entry.expiresAt), nil
+}
+
+// memGetLocked membaca entry dan membuang yang sudah expired. Caller harus memegang lock.
+func memGetLocked(key string) (memoryEntry, bool) {
+	entry, ok := memStore.items[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(memStore.items, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// sweepExpiredLocked membuang semua entry expired, paling sering sekali per
+// memSweepInterval, agar key yang tidak pernah dibaca lagi (OTP, counter,
+// blacklist token) tidak menumpuk. Caller harus memegang lock.
+func sweepExpiredLocked() {
+	now := time.Now()
+	if now.Sub(memStore.lastSweep) < memSweepInterval {
+		return
+	}
+	memStore.lastSweep = now
+	for key, entry := range memStore.items {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(memStore.items, key)
+		}
+	}
+}
+
+// GetOtpFromMemoryOrRedis mengambil hash OTP yang tersimpan untuk email
+func GetOtpFromMemoryOrRedis(email string) string {
+	otp, found, err := Get("otp:" + email)
+	if err != nil || !found {
 		return "" // OTP tidak ditemukan
 	}
 	return otp
 }
 
-func StoreOtpInMemoryOrRedis(email, otp string) error {
-	key := "otp:" + email
-	err := Client.Set(Ctx, key, otp, 0).Err()
+// StoreOtpInMemoryOrRedis menyimpan hash OTP dengan TTL
+func StoreOtpInMemoryOrRedis(email, otpHash string, ttl time.Duration) error {
+	err := Set("otp:"+email, otpHash, ttl)
 	if err != nil {
 		fmt.Println("❌ Failed storing OTP:", err)
 		return err
@@ -44,17 +210,18 @@ func StoreOtpInMemoryOrRedis(email, otp string) error {
 	return nil
 }
 
+func DeleteOtp(email string) error {
+	return Del("otp:" + email)
+}
+
 func BlacklistToken(token string, ttl time.Duration) error {
-	return Client.Set(Ctx, "blacklist:"+token, "1", ttl).Err()
+	return Set("blacklist:"+token, "1", ttl)
 }
 
 func IsTokenBlacklisted(token string) (bool, error) {
-	val, err := Client.Get(Ctx, "blacklist:"+token).Result()
-	if err == redis.Nil {
-		return false, nil
-	}
+	val, found, err := Get("blacklist:" + token)
 	if err != nil {
 		return false, err
 	}
-	return val == "1", nil
+	return found && val == "1", nil
 }
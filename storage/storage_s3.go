@@ -3,15 +3,18 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type S3Storage struct {
@@ -91,3 +94,104 @@ func (s *S3Storage) DownloadS3File(fileName string) ([]byte, error) {
 
 	return body, nil
 }
+
+// OpenS3File membuka object sebagai stream; tidak ada object -> os.ErrNotExist
+func (s *S3Storage) OpenS3File(fileName string) (io.ReadCloser, error) {
+	resp, err := s.client.GetObject(s.ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &fileName,
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, os.ErrNotExist
+		}
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// PutS3Stream mengunggah object dari stream dengan ukuran yang sudah diketahui
+func (s *S3Storage) PutS3Stream(fileName string, body io.Reader, size int64, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket:        &s.bucket,
+		Key:           &fileName,
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err := s.client.PutObject(s.ctx, input)
+	return err
+}
+
+// HeadS3File mengembalikan ukuran dan content type object; tidak ada -> os.ErrNotExist
+func (s *S3Storage) HeadS3File(fileName string) (int64, string, error) {
+	resp, err := s.client.HeadObject(s.ctx, &s3.HeadObjectInput{
+		Bucket: &s.bucket,
+		Key:    &fileName,
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, "", os.ErrNotExist
+		}
+		return 0, "", err
+	}
+	return aws.ToInt64(resp.ContentLength), aws.ToString(resp.ContentType), nil
+}
+
+// DeleteS3File menghapus object
+func (s *S3Storage) DeleteS3File(fileName string) error {
+	_, err := s.client.DeleteObject(s.ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &fileName,
+	})
+	return err
+}
+
+// PresignGetS3 membuat URL GET sementara; contentType (opsional) menimpa
+// Content-Type response
+func (s *S3Storage) PresignGetS3(fileName string, ttl time.Duration, contentType string) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &fileName,
+	}
+	if contentType != "" {
+		input.ResponseContentType = aws.String(contentType)
+	}
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(s.ctx, input, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// PresignPutS3 membuat URL PUT sementara untuk object berukuran tepat size byte
+func (s *S3Storage) PresignPutS3(fileName string, size int64, ttl time.Duration) (string, error) {
+	req, err := s3.NewPresignClient(s.client).PresignPutObject(s.ctx, &s3.PutObjectInput{
+		Bucket:        &s.bucket,
+		Key:           &fileName,
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// CopyS3File menyalin object di dalam bucket (server-side) dengan content type baru
+func (s *S3Storage) CopyS3File(src, dst, contentType string) error {
+	input := &s3.CopyObjectInput{
+		Bucket:     &s.bucket,
+		Key:        &dst,
+		CopySource: aws.String(s.bucket + "/" + src),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+		input.MetadataDirective = types.MetadataDirectiveReplace
+	}
+	_, err := s.client.CopyObject(s.ctx, input)
+	return err
+}
@@ -7,24 +7,30 @@ import (
 
 func ConvertFromProto(p *pb.Block) models.Block {
 	return models.Block{
-		Hash:      p.Hash,
-		PrevHash:  p.PrevHash,
-		Index:     int(p.Index),
-		Timestamp: p.Timestamp,
-		Nonce:     int(p.Nonce),
+		Hash:       p.Hash,
+		PrevHash:   p.PrevHash,
+		MerkleRoot: p.MerkleRoot,
+		Index:      int(p.Index),
+		Timestamp:  p.Timestamp,
+		Nonce:      int(p.Nonce),
+		Encrypted:  p.Encrypted,
 		Transactions: func() []models.Tracker {
 			txs := make([]models.Tracker, len(p.Transactions))
 			for i, tx := range p.Transactions {
 				txs[i] = models.Tracker{
-					ID:             tx.Id,
-					Creator:        tx.Creator,
-					Type:           tx.Type,
-					Privacy:        tx.Privacy,
-					CreatorAddr:    tx.CreatorAddr,
-					CreatedAt:      tx.CreatedAt,
-					TargetEnd:      tx.TargetEnd,
-					Status:         tx.Status,
-					EncryptedNotes: tx.EncryptedNotes,
+					ID:               tx.Id,
+					Creator:          tx.Creator,
+					Type:             tx.Type,
+					Privacy:          tx.Privacy,
+					CreatorAddr:      tx.CreatorAddr,
+					CreatedAt:        tx.CreatedAt,
+					TargetEnd:        tx.TargetEnd,
+					Status:           tx.Status,
+					EncryptedNotes:   tx.EncryptedNotes,
+					EncryptedContent: tx.EncryptedContent,
+					Attachments:      attachmentsFromProto(tx.Attachments),
+					Document:         documentFromProto(tx.Document),
+					Template:         tx.Template,
 					Checkpoints: func() []models.Checkpoint {
 						checkpoints := make([]models.Checkpoint, len(tx.Checkpoints))
 						for j, cp := range tx.Checkpoints {
@@ -41,6 +47,11 @@ func ConvertFromProto(p *pb.Block) models.Block {
 								EvidencePath:  cp.EvidencePath,
 								IsCompleted:   cp.IsCompleted,
 								CompletedAt:   cp.CompletedAt,
+								DueAt:         cp.DueAt,
+								EvidenceKeys:  cp.EvidenceKeys,
+								EvidenceType:  cp.EvidenceType,
+								EvidenceSize:  cp.EvidenceSize,
+								Evidence:      evidenceItemsFromProto(cp.Evidence),
 							}
 						}
 						return checkpoints
@@ -54,24 +65,30 @@ func ConvertFromProto(p *pb.Block) models.Block {
 
 func ConvertToProto(b models.Block) *pb.Block {
 	return &pb.Block{
-		Hash:      b.Hash,
-		PrevHash:  b.PrevHash,
-		Index:     int32(b.Index),
-		Timestamp: b.Timestamp,
-		Nonce:     int32(b.Nonce),
+		Hash:       b.Hash,
+		PrevHash:   b.PrevHash,
+		MerkleRoot: b.MerkleRoot,
+		Index:      int32(b.Index),
+		Timestamp:  b.Timestamp,
+		Nonce:      int32(b.Nonce),
+		Encrypted:  b.Encrypted,
 		Transactions: func() []*pb.Tracker {
 			txs := make([]*pb.Tracker, len(b.Transactions))
 			for i, tx := range b.Transactions {
 				txs[i] = &pb.Tracker{
-					Id:             tx.ID,
-					Creator:        tx.Creator,
-					Type:           tx.Type,
-					Privacy:        tx.Privacy,
-					CreatorAddr:    tx.CreatorAddr,
-					CreatedAt:      tx.CreatedAt,
-					TargetEnd:      tx.TargetEnd,
-					Status:         tx.Status,
-					EncryptedNotes: tx.EncryptedNotes,
+					Id:               tx.ID,
+					Creator:          tx.Creator,
+					Type:             tx.Type,
+					Privacy:          tx.Privacy,
+					CreatorAddr:      tx.CreatorAddr,
+					CreatedAt:        tx.CreatedAt,
+					TargetEnd:        tx.TargetEnd,
+					Status:           tx.Status,
+					EncryptedNotes:   tx.EncryptedNotes,
+					EncryptedContent: tx.EncryptedContent,
+					Attachments:      attachmentsToProto(tx.Attachments),
+					Document:         documentToProto(tx.Document),
+					Template:         tx.Template,
 					Checkpoints: func() []*pb.Checkpoint {
 						checkpoints := make([]*pb.Checkpoint, len(tx.Checkpoints))
 						for j, cp := range tx.Checkpoints {
@@ -88,6 +105,11 @@ func ConvertToProto(b models.Block) *pb.Block {
 								EvidencePath:  cp.EvidencePath,
 								IsCompleted:   cp.IsCompleted,
 								CompletedAt:   cp.CompletedAt,
+								DueAt:         cp.DueAt,
+								EvidenceKeys:  cp.EvidenceKeys,
+								EvidenceType:  cp.EvidenceType,
+								EvidenceSize:  cp.EvidenceSize,
+								Evidence:      evidenceItemsToProto(cp.Evidence),
 							}
 						}
 						return checkpoints
@@ -113,11 +135,13 @@ func ConvertToProtoBlockList(blocks []models.Block) *pb.BlockList {
 
 func ConvertToProtoBlock(block models.Block) *pb.Block {
 	return &pb.Block{
-		Hash:      block.Hash,
-		PrevHash:  block.PrevHash,
-		Index:     int32(block.Index),
-		Timestamp: block.Timestamp,
-		Nonce:     int32(block.Nonce),
+		Hash:       block.Hash,
+		PrevHash:   block.PrevHash,
+		MerkleRoot: block.MerkleRoot,
+		Index:      int32(block.Index),
+		Timestamp:  block.Timestamp,
+		Nonce:      int32(block.Nonce),
+		Encrypted:  block.Encrypted,
 		Transactions: func() []*pb.Tracker {
 			txs := make([]*pb.Tracker, len(block.Transactions))
 			for i, tx := range block.Transactions {
@@ -139,6 +163,24 @@ func ConvertToProtoBlock(block models.Block) *pb.Block {
 	}
 }
 
+// ConvertToProtoHeaders mengubah header block untuk light client
+func ConvertToProtoHeaders(headers []models.BlockHeader) *pb.HeaderList {
+	list := &pb.HeaderList{Headers: make([]*pb.BlockHeader, len(headers))}
+	for i, h := range headers {
+		list.Headers[i] = &pb.BlockHeader{
+			Index:      int32(h.Index),
+			Timestamp:  h.Timestamp,
+			PrevHash:   h.PrevHash,
+			Hash:       h.Hash,
+			MerkleRoot: h.MerkleRoot,
+			Nonce:      int32(h.Nonce),
+			TxCount:    int32(h.TxCount),
+			Legacy:     h.Legacy,
+		}
+	}
+	return list
+}
+
 func ConvertToProtoCheckpoints(checkpoints []models.Checkpoint) []*pb.Checkpoint {
 	cpList := make([]*pb.Checkpoint, len(checkpoints))
 	for i, cp := range checkpoints {
@@ -155,7 +197,114 @@ func ConvertToProtoCheckpoints(checkpoints []models.Checkpoint) []*pb.Checkpoint
 			EvidencePath:  cp.EvidencePath,
 			IsCompleted:   cp.IsCompleted,
 			CompletedAt:   cp.CompletedAt,
+			DueAt:         cp.DueAt,
+			EvidenceKeys:  cp.EvidenceKeys,
+			EvidenceType:  cp.EvidenceType,
+			EvidenceSize:  cp.EvidenceSize,
+			Evidence:      evidenceItemsToProto(cp.Evidence),
 		}
 	}
 	return cpList
 }
+
+func attachmentsFromProto(list []*pb.Attachment) []models.Attachment {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]models.Attachment, len(list))
+	for i, a := range list {
+		out[i] = models.Attachment{
+			Name:        a.Name,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			Hash:        a.Hash,
+			Ciphertext:  a.Ciphertext,
+		}
+	}
+	return out
+}
+
+func attachmentsToProto(list []models.Attachment) []*pb.Attachment {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]*pb.Attachment, len(list))
+	for i, a := range list {
+		out[i] = &pb.Attachment{
+			Name:        a.Name,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			Hash:        a.Hash,
+			Ciphertext:  a.Ciphertext,
+		}
+	}
+	return out
+}
+
+func documentFromProto(d *pb.DocumentFingerprint) *models.DocumentFingerprint {
+	if d == nil {
+		return nil
+	}
+	return &models.DocumentFingerprint{
+		Hash:         d.Hash,
+		Name:         d.Name,
+		ContentType:  d.ContentType,
+		Size:         d.Size,
+		PageHashes:   d.PageHashes,
+		RegisteredAt: d.RegisteredAt,
+	}
+}
+
+func documentToProto(d *models.DocumentFingerprint) *pb.DocumentFingerprint {
+	if d == nil {
+		return nil
+	}
+	return &pb.DocumentFingerprint{
+		Hash:         d.Hash,
+		Name:         d.Name,
+		ContentType:  d.ContentType,
+		Size:         d.Size,
+		PageHashes:   d.PageHashes,
+		RegisteredAt: d.RegisteredAt,
+	}
+}
+
+func evidenceItemsToProto(items []models.EvidenceItem) []*pb.EvidenceItem {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]*pb.EvidenceItem, len(items))
+	for i, e := range items {
+		out[i] = &pb.EvidenceItem{
+			Hash:        e.Hash,
+			Path:        e.Path,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			Label:       e.Label,
+			Uploader:    e.Uploader,
+			UploadedAt:  e.UploadedAt,
+			Keys:        e.Keys,
+		}
+	}
+	return out
+}
+
+func evidenceItemsFromProto(items []*pb.EvidenceItem) []models.EvidenceItem {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]models.EvidenceItem, len(items))
+	for i, e := range items {
+		out[i] = models.EvidenceItem{
+			Hash:        e.Hash,
+			Path:        e.Path,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			Label:       e.Label,
+			Uploader:    e.Uploader,
+			UploadedAt:  e.UploadedAt,
+			Keys:        e.Keys,
+		}
+	}
+	return out
+}
@@ -37,6 +37,8 @@ func RandomID() string {
 	return hex.EncodeToString(b)
 }
 
+// EncryptWithPublicKey Deprecated: key AES diturunkan dari public key sehingga siapa pun
+// bisa mendekripsi. Gunakan EncryptNote; fungsi ini hanya untuk membaca/migrasi note lama.
 func EncryptWithPublicKey(plainText string, pub *ecdsa.PublicKey) string {
 	key := sha256.Sum256(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
 	block, _ := aes.NewCipher(key[:])
@@ -48,6 +50,7 @@ func EncryptWithPublicKey(plainText string, pub *ecdsa.PublicKey) string {
 	return base64.StdEncoding.EncodeToString(ciphertext)
 }
 
+// DecryptWithPrivateKey Deprecated: pasangan EncryptWithPublicKey, hanya untuk note lama
 func DecryptWithPrivateKey(cipherTextB64 string, priv *ecdsa.PrivateKey) string {
 	cipherText, _ := base64.StdEncoding.DecodeString(cipherTextB64)
 	key := sha256.Sum256(elliptic.Marshal(priv.Curve, priv.PublicKey.X, priv.PublicKey.Y))
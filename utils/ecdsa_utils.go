@@ -5,9 +5,15 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"math/big"
 	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 )
 
 func LoadECDSAPublicKey(path string) (*ecdsa.PublicKey, error) {
@@ -53,28 +59,77 @@ func LoadECDSAPrivateKey(path string) (*ecdsa.PrivateKey, error) {
 	return priv, nil
 }
 
-func CreatePemIfNotExists(path string) error {
-	// save
-	privKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+// ParsePublicKeyHex membaca public key dalam hex (uncompressed 04||X||Y atau compressed).
+// Key wallet secp256k1 dicoba dulu, lalu P-256 untuk wallet legacy.
+func ParsePublicKeyHex(pubHex string) (*ecdsa.PublicKey, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(pubHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key hex: %v", err)
+	}
+	if pub, err := secp256k1.ParsePubKey(data); err == nil {
+		return pub.ToECDSA(), nil
+	}
+
+	curve := elliptic.P256()
+	if len(data) == 33 {
+		x, y := elliptic.UnmarshalCompressed(curve, data)
+		if x == nil {
+			return nil, fmt.Errorf("invalid compressed public key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return DeserializePublicKey(data, curve)
+}
 
-	if _, err := os.Stat(path); !os.IsNotExist(err) {
-		return fmt.Errorf("file already exists: %s", path)
+// VerifySignatureHex memverifikasi signature ECDSA (DER atau r||s 64 byte, hex) atas hash
+func VerifySignatureHex(pub *ecdsa.PublicKey, hash []byte, sigHex string) bool {
+	sig, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+	if err != nil || pub == nil {
+		return false
+	}
+	if IsSecp256k1(pub) {
+		return verifySecp256k1(pub, hash, sig)
 	}
+	if len(sig) == 64 {
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, hash, r, s)
+	}
+	return ecdsa.VerifyASN1(pub, hash, sig)
+}
 
-	privBytes, _ := x509.MarshalECPrivateKey(privKey)
-	privPem := &pem.Block{
-		Type:  "EC PRIVATE KEY",
-		Bytes: privBytes,
+// SignHashHex menandatangani hash dan mengembalikan signature DER dalam hex
+func SignHashHex(priv *ecdsa.PrivateKey, hash []byte) (string, error) {
+	if IsSecp256k1(&priv.PublicKey) {
+		var d secp256k1.ModNScalar
+		d.SetByteSlice(priv.D.Bytes())
+		sig := secpecdsa.Sign(secp256k1.NewPrivateKey(&d), hash)
+		return hex.EncodeToString(sig.Serialize()), nil
+	}
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, hash)
+	if err != nil {
+		return "", err
 	}
-	_ = os.WriteFile("data/private.pem", pem.EncodeToMemory(privPem), 0600)
+	return hex.EncodeToString(sig), nil
+}
 
-	pubBytes, _ := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
-	pubPem := &pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: pubBytes,
+func verifySecp256k1(pub *ecdsa.PublicKey, hash, sig []byte) bool {
+	var x, y secp256k1.FieldVal
+	if x.SetByteSlice(pub.X.Bytes()) || y.SetByteSlice(pub.Y.Bytes()) {
+		return false
 	}
-	_ = os.WriteFile("data/public.pem", pem.EncodeToMemory(pubPem), 0644)
+	key := secp256k1.NewPublicKey(&x, &y)
 
-	println("✅ Kunci berhasil dibuat ke data/public.pem dan private.pem")
-	return nil
+	if len(sig) == 64 {
+		var r, s secp256k1.ModNScalar
+		if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+			return false
+		}
+		return secpecdsa.NewSignature(&r, &s).Verify(hash, key)
+	}
+	parsed, err := secpecdsa.ParseDERSignature(sig)
+	if err != nil {
+		return false
+	}
+	return parsed.Verify(hash, key)
 }
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ContentPrefix menandai konten tracker (note/attachment) terenkripsi content key.
+// Format: "aesgcm1:" + base64(nonce || ciphertext+tag)
+const ContentPrefix = "aesgcm1:"
+
+// NewContentKey membuat content key AES-256 acak untuk satu tracker
+func NewContentKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ContentAssociatedData mengikat konten ke tracker dan bagiannya ("note" atau "attachment:<nama>")
+func ContentAssociatedData(trackerID, part string) []byte {
+	return []byte("doctracker-content:" + trackerID + ":" + part)
+}
+
+// EnvelopeAssociatedData mengikat content key terbungkus ke tracker dan address penerima
+func EnvelopeAssociatedData(trackerID, address string) []byte {
+	return []byte("doctracker-envelope:" + trackerID + ":" + address)
+}
+
+// EncryptContent mengenkripsi konten tracker dengan content key
+func EncryptContent(key, plaintext, ad []byte) (string, error) {
+	ct, err := EncryptDataWithAD(key, plaintext, ad)
+	if err != nil {
+		return "", err
+	}
+	return ContentPrefix + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// DecryptContent membuka hasil EncryptContent
+func DecryptContent(key []byte, encoded string, ad []byte) ([]byte, error) {
+	if !strings.HasPrefix(encoded, ContentPrefix) {
+		return nil, errors.New("unsupported content format")
+	}
+	ct, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, ContentPrefix))
+	if err != nil {
+		return nil, err
+	}
+	pt, err := DecryptDataWithAD(key, ct, ad)
+	if err != nil {
+		return nil, ErrNoteDecrypt
+	}
+	return pt, nil
+}
+
+// WrapContentKey membungkus content key untuk public key enkripsi penerima (skema EncryptNote)
+func WrapContentKey(key []byte, pub *ecdsa.PublicKey, trackerID, address string) (string, error) {
+	return EncryptNote(base64.StdEncoding.EncodeToString(key), pub, EnvelopeAssociatedData(trackerID, address))
+}
+
+// UnwrapContentKey membuka content key dari envelope milik address
+func UnwrapContentKey(wrapped string, priv *ecdsa.PrivateKey, trackerID, address string) ([]byte, error) {
+	encoded, err := DecryptNote(wrapped, priv, EnvelopeAssociatedData(trackerID, address))
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, ErrNoteDecrypt
+	}
+	return key, nil
+}
+
+// EvidenceKeyAssociatedData mengikat key evidence terbungkus ke checkpoint dan address penerima
+func EvidenceKeyAssociatedData(trackerID, checkpointAddr, address string) []byte {
+	return []byte("doctracker-evidence-key:" + trackerID + ":" + checkpointAddr + ":" + address)
+}
+
+// WrapEvidenceKey membungkus key evidence checkpoint untuk public key enkripsi penerima
+func WrapEvidenceKey(key []byte, pub *ecdsa.PublicKey, trackerID, checkpointAddr, address string) (string, error) {
+	return EncryptNote(base64.StdEncoding.EncodeToString(key), pub, EvidenceKeyAssociatedData(trackerID, checkpointAddr, address))
+}
+
+// UnwrapEvidenceKey membuka key evidence dari envelope milik address
+func UnwrapEvidenceKey(wrapped string, priv *ecdsa.PrivateKey, trackerID, checkpointAddr, address string) ([]byte, error) {
+	encoded, err := DecryptNote(wrapped, priv, EvidenceKeyAssociatedData(trackerID, checkpointAddr, address))
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, ErrNoteDecrypt
+	}
+	return key, nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	bip32 "github.com/tyler-smith/go-bip32"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// Wallet diturunkan dengan path BIP-44 di kurva secp256k1 (kurva yang dipakai BIP-32):
+//
+//	m/44'/HDCoinType'/account'/purpose/index
+//
+// Level "change" BIP-44 dipakai sebagai pemisah purpose subkey.
+const (
+	HDPurpose  uint32 = 44
+	HDCoinType uint32 = 4476739 // "DOC", tidak terdaftar di SLIP-44
+	HDAccount  uint32 = 0
+)
+
+// KeyPurpose membedakan subkey wallet
+type KeyPurpose uint32
+
+const (
+	KeyPurposeSigning    KeyPurpose = 0 // signature transaksi dan login
+	KeyPurposeEncryption KeyPurpose = 1 // enkripsi note checkpoint
+	KeyPurposeTracker    KeyPurpose = 2 // key per tracker, index dari TrackerKeyIndex
+)
+
+var ErrInvalidDerivedKey = errors.New("derived key is not a valid secp256k1 scalar")
+
+// DerivationPath mengembalikan path BIP-44 untuk purpose dan index
+func DerivationPath(purpose KeyPurpose, index uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d", HDPurpose, HDCoinType, HDAccount, purpose, index)
+}
+
+// DeriveKey menurunkan subkey secp256k1 dari mnemonic sesuai DerivationPath
+func DeriveKey(mnemonic string, purpose KeyPurpose, index uint32) (*ecdsa.PrivateKey, error) {
+	if index >= bip32.FirstHardenedChild {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+
+	seed := bip39.NewSeed(mnemonic, "")
+	key, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	path := []uint32{
+		HDPurpose + bip32.FirstHardenedChild,
+		HDCoinType + bip32.FirstHardenedChild,
+		HDAccount + bip32.FirstHardenedChild,
+		uint32(purpose),
+		index,
+	}
+	for _, idx := range path {
+		key, err = key.NewChildKey(idx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return secp256k1PrivateKey(key.Key)
+}
+
+// DeriveTrackerKey menurunkan subkey khusus satu tracker
+func DeriveTrackerKey(mnemonic, trackerID string) (*ecdsa.PrivateKey, error) {
+	return DeriveKey(mnemonic, KeyPurposeTracker, TrackerKeyIndex(trackerID))
+}
+
+// TrackerKeyIndex memetakan ID tracker ke index non-hardened secara deterministik
+func TrackerKeyIndex(trackerID string) uint32 {
+	sum := sha256.Sum256([]byte("doctracker-tracker-key:" + trackerID))
+	return binary.BigEndian.Uint32(sum[:4]) &^ bip32.FirstHardenedChild
+}
+
+// EncryptionKeyFromMnemonic menurunkan subkey enkripsi note
+func EncryptionKeyFromMnemonic(mnemonic string) (*ecdsa.PrivateKey, error) {
+	return DeriveKey(mnemonic, KeyPurposeEncryption, 0)
+}
+
+// IsSecp256k1 true jika public key berada di kurva secp256k1
+func IsSecp256k1(pub *ecdsa.PublicKey) bool {
+	return pub != nil && pub.Curve == secp256k1.S256()
+}
+
+// secp256k1PrivateKey memvalidasi scalar (0 < d < N) lalu mengubahnya ke *ecdsa.PrivateKey
+func secp256k1PrivateKey(b []byte) (*ecdsa.PrivateKey, error) {
+	if len(b) != 32 {
+		return nil, ErrInvalidDerivedKey
+	}
+	var s secp256k1.ModNScalar
+	if overflow := s.SetByteSlice(b); overflow || s.IsZero() {
+		return nil, ErrInvalidDerivedKey
+	}
+
+	priv := secp256k1.NewPrivateKey(&s).ToECDSA()
+	if !priv.Curve.IsOnCurve(priv.X, priv.Y) {
+		return nil, ErrInvalidDerivedKey
+	}
+	return priv, nil
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+// NotePrefix menandai note terenkripsi dengan skema ECIES saat ini.
+//
+// Format: "ecies1:" + base64(ephemeralPub || nonce || ciphertext+tag)
+//   - ephemeralPub : 0x04||X||Y di kurva yang sama dengan key penerima
+//   - key AES-256  : HKDF-SHA256(ECDH x, salt = ephemeralPub||recipientPub, info = noteHKDFInfo)
+//   - AES-GCM dengan associated data dari NoteAssociatedData
+//
+// Client yang memegang key sendiri (wallet non-custodial) bisa mendekripsi
+// dengan langkah yang sama.
+const NotePrefix = "ecies1:"
+
+const noteHKDFInfo = "doctracker-note:v1"
+
+var ErrNoteDecrypt = errors.New("note decryption failed")
+
+// NoteAssociatedData mengikat ciphertext note ke tracker dan address checkpoint
+func NoteAssociatedData(trackerID, address string) []byte {
+	return []byte("doctracker-note:" + trackerID + ":" + address)
+}
+
+// IsLegacyNote true jika note dienkripsi dengan EncryptWithPublicKey (skema lama)
+func IsLegacyNote(encoded string) bool {
+	return encoded != "" && !strings.HasPrefix(encoded, NotePrefix)
+}
+
+// EncryptNote mengenkripsi note untuk public key penerima
+func EncryptNote(plaintext string, pub *ecdsa.PublicKey, ad []byte) (string, error) {
+	if pub == nil {
+		return "", errors.New("public key is nil")
+	}
+
+	ephemeral, err := generateKeyOnCurve(pub.Curve)
+	if err != nil {
+		return "", err
+	}
+	ephemeralPub := SerializePublicKey(&ephemeral.PublicKey)
+
+	key, err := noteKey(ephemeral.D, pub, ephemeralPub, SerializePublicKey(pub))
+	if err != nil {
+		return "", err
+	}
+	ct, err := EncryptDataWithAD(key, []byte(plaintext), ad)
+	if err != nil {
+		return "", err
+	}
+
+	return NotePrefix + base64.StdEncoding.EncodeToString(append(ephemeralPub, ct...)), nil
+}
+
+// DecryptNote membuka hasil EncryptNote dengan private key penerima
+func DecryptNote(encoded string, priv *ecdsa.PrivateKey, ad []byte) (string, error) {
+	if priv == nil {
+		return "", errors.New("private key is nil")
+	}
+	if !strings.HasPrefix(encoded, NotePrefix) {
+		return "", fmt.Errorf("unsupported note format")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, NotePrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid note encoding: %v", err)
+	}
+
+	keyLen := (priv.Curve.Params().BitSize + 7) / 8
+	pubLen := 1 + 2*keyLen
+	if len(data) <= pubLen {
+		return "", ErrNoteDecrypt
+	}
+	ephemeral, err := DeserializePublicKey(data[:pubLen], priv.Curve)
+	if err != nil {
+		return "", ErrNoteDecrypt
+	}
+
+	key, err := noteKey(priv.D, ephemeral, data[:pubLen], SerializePublicKey(&priv.PublicKey))
+	if err != nil {
+		return "", err
+	}
+	pt, err := DecryptDataWithAD(key, data[pubLen:], ad)
+	if err != nil {
+		return "", ErrNoteDecrypt
+	}
+	return string(pt), nil
+}
+
+// noteKey: ECDH(d, peer) lalu HKDF. salt selalu ephemeralPub||recipientPub
+// sehingga pengirim (d = ephemeral) dan penerima (d = recipient) mendapat key sama.
+func noteKey(d *big.Int, peer *ecdsa.PublicKey, ephemeralPub, recipientPub []byte) ([]byte, error) {
+	x, _ := peer.Curve.ScalarMult(peer.X, peer.Y, d.Bytes())
+	if x == nil || x.Sign() == 0 {
+		return nil, errors.New("failed to derive shared secret")
+	}
+	shared := x.FillBytes(make([]byte, (peer.Curve.Params().BitSize+7)/8))
+	return hkdfKey(shared, ephemeralPub, recipientPub)
+}
+
+func hkdfKey(shared, ephemeralPub, recipientPub []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(noteHKDFInfo)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func generateKeyOnCurve(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	if curve == secp256k1.S256() {
+		k, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		return k.ToECDSA(), nil
+	}
+	return ecdsa.GenerateKey(curve, rand.Reader)
+}
+
+// DecryptLegacyNote membuka note skema lama (AES-CFB, key = SHA-256 public key).
+// Cukup public key penerima, dipakai untuk migrasi dan note di block lama.
+func DecryptLegacyNote(cipherTextB64 string, pub *ecdsa.PublicKey) (string, error) {
+	ct, err := base64.StdEncoding.DecodeString(cipherTextB64)
+	if err != nil || len(ct) < aes.BlockSize || pub == nil {
+		return "", ErrNoteDecrypt
+	}
+	key := sha256.Sum256(elliptic.Marshal(pub.Curve, pub.X, pub.Y))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	pt := make([]byte, len(ct)-aes.BlockSize)
+	cipher.NewCFBDecrypter(block, ct[:aes.BlockSize]).XORKeyStream(pt, ct[aes.BlockSize:])
+	return string(pt), nil
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PDFFont font standar PDF (tidak perlu di-embed)
+type PDFFont int
+
+const (
+	FontRegular PDFFont = iota // Helvetica
+	FontBold                   // Helvetica-Bold
+	FontMono                   // Courier
+)
+
+// PDF penulis PDF minimal (teks, garis, kotak, lampiran) untuk label dan
+// sertifikat. Koordinat dalam point (1/72 inch) dengan titik 0,0 di kiri atas.
+type PDF struct {
+	pages       []*PDFPage
+	attachments []pdfAttachment
+}
+
+type pdfAttachment struct {
+	name, description string
+	data              []byte
+}
+
+// PDFPage satu halaman PDF
+type PDFPage struct {
+	Width, Height float64
+	content       bytes.Buffer
+}
+
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// AddPage menambah halaman berukuran width x height point
+func (d *PDF) AddPage(width, height float64) *PDFPage {
+	p := &PDFPage{Width: width, Height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Attach menyematkan file (embedded file) yang bisa diekstrak dari PDF viewer
+func (d *PDF) Attach(name, description string, data []byte) {
+	d.attachments = append(d.attachments, pdfAttachment{name: name, description: description, data: data})
+}
+
+// Fill mengatur warna isi (0..1) untuk Rect dan Text berikutnya
+func (p *PDFPage) Fill(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", pdfNum(r), pdfNum(g), pdfNum(b))
+}
+
+// Stroke mengatur warna dan tebal garis
+func (p *PDFPage) Stroke(r, g, b, width float64) {
+	fmt.Fprintf(&p.content, "%s %s %s RG %s w\n", pdfNum(r), pdfNum(g), pdfNum(b), pdfNum(width))
+}
+
+// Rect kotak terisi warna Fill
+func (p *PDFPage) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", pdfNum(x), pdfNum(p.Height-y-h), pdfNum(w), pdfNum(h))
+}
+
+// StrokeRect garis tepi kotak
+func (p *PDFPage) StrokeRect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re S\n", pdfNum(x), pdfNum(p.Height-y-h), pdfNum(w), pdfNum(h))
+}
+
+// Line garis lurus dengan warna Stroke
+func (p *PDFPage) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", pdfNum(x1), pdfNum(p.Height-y1), pdfNum(x2), pdfNum(p.Height-y2))
+}
+
+// Text menulis teks satu baris; y adalah baseline
+func (p *PDFPage) Text(x, y float64, font PDFFont, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n", int(font)+1, pdfNum(size), pdfNum(x), pdfNum(p.Height-y), pdfEscape(s))
+}
+
+// TextCenter menulis teks di tengah antara x dan x+w
+func (p *PDFPage) TextCenter(x, w, y float64, font PDFFont, size float64, s string) {
+	p.Text(x+(w-TextWidth(font, size, s))/2, y, font, size, s)
+}
+
+// Bytes menyusun file PDF lengkap
+func (d *PDF) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) int {
+		offsets = append(offsets, buf.Len())
+		n := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n%s", n, body)
+		if stream != nil {
+			buf.WriteString("\nstream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream")
+		}
+		buf.WriteString("\nendobj\n")
+		return n
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	// 1: catalog, 2: pages; ditulis di akhir setelah semua objek lain
+	offsets = append(offsets, 0, 0)
+
+	fonts := ""
+	for i, name := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		n := obj("<< /Type /Font /Subtype /Type1 /BaseFont /"+name+" /Encoding /WinAnsiEncoding >>", nil)
+		fonts += fmt.Sprintf(" /F%d %d 0 R", i+1, n)
+	}
+
+	var kids []string
+	for _, p := range d.pages {
+		content := p.content.Bytes()
+		c := obj(fmt.Sprintf("<< /Length %d >>", len(content)), content)
+		n := obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font <<%s >> >> /Contents %d 0 R >>",
+			pdfNum(p.Width), pdfNum(p.Height), fonts, c), nil)
+		kids = append(kids, fmt.Sprintf("%d 0 R", n))
+	}
+
+	// Nama embedded file harus urut agar name tree valid
+	attachments := append([]pdfAttachment(nil), d.attachments...)
+	sort.SliceStable(attachments, func(i, j int) bool { return attachments[i].name < attachments[j].name })
+	var names []string
+	for _, a := range attachments {
+		f := obj(fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>", len(a.data)), a.data)
+		spec := obj(fmt.Sprintf("<< /Type /Filespec /F (%s) /UF (%s) /Desc (%s) /EF << /F %d 0 R >> >>",
+			pdfEscape(a.name), pdfEscape(a.name), pdfEscape(a.description), f), nil)
+		names = append(names, fmt.Sprintf("(%s) %d 0 R", pdfEscape(a.name), spec))
+	}
+
+	catalog := "<< /Type /Catalog /Pages 2 0 R"
+	if len(names) > 0 {
+		catalog += " /Names << /EmbeddedFiles << /Names [" + strings.Join(names, " ") + "] >> >> /PageMode /UseAttachments"
+	}
+	offsets[0] = buf.Len()
+	fmt.Fprintf(&buf, "1 0 obj\n%s >>\nendobj\n", catalog)
+	offsets[1] = buf.Len()
+	fmt.Fprintf(&buf, "2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(kids))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// TextWidth lebar teks dalam point untuk font standar
+func TextWidth(font PDFFont, size float64, s string) float64 {
+	total := 0
+	for _, r := range s {
+		switch {
+		case font == FontMono:
+			total += 600
+		case r < 32 || r > 126:
+			total += 556
+		case font == FontBold:
+			total += helveticaBoldWidths[r-32]
+		default:
+			total += helveticaWidths[r-32]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfEscape teks untuk string literal PDF; karakter di luar Latin-1 diganti '?'
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func pdfNum(f float64) string {
+	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", f), "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Lebar glyph ASCII 32..126 dari metrik standar Adobe (per 1000 unit)
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -1,7 +1,18 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
 	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
 )
 
 func GenerateQRCode(data string) ([]byte, error) {
@@ -12,3 +23,178 @@ func GenerateQRCode(data string) ([]byte, error) {
 	}
 	return png, nil
 }
+
+// QRLabel label cetak berisi QR dan beberapa baris teks di bawahnya
+type QRLabel struct {
+	Content string   // isi QR (URL verifikasi)
+	Title   string   // baris tebal di bawah QR
+	Lines   []string // baris kecil setelah judul
+}
+
+// qrModules matriks QR termasuk quiet zone; true = modul hitam
+func (l QRLabel) qrModules() ([][]bool, error) {
+	q, err := qrcode.New(l.Content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	return q.Bitmap(), nil
+}
+
+// PNG merender label selebar width pixel
+func (l QRLabel) PNG(width int) ([]byte, error) {
+	modules, err := l.qrModules()
+	if err != nil {
+		return nil, err
+	}
+	scale := width / len(modules)
+	if scale < 1 {
+		scale = 1
+	}
+	qrSide := scale * len(modules)
+
+	// Teks memakai font bitmap 7x13 yang diperbesar agar tetap terbaca saat dicetak
+	textScale := width / 256
+	if textScale < 1 {
+		textScale = 1
+	}
+	lineHeight := 16 * textScale
+	height := qrSide + lineHeight*(len(l.Lines)+1) + 8*textScale
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	offset := (width - qrSide) / 2
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				r := image.Rect(offset+x*scale, y*scale, offset+(x+1)*scale, (y+1)*scale)
+				draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	y := qrSide
+	for i, line := range append([]string{l.Title}, l.Lines...) {
+		c := color.Color(color.Gray{Y: 0x40})
+		if i == 0 {
+			c = color.Black
+		}
+		drawBitmapText(img, line, y, textScale, c)
+		y += lineHeight
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawBitmapText menulis teks di tengah horizontal pada baris y (atas) dengan skala nearest-neighbour
+func drawBitmapText(dst *image.RGBA, s string, y, scale int, c color.Color) {
+	face := basicfont.Face7x13
+	// Baris panjang (mis. ID tracker) diperkecil dulu sebelum dipotong
+	for scale > 1 && len(s)*face.Advance*scale > dst.Bounds().Dx() {
+		scale--
+	}
+	maxChars := dst.Bounds().Dx() / face.Advance
+	if len(s) > maxChars && maxChars > 3 {
+		s = s[:maxChars-3] + "..."
+	}
+	w := font.MeasureString(face, s).Ceil()
+	if w == 0 {
+		return
+	}
+	small := image.NewRGBA(image.Rect(0, 0, w, 16))
+	draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+	d := font.Drawer{Dst: small, Src: image.NewUniform(c), Face: face, Dot: fixed.P(0, face.Ascent+1)}
+	d.DrawString(s)
+
+	x := (dst.Bounds().Dx() - w*scale) / 2
+	target := image.Rect(x, y, x+w*scale, y+16*scale)
+	draw.NearestNeighbor.Scale(dst, target, small, small.Bounds(), draw.Src, nil)
+}
+
+// qrRuns memanggil fn untuk tiap deretan n modul hitam berurutan dalam satu
+// baris, agar output vektor tidak berisi satu kotak per modul
+func qrRuns(modules [][]bool, fn func(x, y, n int)) {
+	for y, row := range modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			fn(start, y, x-start+1)
+		}
+	}
+}
+
+// SVG merender label sebagai SVG vektor (satu modul QR = 1 unit = 1mm)
+func (l QRLabel) SVG() ([]byte, error) {
+	modules, err := l.qrModules()
+	if err != nil {
+		return nil, err
+	}
+	side := len(modules)
+	lineHeight := 3.0
+	height := float64(side) + lineHeight*float64(len(l.Lines)+1) + 1
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %s" width="%dmm" height="%smm" shape-rendering="crispEdges">`+"\n",
+		side, pdfNum(height), side, pdfNum(height))
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#fff"/>`+"\n<path fill=\"#000\" d=\"")
+	qrRuns(modules, func(x, y, n int) {
+		fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x, y, n, n)
+	})
+	buf.WriteString("\"/>\n")
+
+	y := float64(side) + 1
+	for i, line := range append([]string{l.Title}, l.Lines...) {
+		size, weight, family := 2.0, "normal", "monospace"
+		if i == 0 {
+			size, weight, family = 2.6, "bold", "sans-serif"
+		}
+		fmt.Fprintf(&buf, `<text x="%s" y="%s" font-family="%s" font-size="%s" font-weight="%s" text-anchor="middle">`,
+			pdfNum(float64(side)/2), pdfNum(y), family, pdfNum(size), weight)
+		xml.EscapeText(&buf, []byte(line))
+		buf.WriteString("</text>\n")
+		y += lineHeight
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// PDF merender label satu halaman selebar widthPt point dengan QR vektor
+func (l QRLabel) PDF(widthPt float64) ([]byte, error) {
+	modules, err := l.qrModules()
+	if err != nil {
+		return nil, err
+	}
+	module := widthPt / float64(len(modules))
+	qrSide := module * float64(len(modules))
+	lineHeight := 12.0
+	height := qrSide + lineHeight*float64(len(l.Lines)+1) + 10
+
+	doc := NewPDF()
+	page := doc.AddPage(widthPt, height)
+	page.Fill(0, 0, 0)
+	qrRuns(modules, func(x, y, n int) {
+		page.Rect(float64(x)*module, float64(y)*module, float64(n)*module, module)
+	})
+
+	y := qrSide + 4
+	page.TextCenter(0, widthPt, y, FontBold, 10, l.Title)
+	page.Fill(0.25, 0.25, 0.25)
+	for _, line := range l.Lines {
+		y += lineHeight
+		size := 7.0
+		for size > 4 && TextWidth(FontMono, size, line) > widthPt-8 {
+			size -= 0.5
+		}
+		page.TextCenter(0, widthPt, y, FontMono, size, line)
+	}
+	return doc.Bytes(), nil
+}
@@ -21,17 +21,34 @@ func GenerateMnemonic() string {
 	return mnemonic
 }
 
-// PrivateKeyFromMnemonic returns ECDSA key from mnemonic
+// PrivateKeyFromMnemonic returns the secp256k1 signing key (DerivationPath
+// KeyPurposeSigning, index 0) and its address
 func PrivateKeyFromMnemonic(mnemonic string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, string) {
+	priv, err := DeriveKey(mnemonic, KeyPurposeSigning, 0)
+	if err != nil {
+		log.Printf("Failed to derive signing key: %v", err)
+		return nil, nil, ""
+	}
+	return priv, &priv.PublicKey, PublicKeyToAddress(&priv.PublicKey)
+}
+
+// LegacyPrivateKeyFromMnemonic returns the key derived the old way: byte child 0
+// dari master BIP-32 dipakai sebagai scalar P-256. Hanya untuk mode kompatibilitas
+// address lama, jangan dipakai untuk wallet baru.
+func LegacyPrivateKeyFromMnemonic(mnemonic string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, string) {
 	seed := bip39.NewSeed(mnemonic, "")
 	masterKey, err := bip32.NewMasterKey(seed)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Failed to derive legacy key: %v", err)
+		return nil, nil, ""
 	}
 
-	key, _ := masterKey.NewChildKey(0)
-	priv := key.Key
-	x, y := elliptic.P256().ScalarBaseMult(priv)
+	key, err := masterKey.NewChildKey(0)
+	if err != nil {
+		log.Printf("Failed to derive legacy key: %v", err)
+		return nil, nil, ""
+	}
+	x, y := elliptic.P256().ScalarBaseMult(key.Key)
 	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
 
 	address := PublicKeyToAddress(pub)